@@ -9,6 +9,7 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/zdyxry/tokui/codeowners"
 	"github.com/zdyxry/tokui/provider"
 	"github.com/zdyxry/tokui/provider/scc"
 	"github.com/zdyxry/tokui/render"
@@ -20,11 +21,16 @@ import (
 )
 
 var (
-	ErrUnknown  = errors.New("unknown error")
-	root        string
-	treeMode    bool
-	treemapMode bool
+	ErrUnknown   = errors.New("unknown error")
+	root         string
+	treeMode     bool
+	treemapMode  bool
 	providerName string
+	testPatterns []string
+	ownersFile   string
+	churnSince   string
+	minLines     int64
+	presetName   string
 
 	appCmd = &cobra.Command{
 		Use:   "tokui [directory]",
@@ -81,6 +87,36 @@ func init() {
 		"tokei",
 		`Stats provider: tokei|scc. Defaults to "tokei"; can be overridden with the TOKUI_PROVIDER environment variable.`,
 	)
+	appCmd.PersistentFlags().StringSliceVar(
+		&testPatterns,
+		"test-patterns",
+		nil,
+		`Comma-separated patterns that classify files as test code, replacing the built-in conventions. Patterns ending in "/" match directories; others are file name globs. Can also be set with the TOKUI_TEST_PATTERNS environment variable.`,
+	)
+	appCmd.PersistentFlags().StringVar(
+		&ownersFile,
+		"codeowners",
+		"",
+		`Path to a CODEOWNERS file. Defaults to CODEOWNERS, .github/CODEOWNERS, .gitlab/CODEOWNERS or docs/CODEOWNERS under the analysis root.`,
+	)
+	appCmd.PersistentFlags().StringVar(
+		&churnSince,
+		"since",
+		"6.months",
+		`Time window of git history used for churn and hotspot analysis (any date git understands, e.g. "6.months" or "2024-01-01"). An empty value disables it.`,
+	)
+	appCmd.PersistentFlags().Int64Var(
+		&minLines,
+		"min-lines",
+		0,
+		`Fold rows with fewer total lines into a single "(N smaller entries)" row. Press z to cycle folding thresholds.`,
+	)
+	appCmd.PersistentFlags().StringVar(
+		&presetName,
+		"preset",
+		"",
+		`Apply a view preset saved with Ctrl+S in the analyzed repository.`,
+	)
 	appCmd.MarkFlagsMutuallyExclusive("tree", "treemap")
 }
 
@@ -139,7 +175,6 @@ func resolveVersion(version string) string {
 	return "dev"
 }
 
-
 func runApp(cmd *cobra.Command, args []string) error {
 	defer func() {
 		if r := recover(); r != nil {
@@ -164,6 +199,10 @@ func runApp(cmd *cobra.Command, args []string) error {
 	}
 
 	tree := structure.NewTree(nil)
+	tree.SetTestMatcher(resolveTestMatcher(cmd))
+
+	// Pipe mode has no explicit analysis root and uses the current directory.
+	ownersRoot := "."
 
 	// If there is pipe input, use pipe mode
 	if (stat.Mode() & os.ModeCharDevice) == 0 {
@@ -176,6 +215,7 @@ func runApp(cmd *cobra.Command, args []string) error {
 			root = args[0]
 		}
 		analysisPath := filepath.Clean(root)
+		ownersRoot = analysisPath
 
 		// Validate the path before shelling out to the provider so users get a
 		// clear message instead of a raw provider failure and stack trace.
@@ -209,8 +249,12 @@ func runApp(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if err := applyCodeowners(tree, ownersRoot); err != nil {
+		return err
+	}
+
 	// Initialize view model
-	vm, err := initViewModel(tree, p.Info(), treeMode, treemapMode)
+	vm, err := initViewModel(tree, p.Info(), treeMode, treemapMode, churnSince, minLines, presetName)
 	if err != nil {
 		return err
 	}
@@ -255,6 +299,42 @@ func resolveProvider(cmd *cobra.Command) string {
 	return "tokei"
 }
 
+// resolveTestMatcher returns the test code classifier using the precedence:
+// 1. Explicit --test-patterns flag, 2. TOKUI_TEST_PATTERNS environment
+// variable, 3. the built-in conventions.
+func resolveTestMatcher(cmd *cobra.Command) *structure.TestMatcher {
+	if cmd.Flags().Changed("test-patterns") {
+		return structure.NewTestMatcher(testPatterns)
+	}
+	if env := os.Getenv("TOKUI_TEST_PATTERNS"); env != "" {
+		return structure.NewTestMatcher(strings.Split(env, ","))
+	}
+	return structure.DefaultTestMatcher()
+}
+
+// applyCodeowners attributes the tree's files to the owners declared in the
+// CODEOWNERS file given by --codeowners or found under root. A missing file is
+// not an error: ownership views are simply unavailable.
+func applyCodeowners(tree *structure.Tree, root string) error {
+	var (
+		rs  *codeowners.Ruleset
+		err error
+	)
+	if ownersFile != "" {
+		rs, err = codeowners.LoadFile(ownersFile)
+	} else {
+		rs, _, err = codeowners.Load(root)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load CODEOWNERS: %w", err)
+	}
+	tree.ApplyOwners(rs)
+	return nil
+}
+
 // runPipeMode reads stdin once and either uses the selected provider or
 // attempts to auto-detect the format.
 func runPipeMode(tree *structure.Tree, p provider.Provider, explicitProvider string) error {
@@ -307,9 +387,13 @@ func parseStdinWithProvider(p provider.Provider, data []byte, explicitProvider s
 	)
 }
 
-func initViewModel(tree *structure.Tree, info provider.Info, treeMode, treemapMode bool) (*render.ViewModel, error) {
+func initViewModel(tree *structure.Tree, info provider.Info, treeMode, treemapMode bool, since string, minLines int64, preset string) (*render.ViewModel, error) {
 	nav := render.NewCodeNavigation(tree)
 	dirModel := render.NewDirModel(nav, info, treeMode, treemapMode)
+	dirModel.SetChurnWindow(since)
+	dirModel.SetMinLines(minLines)
+	dirModel.SetStartPreset(preset)
+	dirModel.EnableFileTimes()
 	vm := render.NewViewModel(
 		nav,
 		dirModel,
@@ -0,0 +1,254 @@
+// Package codeowners parses CODEOWNERS files in GitHub and GitLab syntax and
+// resolves the owners of paths relative to the repository root.
+package codeowners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Locations lists the paths, relative to the repository root, where a
+// CODEOWNERS file is looked up, in order of precedence.
+var Locations = []string{
+	"CODEOWNERS",
+	".github/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// Rule is a single pattern line of a CODEOWNERS file.
+type Rule struct {
+	Pattern string
+	Owners  []string
+	Line    int
+	re      *regexp.Regexp
+}
+
+// Section groups rules. GitHub files have a single unnamed section; GitLab
+// files may declare named sections, each resolved independently.
+type Section struct {
+	Name          string
+	DefaultOwners []string
+	Rules         []Rule
+}
+
+// Ruleset is a parsed CODEOWNERS file.
+type Ruleset struct {
+	Sections []*Section
+}
+
+// sectionHeaderRegexp matches GitLab section headers such as "[Docs]",
+// "^[Optional][2] @team" or "[Backend] @alice @bob".
+var sectionHeaderRegexp = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?\s*(.*)$`)
+
+// Parse reads a CODEOWNERS file. Malformed patterns are reported with their
+// line number.
+func Parse(r io.Reader) (*Ruleset, error) {
+	rs := &Ruleset{Sections: []*Section{{}}}
+	current := rs.Sections[0]
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if m := sectionHeaderRegexp.FindStringSubmatch(line); m != nil {
+			current = rs.section(strings.TrimSpace(m[1]))
+			current.DefaultOwners = strings.Fields(m[2])
+			continue
+		}
+
+		fields := splitFields(line)
+		pattern := fields[0]
+		re, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", lineNo, pattern, err)
+		}
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = current.DefaultOwners
+		}
+		current.Rules = append(current.Rules, Rule{
+			Pattern: pattern,
+			Owners:  owners,
+			Line:    lineNo,
+			re:      re,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// section returns the section with the given name, creating it if needed.
+// GitLab merges sections that share a name (case-insensitively).
+func (rs *Ruleset) section(name string) *Section {
+	for _, s := range rs.Sections {
+		if strings.EqualFold(s.Name, name) {
+			return s
+		}
+	}
+	s := &Section{Name: name}
+	rs.Sections = append(rs.Sections, s)
+	return s
+}
+
+// Owners returns the owners of the slash-separated path relative to the
+// repository root. Within a section the last matching rule wins; owners from
+// different sections are combined. A nil result means the path is unowned.
+func (rs *Ruleset) Owners(relPath string) []string {
+	if rs == nil {
+		return nil
+	}
+	relPath = strings.TrimPrefix(filepath.ToSlash(relPath), "./")
+
+	var owners []string
+	seen := make(map[string]bool)
+	for _, s := range rs.Sections {
+		for i := len(s.Rules) - 1; i >= 0; i-- {
+			if !s.Rules[i].re.MatchString(relPath) {
+				continue
+			}
+			for _, o := range s.Rules[i].Owners {
+				if !seen[o] {
+					seen[o] = true
+					owners = append(owners, o)
+				}
+			}
+			break
+		}
+	}
+	return owners
+}
+
+// Load looks for a CODEOWNERS file in the standard locations under root and
+// parses the first one found. It returns the path of the file that was used.
+// A missing file is reported as an error wrapping os.ErrNotExist.
+func Load(root string) (*Ruleset, string, error) {
+	for _, loc := range Locations {
+		p := filepath.Join(root, filepath.FromSlash(loc))
+		rs, err := LoadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return rs, p, err
+	}
+	return nil, "", fmt.Errorf("no CODEOWNERS file under %q: %w", root, os.ErrNotExist)
+}
+
+// LoadFile parses the CODEOWNERS file at path.
+func LoadFile(path string) (*Ruleset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// stripComment removes a trailing "#" comment. Escaped "\#" is kept as a
+// literal hash.
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitFields splits a rule line on whitespace, honoring "\ " escapes in the
+// pattern.
+func splitFields(line string) []string {
+	var fields []string
+	var sb strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ' ' || r == '\t':
+			if sb.Len() > 0 {
+				fields = append(fields, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() > 0 {
+		fields = append(fields, sb.String())
+	}
+	return fields
+}
+
+// compilePattern translates a gitignore-style CODEOWNERS pattern into a
+// regular expression over slash-separated paths relative to the root.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	p := pattern
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+
+	// A pattern is anchored to the root when it starts with "/" or contains a
+	// slash anywhere but at the end; otherwise it matches at any depth.
+	anchored := strings.HasPrefix(p, "/") || strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		// "/" owns everything.
+		return regexp.Compile(`^.*$`)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				// "**/" matches zero or more directories; a trailing "**"
+				// matches everything below.
+				if i+2 < len(p) && p[i+2] == '/' {
+					sb.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					sb.WriteString(".*")
+					i++
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		sb.WriteString("/.*$")
+	case strings.HasSuffix(p, "/*"):
+		// "docs/*" owns the files directly in docs, not nested ones.
+		sb.WriteString("$")
+	default:
+		sb.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
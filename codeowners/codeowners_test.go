@@ -0,0 +1,134 @@
+package codeowners
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, src string) *Ruleset {
+	t.Helper()
+	rs, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return rs
+}
+
+func TestOwners_GitHubSyntax(t *testing.T) {
+	rs := mustParse(t, `
+# Default owners
+*                   @org/everyone
+*.go                @org/gophers   # inline comment
+/docs/              @org/writers
+build/logs/         @org/ops
+apps/*              @alice
+/scripts/**/*.sh    @bob
+vendor
+README.md
+`)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@org/gophers"}},
+		{"pkg/deep/file.go", []string{"@org/gophers"}},
+		{"docs/guide.md", []string{"@org/writers"}},
+		{"pkg/docs/guide.md", []string{"@org/everyone"}},
+		{"build/logs/out.txt", []string{"@org/ops"}},
+		{"apps/app.js", []string{"@alice"}},
+		{"apps/nested/app.js", []string{"@org/everyone"}},
+		{"scripts/a/b/run.sh", []string{"@bob"}},
+		{"scripts/run.sh", []string{"@bob"}},
+		// Rules without owners unset ownership.
+		{"vendor/lib/x.c", nil},
+		{"README.md", nil},
+	}
+	for _, tt := range tests {
+		if got := rs.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestOwners_GitLabSections(t *testing.T) {
+	rs := mustParse(t, `
+*.go @dev
+
+[Docs] @docs-team
+*.md
+/api/*.md @api-writers
+
+^[Security][2] @sec
+auth/
+
+[docs]
+guides/ @guides
+`)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"main.go", []string{"@dev"}},
+		{"README.md", []string{"@docs-team"}},
+		{"api/ref.md", []string{"@api-writers"}},
+		// Owners from different sections are combined.
+		{"auth/login.go", []string{"@dev", "@sec"}},
+		// Sections with the same name are merged.
+		{"guides/intro.md", []string{"@guides"}},
+		{"Makefile", nil},
+	}
+	for _, tt := range tests {
+		if got := rs.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if n := len(rs.Sections); n != 3 {
+		t.Errorf("sections = %d, want 3", n)
+	}
+}
+
+func TestOwners_EscapedCharacters(t *testing.T) {
+	rs := mustParse(t, `
+\#notes.txt @hash
+my\ file.txt @space
+`)
+	if got := rs.Owners("#notes.txt"); !reflect.DeepEqual(got, []string{"@hash"}) {
+		t.Errorf("escaped hash: got %v", got)
+	}
+	if got := rs.Owners("dir/my file.txt"); !reflect.DeepEqual(got, []string{"@space"}) {
+		t.Errorf("escaped space: got %v", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, _, err := Load(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load on empty dir: err = %v, want os.ErrNotExist", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ".github", "CODEOWNERS")
+	if err := os.WriteFile(path, []byte("* @team\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, used, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if used != path {
+		t.Errorf("used file = %q, want %q", used, path)
+	}
+	if got := rs.Owners("any/file.go"); !reflect.DeepEqual(got, []string{"@team"}) {
+		t.Errorf("Owners = %v, want [@team]", got)
+	}
+}
@@ -0,0 +1,26 @@
+package filter
+
+import "github.com/zdyxry/tokui/structure"
+
+// descendantMatches memoizes, per directory, whether an entry below it
+// matches a path predicate. Path filters keep such directories visible so
+// their matches stay reachable while navigating; the memo makes that a single
+// walk of the tree per pattern instead of one walk per directory row.
+type descendantMatches map[*structure.Entry]bool
+
+// any reports whether an entry below dir satisfies match. The memo is only
+// valid for a single predicate and root.
+func (m descendantMatches) any(dir *structure.Entry, match func(*structure.Entry) bool) bool {
+	if found, ok := m[dir]; ok {
+		return found
+	}
+	found := false
+	for _, c := range dir.Child {
+		if match(c) || (c.IsDir && m.any(c, match)) {
+			found = true
+			break
+		}
+	}
+	m[dir] = found
+	return found
+}
@@ -1,7 +1,7 @@
 package filter
 
 import (
-	"strings"
+	"fmt"
 
 	"github.com/zdyxry/tokui/structure"
 
@@ -16,14 +16,20 @@ const (
 	NameFilterID ID = "NameFilter"
 )
 
-// NameFilter filters individual *structure.Entry instances by their path value.
-// If an entry's path value does not contain the user input, it will not be filtered/discarded.
+// NameFilter filters individual *structure.Entry instances by their name or
+// path. The input is a case-insensitive substring, a glob, a /regex/ or a path
+// glob, optionally negated with a leading "!"; see PatternMode.
 //
 // User input is handled by a textinput.Model instance, so
 // the filter must update its internal state by providing the corresponding Updater implementation.
 type NameFilter struct {
 	input   textinput.Model
 	enabled bool
+	root    string // path patterns are relative to it
+
+	// compiled caches the pattern of the input it was compiled from.
+	compiled    pattern
+	compiledFor string
 }
 
 // NewNameFilter creates a new name filter.
@@ -60,16 +66,46 @@ func (nf *NameFilter) ClearInput() {
 	nf.input.Reset()
 }
 
-// Filter filters a *structure.Entry by checking if its path value contains the current filter input.
+// Value returns the current input.
+func (nf *NameFilter) Value() string {
+	return nf.input.Value()
+}
+
+// SetValue replaces the input.
+func (nf *NameFilter) SetValue(s string) {
+	nf.input.SetValue(s)
+	nf.input.CursorEnd()
+}
+
+// SetRoot sets the directory path patterns are matched relative to.
+func (nf *NameFilter) SetRoot(root string) {
+	if root != nf.root {
+		clear(nf.compiled.below)
+	}
+	nf.root = root
+}
+
+// Mode returns the syntax of the current input.
+func (nf *NameFilter) Mode() PatternMode {
+	return nf.pattern().mode
+}
+
+// pattern returns the compiled pattern of the current input.
+func (nf *NameFilter) pattern() pattern {
+	if v := nf.input.Value(); v != nf.compiledFor {
+		nf.compiled = compilePattern(v)
+		nf.compiledFor = v
+	}
+	return nf.compiled
+}
+
+// Filter reports whether a *structure.Entry matches the current filter input.
 func (nf *NameFilter) Filter(e *structure.Entry) bool {
 	// If not enabled, always pass through
 	if !nf.enabled {
 		return true
 	}
-	return strings.Contains(
-		strings.ToLower(e.Name()),
-		strings.ToLower(nf.input.Value()),
-	)
+	return nf.pattern().match(e, nf.root)
 }
 
 func (nf *NameFilter) Update(msg tea.Msg) {
@@ -103,5 +139,19 @@ func (nf *NameFilter) View() string {
 		BorderTop(true).
 		Padding(0, 1)
 
-	return s.Render(nf.input.View())
+	return s.Render(nf.input.View() + " " + nf.modeIndicator())
+}
+
+// modeIndicator describes how the input is interpreted, or why it is invalid.
+func (nf *NameFilter) modeIndicator() string {
+	p := nf.pattern()
+	if p.err != nil {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).
+			Render(fmt.Sprintf("[%s: %v]", p.mode, p.err))
+	}
+	label := p.mode.String()
+	if p.negate {
+		label = "not " + label
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("[" + label + "]")
 }
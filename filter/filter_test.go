@@ -1,6 +1,8 @@
 package filter
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -161,3 +163,250 @@ func TestFiltersListReset(t *testing.T) {
 		t.Errorf("expected input cleared, got %q", nf.input.Value())
 	}
 }
+
+func newPatternTree() (*structure.Entry, map[string]*structure.Entry) {
+	root := structure.NewDirEntry("root")
+	entries := map[string]*structure.Entry{"root": root}
+	add := func(parent *structure.Entry, path string, dir bool) *structure.Entry {
+		e := structure.NewFileEntry(path, nil)
+		if dir {
+			e = structure.NewDirEntry(path)
+		}
+		parent.AddChild(e)
+		entries[path] = e
+		return e
+	}
+	internal := add(root, "root/internal", true)
+	api := add(internal, "root/internal/api", true)
+	add(api, "root/internal/api/handler.go", false)
+	add(api, "root/internal/api/handler_test.go", false)
+	gen := add(root, "root/gen", true)
+	add(gen, "root/gen/types.pb.go", false)
+	add(root, "root/main.go", false)
+	return root, entries
+}
+
+func TestNameFilterPatterns(t *testing.T) {
+	root, entries := newPatternTree()
+	tests := []struct {
+		input string
+		mode  PatternMode
+		match []string
+		miss  []string
+	}{
+		{"HANDLER", ModeSubstring, []string{"root/internal/api/handler.go"}, []string{"root/internal/api", "root/main.go"}},
+		{"*.pb.go", ModeGlob, []string{"root/gen/types.pb.go"}, []string{"root/main.go", "root/gen"}},
+		{"/^(main|types)\\./", ModeRegex, []string{"root/main.go", "root/gen/types.pb.go"}, []string{"root/internal/api/handler.go"}},
+		{"internal/**/handler*", ModePath, []string{"root/internal/api/handler.go", "root/internal/api/handler_test.go", "root/internal", "root/internal/api"}, []string{"root/gen", "root/main.go"}},
+		{"**/*.pb.go", ModePath, []string{"root/gen/types.pb.go", "root/gen"}, []string{"root/internal"}},
+		{"!_test.go", ModeSubstring, []string{"root/internal/api/handler.go", "root/internal"}, []string{"root/internal/api/handler_test.go"}},
+		{"!gen/**", ModePath, []string{"root/internal", "root/main.go"}, []string{"root/gen", "root/gen/types.pb.go"}},
+		{"[", ModeGlob, []string{"root/main.go"}, nil},
+		{"/unterminated", ModeRegex, []string{"root/main.go"}, nil},
+		{"!", ModeSubstring, []string{"root/main.go"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			nf := NewNameFilter("search")
+			nf.SetRoot(root.Path)
+			nf.Toggle()
+			nf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.input)})
+
+			if got := nf.Mode(); got != tt.mode {
+				t.Errorf("Mode() = %v, want %v", got, tt.mode)
+			}
+			for _, path := range tt.match {
+				if !nf.Filter(entries[path]) {
+					t.Errorf("expected %q to match", path)
+				}
+			}
+			for _, path := range tt.miss {
+				if nf.Filter(entries[path]) {
+					t.Errorf("expected %q not to match", path)
+				}
+			}
+		})
+	}
+}
+
+func TestNameFilterPathMemoizesDirectories(t *testing.T) {
+	root, entries := newPatternTree()
+	nf := NewNameFilter("search")
+	nf.SetRoot(root.Path)
+	nf.Toggle()
+	nf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("**/api/handler.go")})
+
+	if !nf.Filter(entries["root/internal"]) {
+		t.Fatal("expected root/internal to lead to the match")
+	}
+	// The walk below root/internal is remembered for the nested directory.
+	below := nf.pattern().below
+	if found, ok := below[entries["root/internal/api"]]; !ok || !found {
+		t.Errorf("expected root/internal/api to be memoized as leading to a match")
+	}
+	if nf.Filter(entries["root/gen"]) {
+		t.Error("expected root/gen not to match")
+	}
+	if found, ok := below[entries["root/gen"]]; !ok || found {
+		t.Errorf("expected root/gen to be memoized without a match")
+	}
+
+	// A different root invalidates the memo.
+	nf.SetRoot("elsewhere")
+	if len(below) != 0 {
+		t.Errorf("expected memo to be cleared, got %d entries", len(below))
+	}
+}
+
+func TestNameFilterViewShowsMode(t *testing.T) {
+	nf := NewNameFilter("search")
+	nf.Toggle()
+	nf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!*_test.go")})
+	if view := nf.View(); !strings.Contains(view, "[not glob]") {
+		t.Errorf("expected mode indicator in view, got %q", view)
+	}
+
+	nf.ClearInput()
+	nf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/(/")})
+	if view := nf.View(); !strings.Contains(view, "[regex: ") {
+		t.Errorf("expected regex error in view, got %q", view)
+	}
+}
+
+func newQueryTree() (*structure.Entry, map[string]*structure.Entry) {
+	root := structure.NewDirEntry("root")
+	entries := map[string]*structure.Entry{"root": root}
+	add := func(parent *structure.Entry, e *structure.Entry) *structure.Entry {
+		parent.AddChild(e)
+		entries[e.Path] = e
+		return e
+	}
+	services := add(root, structure.NewDirEntry("root/services"))
+	api := add(services, structure.NewDirEntry("root/services/api"))
+	add(api, structure.NewFileEntry("root/services/api/server.go", map[string]structure.CodeStats{"Go": {Code: 800, Comments: 20}}))
+	add(api, structure.NewFileEntry("root/services/api/generated.go", map[string]structure.CodeStats{"Go": {Code: 2000}}))
+	add(api, structure.NewFileEntry("root/services/api/tool.py", map[string]structure.CodeStats{"Python": {Code: 600, Comments: 300}}))
+	add(root, structure.NewFileEntry("root/main.go", map[string]structure.CodeStats{"Go": {Code: 100, Comments: 50}}))
+	root.AggregateStats()
+	return root, entries
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	langs := []string{"Go", "Python"}
+	tests := []struct {
+		input, err string
+	}{
+		{"size>5", `size>5: unknown metric "size"`},
+		{"code>lots", `code>lots: invalid number "lots"`},
+		{"owner:me", `owner:me: unknown field "owner"`},
+		{"lang:", "lang:: missing value"},
+		{"lang:Rust", `lang:Rust: unknown language "Rust"`},
+		{"comments/>1", "comments/>1: missing metric"},
+		{"code>1 !", "!: empty term"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseQuery(tt.input, langs); err == nil || err.Error() != tt.err {
+			t.Errorf("ParseQuery(%q) error = %v, want %q", tt.input, err, tt.err)
+		}
+	}
+	if _, err := ParseQuery("lang:go code>=1.5k comments/code<0.05 path:services/ !generated ext:go", langs); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	root, entries := newQueryTree()
+	tests := []struct {
+		query string
+		match []string
+		miss  []string
+	}{
+		{"code>500", []string{"root/services", "root/services/api/server.go"}, []string{"root/main.go"}},
+		{"lang:go code>2k", []string{"root/services/api"}, []string{"root/services/api/tool.py"}},
+		{"lang:python", []string{"root/services/api/tool.py", "root/services"}, []string{"root/main.go"}},
+		{"comments/code<0.05", []string{"root/services/api/server.go"}, []string{"root/main.go", "root/services/api/tool.py"}},
+		{"path:services/api ext:go !generated", []string{"root/services/api/server.go"}, []string{"root/services/api/generated.go", "root/services/api/tool.py", "root/main.go"}},
+		{"path:services/api", []string{"root/services", "root/services/api"}, []string{"root/main.go"}},
+		{"path:api/", []string{"root/services", "root/services/api", "root/services/api/tool.py"}, []string{"root/main.go"}},
+		{"!path:services/", []string{"root/main.go"}, []string{"root/services", "root/services/api/server.go"}},
+		{"main files=1", []string{"root/main.go"}, []string{"root/services"}},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query, []string{"Go", "Python"})
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.query, err)
+		}
+		for _, path := range tt.match {
+			if !q.Match(entries[path], root.Path) {
+				t.Errorf("%q: expected %q to match", tt.query, path)
+			}
+		}
+		for _, path := range tt.miss {
+			if q.Match(entries[path], root.Path) {
+				t.Errorf("%q: expected %q not to match", tt.query, path)
+			}
+		}
+	}
+}
+
+func TestCompleteQuery(t *testing.T) {
+	langs := []string{"Go", "Python"}
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"la", []string{"lang:"}},
+		{"code>5 !com", []string{"code>5 !comments", "code>5 !complexity"}},
+		{"lang:p", []string{"lang:Python"}},
+		{"comments/co", []string{"comments/code", "comments/comments", "comments/complexity"}},
+		{"code>5", nil},
+	}
+	for _, tt := range tests {
+		if got := completeQuery(tt.input, langs); !slices.Equal(got, tt.want) {
+			t.Errorf("completeQuery(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestQueryFilterEditing(t *testing.T) {
+	root, entries := newQueryTree()
+	qf := NewQueryFilter("query")
+	qf.SetRoot(root.Path)
+	qf.SetLanguages([]string{"Go", "Python"})
+	if !qf.Filter(entries["root/main.go"]) {
+		t.Error("expected disabled filter to pass everything")
+	}
+
+	qf.Edit()
+	qf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("lang:py")})
+	qf.Complete()
+	if qf.Value() != "lang:Python" {
+		t.Errorf("expected completion to lang:Python, got %q", qf.Value())
+	}
+	if qf.Filter(entries["root/main.go"]) {
+		t.Error("expected main.go to be filtered out")
+	}
+
+	qf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" code>x")})
+	if qf.Err() == nil || !strings.Contains(qf.View(), `invalid number "x"`) {
+		t.Errorf("expected inline error, got %q", qf.View())
+	}
+	if !qf.Filter(entries["root/main.go"]) {
+		t.Error("expected an invalid query to filter nothing")
+	}
+
+	qf.SetValue("code>500")
+	qf.Commit()
+	if !qf.IsEnabled() || qf.IsEditing() {
+		t.Error("expected a committed query to stay enabled without focus")
+	}
+	qf.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if qf.Value() != "code>500" {
+		t.Errorf("expected keys to be ignored after commit, got %q", qf.Value())
+	}
+
+	qf.Reset()
+	if qf.IsEnabled() || qf.Value() != "" {
+		t.Error("expected reset to clear and disable the query")
+	}
+}
@@ -0,0 +1,154 @@
+package filter
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+// PatternMode is the syntax of a name filter pattern. It is picked from the
+// pattern itself:
+//
+//	/re/           regular expression on the name
+//	dir/**/name*   glob on the path below the root, with ** spanning directories
+//	*.pb.go        glob on the name
+//	handler        case-insensitive substring of the name
+//
+// A leading "!" negates any of them.
+type PatternMode int
+
+const (
+	ModeSubstring PatternMode = iota
+	ModeGlob
+	ModeRegex
+	ModePath
+)
+
+func (m PatternMode) String() string {
+	switch m {
+	case ModeGlob:
+		return "glob"
+	case ModeRegex:
+		return "regex"
+	case ModePath:
+		return "path"
+	default:
+		return "substring"
+	}
+}
+
+// errUnterminatedRegex reports a regular expression whose closing slash has
+// not been typed yet.
+var errUnterminatedRegex = errors.New("missing closing /")
+
+// pattern is a compiled name filter pattern.
+type pattern struct {
+	mode   PatternMode
+	negate bool
+	text   string            // lower-cased substring or glob
+	re     *regexp.Regexp    // ModeRegex
+	segs   []string          // lower-cased path segments for ModePath
+	below  descendantMatches // directories containing a ModePath match
+	err    error             // set for invalid patterns, which match everything
+}
+
+// compilePattern parses user input into a pattern.
+func compilePattern(input string) pattern {
+	var p pattern
+	input = strings.TrimSpace(input)
+	if rest, ok := strings.CutPrefix(input, "!"); ok {
+		// A lone "!" is still being typed and matches everything.
+		p.negate = rest != ""
+		input = rest
+	}
+
+	switch {
+	case strings.HasPrefix(input, "/"):
+		p.mode = ModeRegex
+		if len(input) < 2 || !strings.HasSuffix(input, "/") {
+			p.err = errUnterminatedRegex
+			break
+		}
+		p.re, p.err = regexp.Compile("(?i)" + input[1:len(input)-1])
+	case strings.Contains(input, "/"):
+		p.mode = ModePath
+		p.below = make(descendantMatches)
+		p.segs = strings.Split(strings.Trim(strings.ToLower(input), "/"), "/")
+		for _, seg := range p.segs {
+			if _, err := path.Match(seg, ""); err != nil {
+				p.err = err
+				break
+			}
+		}
+	case strings.ContainsAny(input, "*?["):
+		p.mode = ModeGlob
+		p.text = strings.ToLower(input)
+		_, p.err = path.Match(p.text, "")
+	default:
+		p.text = strings.ToLower(input)
+	}
+	return p
+}
+
+// match reports whether e passes the pattern. Path patterns keep directories
+// that contain a match, so the matches stay reachable while navigating.
+func (p pattern) match(e *structure.Entry, root string) bool {
+	if p.err != nil {
+		return true
+	}
+	var ok bool
+	switch p.mode {
+	case ModeRegex:
+		ok = p.re.MatchString(e.Name())
+	case ModeGlob:
+		ok, _ = path.Match(p.text, strings.ToLower(e.Name()))
+	case ModePath:
+		ok = p.matchPath(e, root)
+		if !ok && !p.negate && e.IsDir {
+			ok = p.below.any(e, func(c *structure.Entry) bool { return p.matchPath(c, root) })
+		}
+	default:
+		ok = strings.Contains(strings.ToLower(e.Name()), p.text)
+	}
+	return ok != p.negate
+}
+
+// matchPath matches the path of e relative to root against the segments.
+func (p pattern) matchPath(e *structure.Entry, root string) bool {
+	rel := e.Path
+	if root != "" {
+		if r, err := filepath.Rel(root, e.Path); err == nil {
+			rel = r
+		}
+	}
+	rel = strings.Trim(filepath.ToSlash(strings.ToLower(rel)), "/")
+	if rel == "." || rel == "" {
+		return false
+	}
+	return matchSegments(p.segs, strings.Split(rel, "/"))
+}
+
+// matchSegments matches path segments against glob segments, where "**"
+// matches any number of segments, including none.
+func matchSegments(pat, parts []string) bool {
+	if len(pat) == 0 {
+		return len(parts) == 0
+	}
+	if pat[0] == "**" {
+		if matchSegments(pat[1:], parts) {
+			return true
+		}
+		return len(parts) > 0 && matchSegments(pat, parts[1:])
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pat[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pat[1:], parts[1:])
+}
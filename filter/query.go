@@ -0,0 +1,304 @@
+package filter
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+// queryFields are the text predicates of a query, written as field:value.
+var queryFields = []string{"lang", "name", "path", "ext"}
+
+// queryMetrics are the numeric values a query can compare. A ratio of two of
+// them is written as a/b, e.g. comments/code.
+var queryMetrics = []string{"code", "comments", "blanks", "lines", "files", "complexity"}
+
+// queryOps are the comparison operators, longest first so that ">=" is not
+// read as ">".
+var queryOps = []string{">=", "<=", "!=", ">", "<", "="}
+
+// QueryError reports an invalid query term.
+type QueryError struct {
+	Term string
+	Msg  string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Term, e.Msg)
+}
+
+// Query is a parsed query expression: whitespace-separated terms that must
+// all hold. A term is one of
+//
+//	lang:Go           the entry has lines in the language
+//	name:gen, gen     the name contains the text
+//	path:services/    the path below the root contains the text
+//	ext:go            the file extension
+//	code>500          a metric compared with a number (k and m suffixes)
+//	comments/code<0.05  a ratio of two metrics
+//
+// and may be negated with a leading "!". When the query names languages,
+// metrics count the lines of those languages only.
+type Query struct {
+	terms []queryTerm
+	langs []string // languages of the positive lang: terms
+}
+
+// queryTerm is a single predicate of a query.
+type queryTerm struct {
+	raw    string
+	negate bool
+	field  string // text field, or "" for a comparison
+	value  string // lower-cased
+	num    string // metric, or numerator of a ratio
+	den    string // denominator of a ratio, or ""
+	op     string
+	limit  float64
+	below  descendantMatches // directories containing a path: match
+}
+
+// ParseQuery parses a query expression. Languages, when given, are used to
+// validate lang: terms and to normalize their case.
+func ParseQuery(s string, languages []string) (Query, error) {
+	var q Query
+	for _, raw := range strings.Fields(s) {
+		t, err := parseQueryTerm(raw, languages)
+		if err != nil {
+			return Query{}, err
+		}
+		if t.field == "lang" && !t.negate {
+			q.langs = append(q.langs, t.value)
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+// parseQueryTerm parses one term of a query.
+func parseQueryTerm(raw string, languages []string) (queryTerm, error) {
+	t := queryTerm{raw: raw}
+	body := raw
+	if rest, ok := strings.CutPrefix(body, "!"); ok {
+		t.negate = true
+		body = rest
+	}
+	if body == "" {
+		return t, &QueryError{Term: raw, Msg: "empty term"}
+	}
+
+	if field, value, ok := strings.Cut(body, ":"); ok {
+		t.field = strings.ToLower(field)
+		if !slices.Contains(queryFields, t.field) {
+			return t, &QueryError{Term: raw, Msg: fmt.Sprintf("unknown field %q", field)}
+		}
+		if value == "" {
+			return t, &QueryError{Term: raw, Msg: "missing value"}
+		}
+		t.value = strings.ToLower(value)
+		if t.field == "lang" && len(languages) > 0 {
+			idx := slices.IndexFunc(languages, func(l string) bool { return strings.EqualFold(l, value) })
+			if idx < 0 {
+				return t, &QueryError{Term: raw, Msg: fmt.Sprintf("unknown language %q", value)}
+			}
+			t.value = strings.ToLower(languages[idx])
+		}
+		if t.field == "path" {
+			t.below = make(descendantMatches)
+		}
+		return t, nil
+	}
+
+	for _, op := range queryOps {
+		left, right, ok := strings.Cut(body, op)
+		if !ok {
+			continue
+		}
+		t.op = op
+		t.num, t.den, _ = strings.Cut(strings.ToLower(left), "/")
+		for _, m := range []string{t.num, t.den} {
+			if m != "" && !slices.Contains(queryMetrics, m) {
+				return t, &QueryError{Term: raw, Msg: fmt.Sprintf("unknown metric %q", m)}
+			}
+		}
+		if t.num == "" || (strings.Contains(left, "/") && t.den == "") {
+			return t, &QueryError{Term: raw, Msg: "missing metric"}
+		}
+		limit, err := parseQueryNumber(right)
+		if err != nil {
+			return t, &QueryError{Term: raw, Msg: fmt.Sprintf("invalid number %q", right)}
+		}
+		t.limit = limit
+		return t, nil
+	}
+
+	// A bare word matches names, like the name filter.
+	t.field = "name"
+	t.value = strings.ToLower(body)
+	return t, nil
+}
+
+// parseQueryNumber parses a number with an optional k (thousand) or m
+// (million) suffix.
+func parseQueryNumber(s string) (float64, error) {
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(strings.ToLower(s), "k"):
+		scale, s = 1e3, s[:len(s)-1]
+	case strings.HasSuffix(strings.ToLower(s), "m"):
+		scale, s = 1e6, s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v * scale, err
+}
+
+// Match reports whether e satisfies every term. Paths are relative to root,
+// which must stay the same for the lifetime of the query; see resetPaths.
+func (q Query) Match(e *structure.Entry, root string) bool {
+	stats := e.TotalStats
+	if len(q.langs) > 0 {
+		stats = structure.CodeStats{}
+		for _, lang := range q.langs {
+			for l, s := range e.StatsByLang {
+				if strings.ToLower(l) == lang {
+					stats.Add(s)
+				}
+			}
+		}
+	}
+	for _, t := range q.terms {
+		if t.match(e, stats, root) == t.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// resetPaths forgets which directories lead to path: matches, after the root
+// the paths are relative to has changed.
+func (q Query) resetPaths() {
+	for _, t := range q.terms {
+		clear(t.below)
+	}
+}
+
+// match evaluates the term without its negation.
+func (t queryTerm) match(e *structure.Entry, stats structure.CodeStats, root string) bool {
+	switch t.field {
+	case "lang":
+		for l, s := range e.StatsByLang {
+			if strings.ToLower(l) == t.value && s.Total() > 0 {
+				return true
+			}
+		}
+		return false
+	case "name":
+		return strings.Contains(strings.ToLower(e.Name()), t.value)
+	case "ext":
+		return !e.IsDir && e.Ext() == strings.TrimPrefix(t.value, ".")
+	case "path":
+		contains := func(e *structure.Entry) bool { return strings.Contains(queryRelPath(e, root), t.value) }
+		// Directories containing a matching path stay visible so the matches
+		// can be reached while navigating.
+		return contains(e) || (e.IsDir && !t.negate && t.below.any(e, contains))
+	}
+
+	v := queryMetric(stats, t.num)
+	if t.den != "" {
+		d := queryMetric(stats, t.den)
+		if d == 0 {
+			return false
+		}
+		v /= d
+	}
+	switch t.op {
+	case ">=":
+		return v >= t.limit
+	case "<=":
+		return v <= t.limit
+	case "!=":
+		return v != t.limit
+	case ">":
+		return v > t.limit
+	case "<":
+		return v < t.limit
+	default:
+		return v == t.limit
+	}
+}
+
+// queryRelPath returns the lower-cased, slash-separated path of e below root.
+// Directories end with a slash, so path:services/ matches the directory too.
+func queryRelPath(e *structure.Entry, root string) string {
+	rel := e.Path
+	if root != "" {
+		if r, err := filepath.Rel(root, e.Path); err == nil {
+			rel = r
+		}
+	}
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	if e.IsDir {
+		rel += "/"
+	}
+	return rel
+}
+
+// queryMetric returns the value of a metric.
+func queryMetric(stats structure.CodeStats, metric string) float64 {
+	switch metric {
+	case "code":
+		return float64(stats.Code)
+	case "comments":
+		return float64(stats.Comments)
+	case "blanks":
+		return float64(stats.Blanks)
+	case "files":
+		return float64(stats.Files)
+	case "complexity":
+		return float64(stats.Complexity)
+	default:
+		return float64(stats.Total())
+	}
+}
+
+// completeQuery returns the completions of the last word of input: field and
+// metric names, and languages after "lang:". Each completion is the whole
+// input with the last word replaced.
+func completeQuery(input string, languages []string) []string {
+	start := strings.LastIndexAny(input, " \t") + 1
+	head, word := input[:start], input[start:]
+	neg := ""
+	if rest, ok := strings.CutPrefix(word, "!"); ok {
+		neg, word = "!", rest
+	}
+
+	var candidates []string
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasPrefix(lower, "lang:"):
+		for _, l := range languages {
+			candidates = append(candidates, "lang:"+l)
+		}
+	case strings.Contains(word, "/") && !strings.ContainsAny(word, ":<>=!"):
+		num, _, _ := strings.Cut(word, "/")
+		for _, m := range queryMetrics {
+			candidates = append(candidates, num+"/"+m)
+		}
+	case !strings.ContainsAny(word, ":<>=!/"):
+		for _, f := range queryFields {
+			candidates = append(candidates, f+":")
+		}
+		candidates = append(candidates, queryMetrics...)
+	}
+
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), lower) && len(c) > len(word) {
+			out = append(out, head+neg+c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+package filter
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/structure"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// ID for the query filter
+	QueryFilterID ID = "QueryFilter"
+)
+
+// queryMaxSuggestions caps the completions listed below the query input.
+const queryMaxSuggestions = 6
+
+// QueryFilter filters entries by a query expression over their stats, names
+// and paths; see Query. The filter stays active after editing ends, until the
+// query is cleared. An invalid query filters nothing and its error is shown
+// in the filter bar.
+type QueryFilter struct {
+	input     textinput.Model
+	enabled   bool
+	root      string   // paths are relative to it
+	languages []string // for validation and completion
+
+	// query is the parsed input, or the zero Query when err is set.
+	query     Query
+	err       error
+	parsed    bool
+	parsedFor string
+}
+
+// NewQueryFilter creates a new query filter.
+func NewQueryFilter(placeholder string) *QueryFilter {
+	textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#3a86ff"))
+	ti := textinput.New()
+
+	ti.Placeholder = placeholder
+	ti.Width = lipgloss.Width(placeholder)
+	ti.Prompt = ": "
+	ti.PromptStyle, ti.TextStyle = textStyle, textStyle
+
+	return &QueryFilter{input: ti}
+}
+
+// ID returns the unique identifier of the filter.
+func (qf *QueryFilter) ID() ID {
+	return QueryFilterID
+}
+
+// Toggle switches the enabled state of the filter.
+func (qf *QueryFilter) Toggle() {
+	qf.enabled = !qf.enabled
+}
+
+// IsEnabled returns whether the filter is enabled.
+func (qf *QueryFilter) IsEnabled() bool {
+	return qf.enabled
+}
+
+// Edit enables the filter and focuses its input.
+func (qf *QueryFilter) Edit() {
+	qf.enabled = true
+	qf.input.Focus()
+	qf.input.CursorEnd()
+}
+
+// Commit ends editing. The filter stays enabled while the query has terms.
+func (qf *QueryFilter) Commit() {
+	qf.input.Blur()
+	qf.enabled = strings.TrimSpace(qf.input.Value()) != ""
+}
+
+// IsEditing reports whether the input has focus.
+func (qf *QueryFilter) IsEditing() bool {
+	return qf.input.Focused()
+}
+
+// SetRoot sets the directory paths are matched relative to.
+func (qf *QueryFilter) SetRoot(root string) {
+	if root != qf.root {
+		qf.query.resetPaths()
+	}
+	qf.root = root
+}
+
+// SetLanguages sets the known languages and re-validates the query.
+func (qf *QueryFilter) SetLanguages(languages []string) {
+	qf.languages = languages
+	qf.parsed = false
+	qf.parse()
+}
+
+// Value returns the query text.
+func (qf *QueryFilter) Value() string {
+	return qf.input.Value()
+}
+
+// SetValue replaces the query text.
+func (qf *QueryFilter) SetValue(s string) {
+	qf.input.SetValue(s)
+	qf.input.CursorEnd()
+	qf.parse()
+}
+
+// Err returns the validation error of the query, if any.
+func (qf *QueryFilter) Err() error {
+	qf.parse()
+	return qf.err
+}
+
+// parse re-parses the input when it has changed.
+func (qf *QueryFilter) parse() {
+	v := qf.input.Value()
+	if qf.parsed && v == qf.parsedFor {
+		return
+	}
+	qf.query, qf.err = ParseQuery(v, qf.languages)
+	qf.parsed, qf.parsedFor = true, v
+}
+
+// Filter reports whether a *structure.Entry satisfies the query.
+func (qf *QueryFilter) Filter(e *structure.Entry) bool {
+	if !qf.enabled {
+		return true
+	}
+	qf.parse()
+	return qf.query.Match(e, qf.root)
+}
+
+// Complete completes the word under the cursor to the longest common prefix
+// of its completions.
+func (qf *QueryFilter) Complete() {
+	candidates := completeQuery(qf.input.Value(), qf.languages)
+	if len(candidates) == 0 {
+		return
+	}
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(strings.ToLower(c), strings.ToLower(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(qf.input.Value()) {
+		qf.SetValue(prefix)
+	}
+}
+
+func (qf *QueryFilter) Update(msg tea.Msg) {
+	if resizeMsg, ok := msg.(tea.WindowSizeMsg); ok {
+		qf.input.Width = resizeMsg.Width - 4
+	}
+	if !qf.enabled || !qf.input.Focused() {
+		return
+	}
+
+	var cmd tea.Cmd
+	qf.input, cmd = qf.input.Update(msg)
+	_ = cmd
+	qf.parse()
+}
+
+// Reset disables the filter and clears the query.
+func (qf *QueryFilter) Reset() {
+	qf.enabled = false
+	qf.input.Blur()
+	qf.SetValue("")
+}
+
+func (qf *QueryFilter) View() string {
+	if !qf.enabled {
+		return ""
+	}
+
+	s := lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		BorderTop(true).
+		Padding(0, 1)
+
+	// While a word is being typed, its completions take precedence over
+	// errors, which are usually about the unfinished word.
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	var suggestions []string
+	if v := qf.input.Value(); qf.input.Focused() && (v == "" || !strings.HasSuffix(v, " ")) {
+		suggestions = completeQuery(qf.input.Value(), qf.languages)
+	}
+	var hint string
+	switch err := qf.Err(); {
+	case len(suggestions) > 0:
+		words := make([]string, 0, queryMaxSuggestions+1)
+		for _, c := range suggestions[:min(len(suggestions), queryMaxSuggestions)] {
+			words = append(words, c[strings.LastIndexAny(c, " \t")+1:])
+		}
+		if len(suggestions) > queryMaxSuggestions {
+			words = append(words, "…")
+		}
+		hint = hintStyle.Render("Tab: " + strings.Join(words, " "))
+	case err != nil:
+		hint = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87")).Render(err.Error())
+	case qf.input.Focused():
+		hint = hintStyle.Render("Enter: apply • Esc: clear")
+	}
+	if hint == "" {
+		return s.Render(qf.input.View())
+	}
+	return s.Render(qf.input.View() + "  " + hint)
+}
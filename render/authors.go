@@ -0,0 +1,193 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zdyxry/tokui/structure"
+	"github.com/zdyxry/tokui/vcs"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// topAuthorsShown is the number of authors listed in the "Top Authors" column.
+const topAuthorsShown = 3
+
+// AuthorsLoaded reports the result of blaming the files below Root.
+type AuthorsLoaded struct {
+	Root  *structure.Entry
+	Lines map[string]map[string]int64 // per-author line counts keyed by file path
+	Err   error
+}
+
+// toggleAuthors switches authors mode on or off. Turning it on starts loading
+// blame data for the current directory; turning it off stops a running blame.
+func (dm *DirModel) toggleAuthors() tea.Cmd {
+	dm.authorsMode = !dm.authorsMode
+	if !dm.authorsMode {
+		dm.cancelAuthors()
+		dm.columns = removeColumn(dm.columns, SortByAuthors)
+		if dm.sortState.Key == SortByAuthors {
+			dm.sortState = SortState{Key: SortByTotal, Desc: true}
+		}
+		if dm.chartBy == chartByAuthor {
+			dm.chartBy = chartByLang
+		}
+		dm.updateTableData()
+		return nil
+	}
+	dm.columns = append(dm.columns, Column{Title: "Top Authors", SortKey: SortByAuthors})
+	dm.updateTableData()
+	return dm.authorsCmd()
+}
+
+// cancelAuthors stops a running blame.
+func (dm *DirModel) cancelAuthors() {
+	if dm.authorsCancel != nil {
+		dm.authorsCancel()
+		dm.authorsCancel = nil
+	}
+	dm.authorsLoading = false
+	dm.authorsRoot = nil
+}
+
+// authorsCmd returns a command blaming the current directory's files when
+// authors mode is on and they have not been loaded yet. Subdirectories that
+// were blamed before are skipped. A blame still running for another directory
+// is canceled, so the directory the user is in is blamed first; files it had
+// blamed already are cached by the blamer.
+func (dm *DirModel) authorsCmd() tea.Cmd {
+	entry := dm.nav.Entry()
+	if !dm.authorsMode || entry == nil || dm.blamedDirs[entry] {
+		return nil
+	}
+	if dm.authorsLoading {
+		if dm.authorsRoot == entry {
+			return nil
+		}
+		dm.cancelAuthors()
+	}
+	if dm.blamer == nil {
+		dm.blamer = vcs.NewBlamer()
+	}
+	dm.authorsLoading = true
+	dm.authorsRoot = entry
+
+	// Collect paths here rather than in the command so the tree is only read
+	// from the update loop.
+	var paths []string
+	var walk func(e *structure.Entry)
+	walk = func(e *structure.Entry) {
+		if !e.IsDir {
+			paths = append(paths, e.Path)
+			return
+		}
+		if dm.blamedDirs[e] {
+			return
+		}
+		for _, child := range e.Child {
+			walk(child)
+		}
+	}
+	walk(entry)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dm.authorsCancel = cancel
+	blamer := dm.blamer
+	return func() tea.Msg {
+		if _, err := vcs.RepoRoot(entry.Path); err != nil {
+			return AuthorsLoaded{Root: entry, Err: err}
+		}
+		lines := blamer.BlameFiles(ctx, paths)
+		if err := ctx.Err(); err != nil {
+			return AuthorsLoaded{Root: entry, Err: err}
+		}
+		return AuthorsLoaded{Root: entry, Lines: lines}
+	}
+}
+
+// applyAuthors merges loaded blame data into the tree and starts blaming the
+// current directory if it is not covered. Results arriving after authors mode
+// was turned off are dropped; results of a blame that finished just before
+// it was superseded are still merged.
+func (dm *DirModel) applyAuthors(msg AuthorsLoaded) tea.Cmd {
+	if !dm.authorsMode || errors.Is(msg.Err, context.Canceled) {
+		return nil
+	}
+	current := msg.Root == dm.authorsRoot
+	if current {
+		dm.authorsLoading = false
+		dm.authorsCancel = nil
+		dm.authorsRoot = nil
+	}
+	if msg.Err != nil {
+		if current {
+			dm.err = fmt.Errorf("authors mode unavailable: %w", msg.Err)
+			dm.toggleAuthors()
+		}
+		return nil
+	}
+	structure.ApplyAuthors(dm.nav.tree.Root(), msg.Root, msg.Lines)
+
+	if dm.blamedDirs == nil {
+		dm.blamedDirs = make(map[*structure.Entry]bool)
+	}
+	var mark func(e *structure.Entry)
+	mark = func(e *structure.Entry) {
+		if !e.IsDir {
+			return
+		}
+		dm.blamedDirs[e] = true
+		for _, child := range e.Child {
+			mark(child)
+		}
+	}
+	mark(msg.Root)
+
+	// Shadow entries copy author data, so the owner filter must be rebuilt.
+	dm.applyStatsOverlay()
+	dm.updateTableData()
+	return dm.authorsCmd()
+}
+
+// topAuthorsLabel lists the entry's main authors with their share of the
+// blamed lines, e.g. "alice 62%, bob 30%".
+func topAuthorsLabel(e *structure.Entry) string {
+	authors := e.Authors()
+	var total int64
+	for _, a := range authors {
+		total += a.Lines
+	}
+	if total == 0 {
+		return ""
+	}
+	parts := make([]string, 0, topAuthorsShown)
+	for i, a := range authors {
+		if i == topAuthorsShown {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s %d%%", a.Author, a.Lines*100/total))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// statusAuthorsLabel summarizes authors mode for the status bar.
+func (dm *DirModel) statusAuthorsLabel() string {
+	if dm.authorsLoading {
+		return "loading…"
+	}
+	return fmt.Sprintf("%d", len(dm.statsEntry(dm.nav.Entry()).LinesByAuthor))
+}
+
+// removeColumn returns cols without the column sorting by key.
+func removeColumn(cols []Column, key SortKey) []Column {
+	out := cols[:0]
+	for _, c := range cols {
+		if c.SortKey != key {
+			out = append(out, c)
+		}
+	}
+	return out
+}
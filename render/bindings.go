@@ -26,24 +26,52 @@ func parseBindingKey(msg tea.KeyMsg) bindingKey {
 }
 
 const (
-	backspace          bindingKey = "backspace"
-	quit               bindingKey = "q"
-	cancel             bindingKey = "ctrl+c"
-	escape             bindingKey = "esc"
-	enter              bindingKey = "enter"
-	editFile           bindingKey = "e"
-	quickSearch        bindingKey = "/"
-	globalSearch       bindingKey = "ctrl+p"
-	toggleChart        bindingKey = "ctrl+w"
-	toggleLangFilter   bindingKey = "tab"
-	toggleLangSelect   bindingKey = "ctrl+l"
-	toggleHelp         bindingKey = "?"
-	toggleTree         bindingKey = "t"
-	toggleTreemap      bindingKey = "m"
-	toggleTreemapColor bindingKey = "c"
-	cycleTreemapSize   bindingKey = "M"
-	cycleSortColumn    bindingKey = "s"
-	toggleSortOrder    bindingKey = "S"
+	backspace           bindingKey = "backspace"
+	quit                bindingKey = "q"
+	cancel              bindingKey = "ctrl+c"
+	escape              bindingKey = "esc"
+	enter               bindingKey = "enter"
+	editFile            bindingKey = "e"
+	quickSearch         bindingKey = "/"
+	editQuery           bindingKey = ":"
+	globalSearch        bindingKey = "ctrl+p"
+	contentSearch       bindingKey = "ctrl+g"
+	cycleSearchSort     bindingKey = "ctrl+t"
+	toggleChart         bindingKey = "ctrl+w"
+	toggleLangFilter    bindingKey = "tab"
+	toggleLangSelect    bindingKey = "ctrl+l"
+	toggleHelp          bindingKey = "?"
+	toggleTree          bindingKey = "t"
+	toggleTreemap       bindingKey = "m"
+	toggleIcicle        bindingKey = "I"
+	toggleTreemapColor  bindingKey = "c"
+	cycleHeatMetric     bindingKey = "C"
+	cycleTreemapSize    bindingKey = "M"
+	cycleSortColumn     bindingKey = "s"
+	toggleSortOrder     bindingKey = "S"
+	cycleTestScope      bindingKey = "T"
+	toggleOwnerSelect   bindingKey = "O"
+	cycleChartBreakdown bindingKey = "o"
+	toggleAuthors       bindingKey = "A"
+	toggleFlat          bindingKey = "F"
+	cycleChartMetric    bindingKey = "f"
+	cycleChartStyle     bindingKey = "b"
+	togglePivot         bindingKey = "P"
+	togglePivotPercent  bindingKey = "%"
+	toggleLangSummary   bindingKey = "L"
+	cyclePercentStyle   bindingKey = "B"
+	toggleMark          bindingKey = "v"
+	toggleSelection     bindingKey = "V"
+	clearMarks          bindingKey = "U"
+	markSearchResult    bindingKey = "tab"
+	toggleFilterTotals  bindingKey = "ctrl+f"
+	toggleExclusion     bindingKey = "x"
+	toggleExclusions    bindingKey = "X"
+	saveExclusions      bindingKey = "w"
+	cycleFoldThreshold  bindingKey = "z"
+	savePreset          bindingKey = "ctrl+s"
+	openPresets         bindingKey = "ctrl+o"
+	deletePreset        bindingKey = "d"
 )
 
 var toggleHelpBinding = key.NewBinding(
@@ -126,11 +154,123 @@ var dirsKeyMap = [][]key.Binding{
 				helpDescStyle.Render(" - Toggle treemap"),
 			),
 		),
+		key.NewBinding(
+			key.WithKeys(toggleFlat.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleFlat.String()),
+				helpDescStyle.Render(" - Toggle flat file list"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleLangSummary.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleLangSummary.String()),
+				helpDescStyle.Render(" - Language summary"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(togglePivot.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(togglePivot.String()),
+				helpDescStyle.Render(" - Toggle directory × language matrix"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(togglePivotPercent.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(togglePivotPercent.String()),
+				helpDescStyle.Render(" - Matrix lines/percent"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleMark.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleMark.String()),
+				helpDescStyle.Render(" - Mark/unmark row"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleSelection.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleSelection.String()),
+				helpDescStyle.Render(" - Selection statistics"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(clearMarks.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(clearMarks.String()),
+				helpDescStyle.Render(" - Clear marks"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(cyclePercentStyle.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cyclePercentStyle.String()),
+				helpDescStyle.Render(" - Cycle % column: number/bar/stacked"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(savePreset.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(savePreset.String()),
+				helpDescStyle.Render(" - Save view as preset"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(openPresets.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(openPresets.String()),
+				helpDescStyle.Render(" - Apply a preset"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(cycleFoldThreshold.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cycleFoldThreshold.String()),
+				helpDescStyle.Render(" - Cycle folding of small rows"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleExclusion.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleExclusion.String()),
+				helpDescStyle.Render(" - Exclude/restore the selected row"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleExclusions.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleExclusions.String()),
+				helpDescStyle.Render(" - Show exclusions"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleFilterTotals.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleFilterTotals.String()),
+				helpDescStyle.Render(" - Filters recompute totals"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleIcicle.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleIcicle.String()),
+				helpDescStyle.Render(" - Toggle icicle view"),
+			),
+		),
 		key.NewBinding(
 			key.WithKeys(toggleTreemapColor.String()),
 			key.WithHelp(
 				bindKeyStyle.Render(toggleTreemapColor.String()),
-				helpDescStyle.Render(" - Toggle treemap color mode"),
+				helpDescStyle.Render(" - Cycle treemap color mode"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(cycleHeatMetric.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cycleHeatMetric.String()),
+				helpDescStyle.Render(" - Cycle treemap heat metric"),
 			),
 		),
 		key.NewBinding(
@@ -163,6 +303,13 @@ var dirsKeyMap = [][]key.Binding{
 				helpDescStyle.Render(" - Quick search"),
 			),
 		),
+		key.NewBinding(
+			key.WithKeys(editQuery.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(editQuery.String()),
+				helpDescStyle.Render(" - Query filter"),
+			),
+		),
 		key.NewBinding(
 			key.WithKeys(globalSearch.String()),
 			key.WithHelp(
@@ -170,6 +317,13 @@ var dirsKeyMap = [][]key.Binding{
 				helpDescStyle.Render(" - Global search"),
 			),
 		),
+		key.NewBinding(
+			key.WithKeys(contentSearch.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(contentSearch.String()),
+				helpDescStyle.Render(" - Content search"),
+			),
+		),
 		key.NewBinding(
 			key.WithKeys(toggleLangSelect.String()),
 			key.WithHelp(
@@ -184,6 +338,41 @@ var dirsKeyMap = [][]key.Binding{
 				helpDescStyle.Render(" - Language proportion chart"),
 			),
 		),
+		key.NewBinding(
+			key.WithKeys(toggleOwnerSelect.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleOwnerSelect.String()),
+				helpDescStyle.Render(" - Owner select"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(cycleChartBreakdown.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cycleChartBreakdown.String()),
+				helpDescStyle.Render(" - Chart by language/owner/author"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(cycleChartMetric.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cycleChartMetric.String()),
+				helpDescStyle.Render(" - Cycle chart metric"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(cycleChartStyle.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cycleChartStyle.String()),
+				helpDescStyle.Render(" - Cycle pie/bars/stacked bars"),
+			),
+		),
+		key.NewBinding(
+			key.WithKeys(toggleAuthors.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(toggleAuthors.String()),
+				helpDescStyle.Render(" - Toggle git blame authors"),
+			),
+		),
 	},
 	{
 		key.NewBinding(
@@ -200,6 +389,13 @@ var dirsKeyMap = [][]key.Binding{
 				helpDescStyle.Render(" - Toggle sort order"),
 			),
 		),
+		key.NewBinding(
+			key.WithKeys(cycleTestScope.String()),
+			key.WithHelp(
+				bindKeyStyle.Render(cycleTestScope.String()),
+				helpDescStyle.Render(" - Cycle all/prod/test code"),
+			),
+		),
 	},
 	{
 		toggleHelpBinding,
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"fmt"
 	"math"
 	"sort"
 	"strconv"
@@ -29,6 +30,27 @@ var chartColors = []lipgloss.Color{
 	lipgloss.Color("#b5838d"),
 }
 
+// chartStyle selects how the chart overlay draws the breakdown.
+type chartStyle int
+
+const (
+	chartPie     chartStyle = iota // one pie for the current directory
+	chartBar                       // one horizontal bar per category
+	chartStacked                   // one stacked bar per child
+)
+
+// String returns the name of the chart style shown in the chart title.
+func (s chartStyle) String() string {
+	switch s {
+	case chartBar:
+		return "bars"
+	case chartStacked:
+		return "stacked bars"
+	default:
+		return "pie"
+	}
+}
+
 // RawChartSector is the raw data input for building the chart.
 type RawChartSector struct {
 	Label string
@@ -46,7 +68,8 @@ type chartSector struct {
 }
 
 // Chart generates an ASCII pie chart and its legend based on the provided data.
-func Chart(width, height, radius int, totalValue float64, raw []RawChartSector) string {
+// Legend values are suffixed with unit.
+func Chart(width, height, radius int, totalValue float64, raw []RawChartSector, unit string) string {
 	sb := strings.Builder{}
 	sectors := prepareSectors(totalValue, raw)
 
@@ -95,10 +118,173 @@ func Chart(width, height, radius int, totalValue float64, raw []RawChartSector)
 
 	// Join the pie chart and legend horizontally
 	return lipgloss.JoinHorizontal(
-		lipgloss.Center, sb.String(), legend(sectors, width/2),
+		lipgloss.Center, sb.String(), legend(sectors, width/2, unit),
 	)
 }
 
+// BarChart draws one horizontal bar per category, scaled to the largest one,
+// followed by its value and share of totalValue.
+func BarChart(width int, totalValue float64, raw []RawChartSector, unit string) string {
+	sectors := prepareSectors(totalValue, raw)
+	if len(sectors) == 0 {
+		return ""
+	}
+
+	labelWidth := 0
+	for _, s := range sectors {
+		labelWidth = max(labelWidth, lipgloss.Width(s.label))
+	}
+	labelWidth = min(labelWidth, 20)
+	values := make([]string, len(sectors))
+	valueWidth := 0
+	for i, s := range sectors {
+		values[i] = fmt.Sprintf("%s (%.1f%%)", formatChartValue(s.value, unit), s.usage*100)
+		valueWidth = max(valueWidth, lipgloss.Width(values[i]))
+	}
+	barWidth := max(1, width-labelWidth-valueWidth-4)
+	maxValue := sectors[0].value
+
+	lines := make([]string, 0, len(sectors))
+	for i, s := range sectors {
+		n := 0
+		if maxValue > 0 {
+			n = int(math.Round(s.value / maxValue * float64(barWidth)))
+		}
+		bar := lipgloss.NewStyle().Foreground(s.color).Render(strings.Repeat("█", n))
+		lines = append(lines, fmt.Sprintf("%-*s %s%s %s",
+			labelWidth, fmtName(s.label, labelWidth),
+			bar, strings.Repeat(" ", barWidth-n),
+			values[i],
+		))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// StackedRow is one bar of a stacked bar chart: the breakdown of one entry.
+type StackedRow struct {
+	Label  string
+	Values map[string]float64
+}
+
+// StackedBarChart draws one bar per row, split into one segment per category
+// and scaled to the largest row, so the mix of several entries can be compared
+// side by side. Categories share their colors across rows; the ones beyond
+// maxSectors are merged into "Others".
+func StackedBarChart(width int, rows []StackedRow, unit string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+
+	// Rank categories by their overall value to assign legend colors.
+	totals := make(map[string]float64)
+	var total float64
+	for _, r := range rows {
+		for k, v := range r.Values {
+			totals[k] += v
+			total += v
+		}
+	}
+	raw := make([]RawChartSector, 0, len(totals))
+	for k, v := range totals {
+		raw = append(raw, RawChartSector{Label: k, Value: v})
+	}
+	sort.Slice(raw, func(i, j int) bool {
+		if raw[i].Value != raw[j].Value {
+			return raw[i].Value > raw[j].Value
+		}
+		return raw[i].Label < raw[j].Label
+	})
+	sectors := prepareSectors(total, raw)
+	colors := make(map[string]lipgloss.Color, len(sectors))
+	order := make([]string, 0, len(sectors))
+	for _, s := range sectors {
+		colors[s.label] = s.color
+		order = append(order, s.label)
+	}
+
+	labelWidth := 0
+	rowTotals := make([]float64, len(rows))
+	var maxRow float64
+	for i, r := range rows {
+		labelWidth = max(labelWidth, lipgloss.Width(r.Label))
+		for _, v := range r.Values {
+			rowTotals[i] += v
+		}
+		maxRow = max(maxRow, rowTotals[i])
+	}
+	labelWidth = min(labelWidth, 20)
+	valueWidth := 0
+	for _, v := range rowTotals {
+		valueWidth = max(valueWidth, lipgloss.Width(formatChartValue(v, unit)))
+	}
+	barWidth := max(1, width-labelWidth-valueWidth-4)
+
+	lines := make([]string, 0, len(rows)+2)
+	for i, r := range rows {
+		// Merge the row's categories into the legend's.
+		segments := make(map[string]float64)
+		for k, v := range r.Values {
+			if _, ok := colors[k]; !ok {
+				k = "Others"
+			}
+			segments[k] += v
+		}
+
+		var sb strings.Builder
+		var running float64
+		drawn := 0
+		for _, label := range order {
+			running += segments[label]
+			end := 0
+			if maxRow > 0 {
+				end = int(math.Round(running / maxRow * float64(barWidth)))
+			}
+			if end > drawn {
+				sb.WriteString(lipgloss.NewStyle().Foreground(colors[label]).Render(strings.Repeat("█", end-drawn)))
+				drawn = end
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%-*s %s%s %s",
+			labelWidth, fmtName(r.Label, labelWidth),
+			sb.String(), strings.Repeat(" ", barWidth-drawn),
+			formatChartValue(rowTotals[i], unit),
+		))
+	}
+
+	// A compact legend below the bars, wrapped to the chart width.
+	lines = append(lines, "")
+	var line string
+	for _, label := range order {
+		item := lipgloss.NewStyle().Foreground(colors[label]).Render("█ ") + fmtName(label, 20) + "  "
+		if line != "" && lipgloss.Width(line)+lipgloss.Width(item) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		line += item
+	}
+	lines = append(lines, line)
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// formatChartValue formats a chart value followed by its unit, if any.
+func formatChartValue(v float64, unit string) string {
+	var s string
+	if v >= 1000 {
+		// Large values use integer format
+		s = strconv.FormatFloat(v, 'f', 0, 64)
+	} else if v >= 1 {
+		// Medium values keep 1 decimal place
+		s = strconv.FormatFloat(v, 'f', 1, 64)
+	} else {
+		// Small values keep 2 decimal places
+		s = strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	if unit == "" {
+		return s
+	}
+	return s + " " + unit
+}
+
 // prepareSectors converts raw data to sectors and calculates their angles
 func prepareSectors(totalValue float64, rawSectors []RawChartSector) []chartSector {
 	// Sort by value in descending order so the largest sectors appear first
@@ -190,7 +376,7 @@ func isAngleInSector(angle, startAngle, endAngle float64) bool {
 	return angle >= startAngle-anglePrecision || angle <= endAngle+anglePrecision
 }
 
-func legend(sectors []chartSector, width int) string {
+func legend(sectors []chartSector, width int, unit string) string {
 	l := make([]string, 0, len(sectors))
 	listPadding := 2 // Left and right padding for the legend
 
@@ -198,18 +384,7 @@ func legend(sectors []chartSector, width int) string {
 		// Truncate long labels
 		label := fmtName(s.label, int(float64(width)*0.5))
 
-		// Format values based on size
-		var valueStr string
-		if s.value >= 1000 {
-			// Large values use integer format
-			valueStr = strconv.FormatFloat(s.value, 'f', 0, 64) + " lines"
-		} else if s.value >= 1 {
-			// Medium values keep 1 decimal place
-			valueStr = strconv.FormatFloat(s.value, 'f', 1, 64) + " lines"
-		} else {
-			// Small values keep 2 decimal places
-			valueStr = strconv.FormatFloat(s.value, 'f', 2, 64) + " lines"
-		}
+		valueStr := formatChartValue(s.value, unit)
 
 		// Calculate padding spaces between label and value for right alignment
 		padding := strings.Repeat(
@@ -3,6 +3,8 @@ package render
 import (
 	"strings"
 	"testing"
+
+	"github.com/charmbracelet/lipgloss"
 )
 
 func TestPrepareSectors(t *testing.T) {
@@ -87,7 +89,7 @@ func TestChart(t *testing.T) {
 	}
 
 	t.Run("returns non-empty string for valid input", func(t *testing.T) {
-		got := Chart(20, 10, 3, 100, raw)
+		got := Chart(20, 10, 3, 100, raw, "lines")
 		if got == "" {
 			t.Errorf("Chart() returned empty string")
 		}
@@ -103,7 +105,7 @@ func TestChart(t *testing.T) {
 			}
 		}()
 
-		got := Chart(0, 0, 1, 100, raw)
+		got := Chart(0, 0, 1, 100, raw, "lines")
 		if got == "" {
 			t.Errorf("Chart() returned empty string")
 		}
@@ -116,7 +118,7 @@ func TestLegend(t *testing.T) {
 		{label: "Python", value: 40, usage: 0.4, color: chartColors[1]},
 	}
 
-	out := legend(sectors, 40)
+	out := legend(sectors, 40, "lines")
 	if out == "" {
 		t.Fatalf("legend() returned empty string")
 	}
@@ -132,3 +134,64 @@ func TestLegend(t *testing.T) {
 		}
 	}
 }
+
+func TestBarChart(t *testing.T) {
+	raw := []RawChartSector{
+		{Label: "Go", Value: 60},
+		{Label: "Python", Value: 30},
+	}
+	out := BarChart(40, 90, raw, "files")
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one bar per category, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "Go") || !strings.Contains(lines[0], "60.0 files (66.7%)") {
+		t.Errorf("unexpected first bar %q", lines[0])
+	}
+	// Bars are scaled to the largest category.
+	if got, want := strings.Count(lines[1], "█")*2, strings.Count(lines[0], "█"); got != want && got != want+1 {
+		t.Errorf("expected the second bar to be half as long: %q vs %q", lines[1], lines[0])
+	}
+	if w := lipgloss.Width(out); w > 40 {
+		t.Errorf("expected bars to fit the width, got %d", w)
+	}
+}
+
+func TestStackedBarChart(t *testing.T) {
+	rows := []StackedRow{
+		{Label: "api/", Values: map[string]float64{"Go": 300, "Python": 100}},
+		{Label: "jobs/", Values: map[string]float64{"Python": 200}},
+	}
+	out := StackedBarChart(50, rows, "lines")
+	lines := strings.Split(out, "\n")
+	if !strings.HasPrefix(lines[0], "api/") || !strings.Contains(lines[0], "400.0 lines") {
+		t.Errorf("unexpected first row %q", lines[0])
+	}
+	// jobs has half the lines of api and gets half the bar.
+	if got, want := strings.Count(lines[1], "█")*2, strings.Count(lines[0], "█"); got != want && got != want+1 {
+		t.Errorf("expected the second bar to be half as long: %q vs %q", lines[1], lines[0])
+	}
+	legend := lines[len(lines)-1]
+	if !strings.Contains(legend, "Go") || !strings.Contains(legend, "Python") {
+		t.Errorf("expected a legend of all categories, got %q", legend)
+	}
+	if StackedBarChart(50, nil, "lines") != "" {
+		t.Error("expected no chart without rows")
+	}
+}
+
+func TestFormatChartValue(t *testing.T) {
+	for _, tc := range []struct {
+		v    float64
+		unit string
+		want string
+	}{
+		{1234, "lines", "1234 lines"},
+		{12.5, "files", "12.5 files"},
+		{0.25, "", "0.25"},
+	} {
+		if got := formatChartValue(tc.v, tc.unit); got != tc.want {
+			t.Errorf("formatChartValue(%v, %q) = %q, want %q", tc.v, tc.unit, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/zdyxry/tokui/provider"
+	"github.com/zdyxry/tokui/structure"
+	"github.com/zdyxry/tokui/vcs"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ChurnLoaded reports the result of reading the git history of the tree.
+type ChurnLoaded struct {
+	Churn map[string]structure.Churn // keyed by path relative to the tree root
+	Err   error
+}
+
+// SetChurnWindow sets how far back git history is read for churn and hotspot
+// analysis, e.g. "6.months". History is read the first time a churn metric is
+// selected. An empty window disables churn analysis.
+func (dm *DirModel) SetChurnWindow(since string) {
+	dm.churnSince = since
+	dm.churnEnabled = since != ""
+}
+
+// churnAvailable reports whether the churn metrics can be selected.
+func (dm *DirModel) churnAvailable() bool {
+	return dm.churnEnabled || dm.hasChurn
+}
+
+// churnWanted reports whether the table is sorted by, or the treemap colored
+// by, a churn metric.
+func (dm *DirModel) churnWanted() bool {
+	isChurn := func(k SortKey) bool { return k == SortByCommits || k == SortByChurn || k == SortByHotspot }
+	return isChurn(dm.sortState.Key) || dm.treemapColorMode == treemapColorHeat && isChurn(dm.treemapHeatKey)
+}
+
+// churnCmd returns a command reading churn for the whole tree from git log
+// once a churn metric is first selected.
+func (dm *DirModel) churnCmd() tea.Cmd {
+	root := dm.nav.tree.Root()
+	if !dm.churnEnabled || dm.churnLoading || dm.hasChurn || root == nil || !dm.churnWanted() {
+		return nil
+	}
+	dm.churnLoading = true
+	dir, since := root.Path, dm.churnSince
+	return func() tea.Msg {
+		churn, err := vcs.Churn(dir, since)
+		if err != nil {
+			return ChurnLoaded{Err: err}
+		}
+		byPath := make(map[string]structure.Churn, len(churn))
+		for path, c := range churn {
+			byPath[path] = structure.Churn{Commits: c.Commits, Added: c.Added, Removed: c.Removed}
+		}
+		return ChurnLoaded{Churn: byPath}
+	}
+}
+
+// applyChurn merges loaded churn into the tree and adds the churn columns.
+// When history cannot be read, the churn metrics are withdrawn and the sort
+// and heat metric that asked for them are reset.
+func (dm *DirModel) applyChurn(msg ChurnLoaded) {
+	dm.churnLoading = false
+	if msg.Err != nil {
+		dm.err = fmt.Errorf("churn analysis unavailable: %w", msg.Err)
+		dm.churnEnabled = false
+		switch dm.sortState.Key {
+		case SortByCommits, SortByChurn, SortByHotspot:
+			dm.sortState = SortState{Key: SortByTotal, Desc: true}
+		}
+		if !slices.Contains(dm.heatMetrics(), dm.treemapHeatKey) {
+			dm.treemapHeatKey = dm.heatMetrics()[0]
+		}
+		dm.updateTableData()
+		return
+	}
+	dm.nav.tree.ApplyChurn(msg.Churn)
+	dm.hasChurn = true
+	dm.columns = append(dm.columns,
+		Column{Title: "Commits", SortKey: SortByCommits},
+		Column{Title: "Churn", SortKey: SortByChurn},
+		Column{Title: "Hotspot", SortKey: SortByHotspot},
+	)
+	dm.applyStatsOverlay()
+	dm.updateTableData()
+}
+
+// hotspot returns the hotspot score of an entry: change frequency weighted by
+// complexity when the provider reports it, by lines of code otherwise.
+func (dm *DirModel) hotspot(e *structure.Entry) int64 {
+	c := dm.statsEntry(e).Churn
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		return c.ComplexityHotspot
+	}
+	return c.CodeHotspot
+}
+
+// hotspotDensity is the hotspot score per line of code, which makes entries
+// of different sizes comparable on the heat scale.
+func (dm *DirModel) hotspotDensity(e *structure.Entry) float64 {
+	code := dm.statsEntry(e).TotalStats.Code
+	if code <= 0 {
+		return 0
+	}
+	return float64(dm.hotspot(e)) / float64(code)
+}
+
+// hotspotHeatValue returns the hotspot density of entries with code.
+func (dm *DirModel) hotspotHeatValue(e *structure.Entry) (float64, bool) {
+	if dm.statsEntry(e).TotalStats.Code == 0 {
+		return 0, false
+	}
+	return dm.hotspotDensity(e), true
+}
@@ -3,15 +3,30 @@ package render
 type SortKey string
 
 const (
-	SortByNone       SortKey = ""
-	SortByName       SortKey = "name"
-	SortByLanguages  SortKey = "languages"
-	SortByCode       SortKey = "code"
-	SortByComments   SortKey = "comments"
-	SortByBlanks     SortKey = "blanks"
-	SortByTotal      SortKey = "total"
-	SortByPercent    SortKey = "percent"
-	SortByComplexity SortKey = "complexity"
+	SortByNone              SortKey = ""
+	SortByName              SortKey = "name"
+	SortByLanguages         SortKey = "languages"
+	SortByCode              SortKey = "code"
+	SortByComments          SortKey = "comments"
+	SortByBlanks            SortKey = "blanks"
+	SortByTotal             SortKey = "total"
+	SortByFiles             SortKey = "files"
+	SortByAvgLines          SortKey = "lines/file"
+	SortByCommentRatio      SortKey = "comment ratio"
+	SortByBlankRatio        SortKey = "blank ratio"
+	SortByPercent           SortKey = "percent"
+	SortByComplexity        SortKey = "complexity"
+	SortByComplexityDensity SortKey = "complexity/kloc"
+	SortByMaxComplexity     SortKey = "max complexity"
+	SortByTestRatio         SortKey = "test ratio"
+	SortByOwners            SortKey = "owners"
+	SortByAuthors           SortKey = "authors"
+	SortByCommits           SortKey = "commits"
+	SortByChurn             SortKey = "churn"
+	SortByHotspot           SortKey = "hotspot"
+	SortByLastChanged       SortKey = "last changed"
+	SortByAge               SortKey = "age"
+	SortByPivotOther        SortKey = "other"
 )
 
 type Column struct {
@@ -0,0 +1,202 @@
+package render
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/zdyxry/tokui/search"
+	"github.com/zdyxry/tokui/structure"
+)
+
+const (
+	contentSearchMinQuery = 2   // shorter queries match nearly every line
+	contentSearchLimit    = 500 // matches kept per search
+	contentSearchContext  = 2   // lines shown around the selected match
+)
+
+// ContentSearchDone carries the results of a content search. Seq identifies
+// the search, so results of a query that has since changed are dropped.
+type ContentSearchDone struct {
+	Seq     int
+	Matches []search.LineMatch
+}
+
+// openContentSearch opens the global search overlay searching file contents.
+func (dm *DirModel) openContentSearch() tea.Cmd {
+	cmd := dm.openGlobalSearch()
+	dm.searchContent = true
+	return cmd
+}
+
+// toggleContentSearch switches the search overlay between file names and file
+// contents, keeping the query.
+func (dm *DirModel) toggleContentSearch() tea.Cmd {
+	dm.searchContent = !dm.searchContent
+	dm.searchCursor, dm.searchOffset = 0, 0
+	if !dm.searchContent {
+		dm.cancelContentSearch()
+		return nil
+	}
+	return dm.contentSearchCmd()
+}
+
+// cancelContentSearch stops a running content search and drops its results.
+func (dm *DirModel) cancelContentSearch() {
+	if dm.contentCancel != nil {
+		dm.contentCancel()
+		dm.contentCancel = nil
+	}
+	dm.contentSeq++
+	dm.contentSearching = false
+	dm.contentMatches = nil
+}
+
+// contentSearchCmd starts searching the files below the current directory, or
+// the in: directory of the query, for the query text, replacing any search
+// still running. The lang: filter of the query applies as well.
+func (dm *DirModel) contentSearchCmd() tea.Cmd {
+	dm.cancelContentSearch()
+	q := search.ParseQuery(dm.searchInput.Value())
+	if utf8.RuneCountInString(q.Text) < contentSearchMinQuery || dm.searchIndex == nil {
+		return nil
+	}
+
+	dir := q.In
+	if dir == "" {
+		dir = "."
+		if rel, err := filepath.Rel(dm.nav.tree.Root().Path, dm.nav.Entry().Path); err == nil {
+			dir = filepath.ToSlash(rel)
+		}
+	}
+	var files []search.Item
+	excluded := dm.excludedPaths()
+	for _, item := range dm.searchIndex.FilesUnder(dir) {
+		if q.Matches(item) && !dm.isExcludedPath(excluded, item.Entry.Path) {
+			files = append(files, item)
+		}
+	}
+	query := q.Text
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dm.contentCancel = cancel
+	dm.contentSearching = true
+	seq := dm.contentSeq
+	return func() tea.Msg {
+		return ContentSearchDone{Seq: seq, Matches: search.Grep(ctx, files, query, contentSearchContext, contentSearchLimit)}
+	}
+}
+
+// applyContentSearch shows the results of the latest content search.
+func (dm *DirModel) applyContentSearch(msg ContentSearchDone) {
+	if msg.Seq != dm.contentSeq || !dm.searchContent {
+		return
+	}
+	dm.contentCancel = nil
+	dm.contentSearching = false
+	dm.contentMatches = msg.Matches
+	dm.searchCursor, dm.searchOffset = 0, 0
+}
+
+// searchResultCount returns the number of results in the search overlay.
+func (dm *DirModel) searchResultCount() int {
+	if dm.searchContent {
+		return len(dm.contentMatches)
+	}
+	return len(dm.searchMatches)
+}
+
+// selectedContentMatch returns the highlighted content match, if any.
+func (dm *DirModel) selectedContentMatch() *search.LineMatch {
+	if dm.mode != SEARCH || !dm.searchContent || dm.searchCursor < 0 || dm.searchCursor >= len(dm.contentMatches) {
+		return nil
+	}
+	return &dm.contentMatches[dm.searchCursor]
+}
+
+// selectedSearchEntry returns the entry of the highlighted search result.
+func (dm *DirModel) selectedSearchEntry() *structure.Entry {
+	if m := dm.selectedContentMatch(); m != nil {
+		return m.Item.Entry
+	}
+	if m := dm.SelectedSearchMatch(); m != nil {
+		return m.Item.Entry
+	}
+	return nil
+}
+
+// openContentResult closes the search and previews the file of the selected
+// match, scrolled to the matching line.
+func (dm *DirModel) openContentResult() {
+	m := dm.selectedContentMatch()
+	if m == nil {
+		dm.closeGlobalSearch()
+		return
+	}
+	path, line := m.Item.Entry.Path, m.Line
+	dm.closeGlobalSearch()
+	dm.ShowFilePreview(path)
+	dm.filePreview.ScrollToLine(line)
+}
+
+// renderContentResult renders a content match as "path:line text" with the
+// match highlighted.
+func (dm *DirModel) renderContentResult(m search.LineMatch, selected bool, maxWidth int) string {
+	highlightStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#fb5607"))
+	locStyle := lipgloss.NewStyle().Faint(true)
+
+	prefix := EntryIcon(m.Item.Entry) + "  "
+	if dm.isMarked(m.Item.Entry) {
+		prefix = selectionMark + EntryIcon(m.Item.Entry) + " "
+	}
+	line := prefix + locStyle.Render(fmt.Sprintf("%s:%d", m.Item.Path, m.Line)) + "  " +
+		highlightMatch(m.Text, m.Start, m.End, highlightStyle)
+	line = ansi.Truncate(line, maxWidth, "…")
+
+	if selected {
+		if pad := maxWidth - lipgloss.Width(line); pad > 0 {
+			line += strings.Repeat(" ", pad)
+		}
+		line = lipgloss.NewStyle().Background(lipgloss.Color("240")).Render(line)
+	}
+	return line
+}
+
+// highlightMatch renders text without leading indentation, styling the bytes
+// from start to end.
+func highlightMatch(text string, start, end int, style lipgloss.Style) string {
+	trimmed := strings.TrimLeft(text, " \t")
+	shift := len(text) - len(trimmed)
+	start, end = start-shift, end-shift
+	expand := func(s string) string { return strings.ReplaceAll(s, "\t", "  ") }
+	if start < 0 || end > len(trimmed) || start >= end {
+		return expand(trimmed)
+	}
+	return expand(trimmed[:start]) + style.Render(expand(trimmed[start:end])) + expand(trimmed[end:])
+}
+
+// contentContextLines renders the lines around the selected match, numbered.
+func (dm *DirModel) contentContextLines(maxWidth int) []string {
+	m := dm.selectedContentMatch()
+	if m == nil {
+		return nil
+	}
+	faint := lipgloss.NewStyle().Faint(true)
+	numbered := func(n int, text string) string {
+		return ansi.Truncate(fmt.Sprintf("%5d  %s", n, strings.ReplaceAll(text, "\t", "  ")), maxWidth, "…")
+	}
+	var lines []string
+	for i, text := range m.Before {
+		lines = append(lines, faint.Render(numbered(m.Line-len(m.Before)+i, text)))
+	}
+	lines = append(lines, lipgloss.NewStyle().Bold(true).Render(numbered(m.Line, m.Text)))
+	for i, text := range m.After {
+		lines = append(lines, faint.Render(numbered(m.Line+1+i, text)))
+	}
+	return lines
+}
@@ -2,6 +2,7 @@ package render
 
 import (
 	"cmp"
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -17,6 +18,7 @@ import (
 	"github.com/zdyxry/tokui/provider"
 	"github.com/zdyxry/tokui/search"
 	"github.com/zdyxry/tokui/structure"
+	"github.com/zdyxry/tokui/vcs"
 
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -34,14 +36,29 @@ const (
 	INPUT   Mode = "INPUT"
 	PREVIEW Mode = "PREVIEW"
 	SEARCH  Mode = "SEARCH"
+	QUERY   Mode = "QUERY"
 )
 
 const (
-	SELECT_LANG Mode = "SELECT_LANG"
+	SELECT_LANG  Mode = "SELECT_LANG"
+	SELECT_OWNER Mode = "SELECT_OWNER"
+	LANG_SUMMARY Mode = "LANG_SUMMARY"
+	EXCLUDED     Mode = "EXCLUDED"
+	PRESETS      Mode = "PRESETS"
 )
 
 type CycleLangFilter struct{}
 
+// chartBreakdown selects how the pie chart overlay splits the current
+// directory.
+type chartBreakdown int
+
+const (
+	chartByLang chartBreakdown = iota
+	chartByOwner
+	chartByAuthor
+)
+
 // OpenFileInEditor represents a message to open a file in the default editor.
 type OpenFileInEditor struct {
 	Path string
@@ -61,6 +78,7 @@ type tableEntry struct {
 	entry    *structure.Entry
 	depth    int
 	isParent bool
+	folded   []*structure.Entry // entries summed by a "(N smaller entries)" row
 }
 
 type DirModel struct {
@@ -81,28 +99,107 @@ type DirModel struct {
 	selectedLangs       map[string]bool
 	selectLangsSnapshot map[string]bool
 	selectIndex         int
+	// Owner state
+	owners               []string
+	selectedOwners       map[string]bool
+	selectOwnersSnapshot map[string]bool
+	statsOverlay         map[*structure.Entry]*structure.Entry // owner- or filter-recomputed stats, nil when unfiltered
+	filterTotals         bool                                  // name and query filters recompute totals
+	overlayInputs        string                                // overlaySignature the overlay was computed for
+	chartBy              chartBreakdown
+	chartMetric          SortKey // lines, code, comments, blanks, complexity or files
+	chartStyle           chartStyle
+	// Authors mode state
+	authorsMode    bool
+	authorsLoading bool
+	authorsRoot    *structure.Entry // directory being blamed while loading
+	authorsCancel  context.CancelFunc
+	blamer         *vcs.Blamer
+	blamedDirs     map[*structure.Entry]bool
+	// Churn state
+	churnSince   string
+	churnEnabled bool
+	churnLoading bool
+	hasChurn     bool
+	timesEnabled bool
+	timesLoading bool
+	hasTimes     bool
 	err          error
 	providerInfo provider.Info
 	tableEntries []*tableEntry
 	treeMode     bool
 	treemapMode  bool
+	icicleMode   bool // lays out the treemap view as an icicle chart
+	flatMode     bool
 	sortState    SortState
+	scope        structure.Scope
+	percentStyle percentStyle // how the "% of Parent" column is rendered
+
+	// Folding of small rows
+	fold     foldThreshold
+	minLines int64                     // line threshold from the command line
+	unfolded map[*structure.Entry]bool // directories whose small rows are expanded
+
+	// Language summary screen state
+	langSummary       []langSummaryRow
+	langSummarySort   SortState
+	langSummaryCursor int
+	langSummaryStart  int // first row shown on the last render
+
+	// Exclusion state
+	excluded             map[*structure.Entry]bool // entries left out of all totals
+	unresolvedExclusions []string                  // ignore file lines matching no entry
+	exclusionsLoaded     bool
+	exclusionIndex       int
+	exclusionNote        string // result of the last save
+
+	// Preset state
+	startPreset  string // applied after the first scan
+	presets      []Preset
+	presetIndex  int
+	presetSaving bool // the overlay asks for the name of a new preset
+	presetInput  textinput.Model
+
+	// Selection state
+	marked        map[*structure.Entry]bool // rows marked across directories
+	showSelection bool                      // show the selection statistics panel
+
+	// Pivot matrix state
+	pivotMode    bool
+	pivotPercent bool // show shares of each row instead of lines of code
+	pivotLangs   []string
+	pivotMax     float64
 
 	// Treemap view state
-	treemapBlocks      []treemapBlock
-	treemapSelected    int
-	treemapOffsetY     int // screen Y where the treemap canvas starts
-	treemapColorByLang bool
-	treemapSizeKey     SortKey
+	treemapBlocks    []treemapBlock
+	treemapSelected  int
+	treemapOffsetY   int // screen Y where the treemap canvas starts
+	treemapColorMode treemapColorMode
+	treemapHeatKey   SortKey
+	heatMax          float64 // largest file value of the heat metric, see syncHeatMax
+	heatMaxInputs    string  // heatMaxSignature heatMax was computed for
+	treemapSizeKey   SortKey
 
 	// Global search state
 	searchIndex         *search.Index
+	searchIndexer       *search.IndexBuilder // builds searchIndex, nil when not indexing
 	searchInput         textinput.Model
 	searchMatches       []search.Match
 	searchCursor        int
 	searchOffset        int
+	searchSort          SortKey // SortByNone orders results by fuzzy score
+	searchSeq           int     // identifies the latest query typed
+	searchPending       bool    // a query is waiting or running in the background
+	searchCancel        context.CancelFunc
 	pendingSearchTarget *structure.Entry // for treemap mode selection after render
 
+	// Content search state
+	searchContent    bool // search file contents instead of names
+	contentMatches   []search.LineMatch
+	contentSeq       int // identifies the latest content search
+	contentSearching bool
+	contentCancel    context.CancelFunc
+
 	// Mouse support
 	overlayBounds overlayBounds
 	lastClick     mouseClick
@@ -116,11 +213,11 @@ type mouseClick struct {
 
 // overlayBounds tracks the screen position of the currently rendered overlay.
 type overlayBounds struct {
-	kind      string // "preview", "chart", "langselect" or "search"
+	kind      string // "preview", "chart", "langselect", "ownerselect", "selection", "exclusions", "presets" or "search"
 	x, y      int    // top-left corner
 	w, h      int    // width and height
-	langStart int    // first visible language index (for langselect)
-	langEnd   int    // last visible language index + 1 (for langselect)
+	listStart int    // first visible item index (for select overlays)
+	listEnd   int    // last visible item index + 1 (for select overlays)
 }
 
 const tableHeaderHeight = 2 // TableHeaderStyle has BorderBottom and no padding
@@ -135,9 +232,9 @@ func NewDirModel(nav *Navigation, info provider.Info, treeMode, treemapMode bool
 	// Define new column headers for the table. Optional metrics are appended
 	// based on the Provider's advertised capabilities.
 	columns := []Column{
-		{Title: ""},                                    // Icon
-		{Title: ""},                                    // Full path (hidden)
-		{Title: "Name", SortKey: SortByName},           // Name
+		{Title: ""},                          // Icon
+		{Title: ""},                          // Full path (hidden)
+		{Title: "Name", SortKey: SortByName}, // Name
 		{Title: "Languages", SortKey: SortByLanguages}, // Languages involved
 		{Title: "Code", SortKey: SortByCode},           // Lines of code
 		{Title: "Comments", SortKey: SortByComments},   // Comment lines
@@ -146,33 +243,46 @@ func NewDirModel(nav *Navigation, info provider.Info, treeMode, treemapMode bool
 		{Title: "% of Parent", SortKey: SortByPercent}, // Percentage of parent directory
 	}
 	if info.Capabilities&provider.CapComplexity != 0 {
-		columns = append(columns, Column{Title: "Complexity", SortKey: SortByComplexity})
+		columns = append(columns,
+			Column{Title: "Complexity", SortKey: SortByComplexity},
+			Column{Title: "Cx/KLOC", SortKey: SortByComplexityDensity},
+			Column{Title: "Max Cx", SortKey: SortByMaxComplexity},
+		)
 	}
+	columns = append(columns,
+		Column{Title: "Test %", SortKey: SortByTestRatio},
+		Column{Title: "Files", SortKey: SortByFiles},
+		Column{Title: "Lines/File", SortKey: SortByAvgLines},
+		Column{Title: "Comment %", SortKey: SortByCommentRatio},
+		Column{Title: "Blank %", SortKey: SortByBlankRatio},
+	)
 
-
-	// Keep only the name filter
 	defaultFilters := []filter.EntryFilter{
 		filter.NewNameFilter("Filter by name..."),
+		filter.NewQueryFilter("lang:Go code>500 comments/code<0.05 path:services/ !generated"),
 	}
 
 	searchInput := newSearchInput()
 
 	dm := &DirModel{
-		columns:      columns,
-		filters:      filter.NewFiltersList(defaultFilters...),
-		dirsTable:    buildTable(),
-		mode:         PENDING,
-		nav:          nav,
-		langFilterIdx: -1, // Default to show all languages
-		selectMode:   false,
-		selectedLangs: make(map[string]bool),
-		selectIndex:  0,
-		providerInfo: info,
-		treeMode:     treeMode,
-		treemapMode:  treemapMode,
+		columns:        columns,
+		filters:        filter.NewFiltersList(defaultFilters...),
+		dirsTable:      buildTable(),
+		mode:           PENDING,
+		nav:            nav,
+		langFilterIdx:  -1, // Default to show all languages
+		selectMode:     false,
+		selectedLangs:  make(map[string]bool),
+		selectIndex:    0,
+		selectedOwners: make(map[string]bool),
+		providerInfo:   info,
+		treeMode:       treeMode,
+		treemapMode:    treemapMode,
 		treemapSizeKey: SortByTotal,
-		sortState:    SortState{Key: SortByTotal, Desc: true},
-		searchInput:  searchInput,
+		chartMetric:    SortByTotal,
+		treemapHeatKey: SortByTestRatio,
+		sortState:      SortState{Key: SortByTotal, Desc: true},
+		searchInput:    searchInput,
 	}
 
 	return dm
@@ -182,6 +292,9 @@ func NewDirModel(nav *Navigation, info provider.Info, treeMode, treemapMode bool
 // terminal width and sort key. Optional columns are hidden on narrow screens
 // unless they are the active sort column.
 func (dm *DirModel) visibleColumns() []Column {
+	if dm.pivotMode {
+		return dm.pivotColumns()
+	}
 	cols := make([]Column, 0, len(dm.columns))
 	for _, c := range dm.columns {
 		switch c.SortKey {
@@ -189,6 +302,26 @@ func (dm *DirModel) visibleColumns() []Column {
 			if dm.width < 80 && dm.sortState.Key != SortByComplexity {
 				continue
 			}
+		case SortByTestRatio:
+			if dm.width < 100 && dm.sortState.Key != SortByTestRatio {
+				continue
+			}
+		case SortByFiles:
+			if dm.width < 130 && dm.sortState.Key != SortByFiles {
+				continue
+			}
+		case SortByOwners, SortByAuthors, SortByHotspot:
+			if dm.width < 120 && dm.sortState.Key != c.SortKey {
+				continue
+			}
+		case SortByAvgLines, SortByCommits, SortByChurn, SortByLastChanged, SortByAge:
+			if dm.width < 140 && dm.sortState.Key != c.SortKey {
+				continue
+			}
+		case SortByComplexityDensity, SortByMaxComplexity, SortByCommentRatio, SortByBlankRatio:
+			if dm.width < 160 && dm.sortState.Key != c.SortKey {
+				continue
+			}
 		case SortByLanguages, SortByComments, SortByBlanks:
 			if dm.width < 60 && dm.sortState.Key != c.SortKey {
 				continue
@@ -204,7 +337,7 @@ func columnMinWidth(c Column) int {
 	switch c.SortKey {
 	case SortByName:
 		return 0 // computed from content
-	case SortByLanguages:
+	case SortByLanguages, SortByOwners, SortByAuthors:
 		return 24
 	case SortByPercent:
 		return 14
@@ -230,9 +363,13 @@ func (dm *DirModel) buildParentRow(cols []Column) table.Row {
 			switch c.SortKey {
 			case SortByName:
 				row[i] = ".."
-			case SortByLanguages, SortByPercent:
+			case SortByLanguages, SortByPercent, SortByTestRatio, SortByCommentRatio, SortByBlankRatio, SortByComplexityDensity, SortByOwners, SortByAuthors, SortByLastChanged, SortByAge:
 				row[i] = ""
 			default:
+				if isPivotKey(c.SortKey) {
+					row[i] = ""
+					continue
+				}
 				row[i] = "0"
 			}
 		}
@@ -246,6 +383,9 @@ func (dm *DirModel) buildRow(cols []Column, entry *structure.Entry, name, langSt
 		switch i {
 		case 0:
 			row[i] = EntryIcon(entry)
+			if dm.isMarked(entry) {
+				row[i] = selectionMark + row[i]
+			}
 		case 1:
 			row[i] = entry.Path
 		default:
@@ -262,11 +402,50 @@ func (dm *DirModel) buildRow(cols []Column, entry *structure.Entry, name, langSt
 				row[i] = strconv.FormatInt(stats.Blanks, 10)
 			case SortByTotal:
 				row[i] = strconv.FormatInt(stats.Total(), 10)
+			case SortByFiles:
+				row[i] = strconv.FormatInt(stats.Files, 10)
+			case SortByAvgLines:
+				row[i] = strconv.FormatInt(avgLines(stats), 10)
 			case SortByPercent:
-				row[i] = fmt.Sprintf("%.2f %%", percent)
+				row[i] = dm.formatPercent(percent)
 			case SortByComplexity:
 				row[i] = strconv.FormatInt(stats.Complexity, 10)
+			case SortByComplexityDensity:
+				if v, ok := complexityDensity(stats); ok {
+					row[i] = fmt.Sprintf("%.1f", v)
+				}
+			case SortByMaxComplexity:
+				row[i] = strconv.FormatInt(stats.MaxComplexity, 10)
+			case SortByCommentRatio, SortByBlankRatio:
+				if v, ok := ratioValue(stats, c.SortKey); ok {
+					row[i] = fmt.Sprintf("%.1f %%", v*100)
+				}
+			case SortByTestRatio:
+				row[i] = fmt.Sprintf("%.1f %%", dm.testRatio(entry)*100)
+			case SortByOwners:
+				row[i] = strings.Join(entryOwners(entry), ", ")
+			case SortByAuthors:
+				row[i] = topAuthorsLabel(dm.statsEntry(entry))
+			case SortByCommits:
+				row[i] = strconv.FormatInt(dm.statsEntry(entry).Churn.Commits, 10)
+			case SortByChurn:
+				c := dm.statsEntry(entry).Churn
+				row[i] = fmt.Sprintf("+%d -%d", c.Added, c.Removed)
+			case SortByHotspot:
+				row[i] = strconv.FormatInt(dm.hotspot(entry), 10)
+			case SortByLastChanged:
+				if t := dm.statsEntry(entry).Times.LastChanged; !t.IsZero() {
+					row[i] = t.Format(time.DateOnly)
+				}
+			case SortByAge:
+				if d, ok := dm.age(entry); ok {
+					row[i] = formatAge(d)
+				}
 			default:
+				if isPivotKey(c.SortKey) {
+					row[i] = dm.formatPivotCell(entry, c.SortKey)
+					continue
+				}
 				row[i] = ""
 			}
 		}
@@ -299,21 +478,30 @@ func copyLangSelection(src map[string]bool) map[string]bool {
 
 func (dm *DirModel) ToggleTreeMode() {
 	dm.treeMode = !dm.treeMode
+	if dm.treeMode {
+		dm.flatMode = false
+		dm.pivotMode = false
+	}
 	dm.updateTableData()
 }
 
 func (dm *DirModel) ToggleTreemapMode() {
-	dm.treemapMode = !dm.treemapMode
+	// From the icicle layout, m switches to the treemap layout instead.
+	dm.treemapMode = !dm.treemapMode || dm.icicleMode
+	dm.icicleMode = false
 	if dm.treemapMode {
-		// Treemap and tree mode are mutually exclusive at the view level.
+		// Treemap, tree, flat and pivot mode are mutually exclusive at the
+		// view level.
 		dm.treeMode = false
+		dm.flatMode = false
+		dm.pivotMode = false
 	}
 	dm.treemapSelected = 0
 	dm.updateTableData()
 }
 
 func (dm *DirModel) Init() tea.Cmd {
-	return nil
+	return dm.churnCmd()
 }
 
 func (dm *DirModel) SelectedEntry() *structure.Entry {
@@ -328,7 +516,7 @@ func (dm *DirModel) SelectedEntry() *structure.Entry {
 	if cursor < 0 || cursor >= len(dm.tableEntries) {
 		return nil
 	}
-	if dm.tableEntries[cursor].isParent {
+	if dm.tableEntries[cursor].isParent || dm.tableEntries[cursor].folded != nil {
 		return nil
 	}
 	return dm.tableEntries[cursor].entry
@@ -351,8 +539,27 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ScanFinished:
 		dm.mode = READY
 		dm.updateLanguages()
+		dm.updateOwners()
+		if err := dm.loadExclusions(); err != nil {
+			dm.err = err
+		}
+		if f, ok := dm.filters[filter.NameFilterID].(*filter.NameFilter); ok {
+			f.SetRoot(dm.nav.tree.Root().Path)
+		}
+		if q, ok := dm.queryFilter(); ok {
+			q.SetRoot(dm.nav.tree.Root().Path)
+			q.SetLanguages(dm.nav.tree.Root().Languages())
+		}
+		if err := dm.applyStartPreset(); err != nil {
+			dm.err = err
+		}
 		dm.updateTableData(msg.ResetCursor)
-		dm.searchIndex = search.BuildIndex(dm.nav.tree.Root())
+		if dm.searchIndex.Root() != dm.nav.tree.Root() {
+			dm.searchIndex = nil // rebuilt when the search opens
+		}
+		if dm.searchIndexer != nil && dm.searchIndexer.Index().Root() != dm.nav.tree.Root() {
+			dm.searchIndexer = nil
+		}
 
 	case CycleLangFilter:
 		if len(dm.languages) > 0 {
@@ -375,6 +582,24 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ErrorMsg:
 		dm.err = msg.Err
 		return dm, nil
+	case AuthorsLoaded:
+		return dm, dm.applyAuthors(msg)
+	case ChurnLoaded:
+		dm.applyChurn(msg)
+		return dm, nil
+	case TimesLoaded:
+		dm.applyTimes(msg)
+		return dm, nil
+	case ContentSearchDone:
+		dm.applyContentSearch(msg)
+		return dm, nil
+	case SearchDebounced:
+		return dm, dm.runQueuedSearch(msg)
+	case SearchDone:
+		dm.applySearchDone(msg)
+		return dm, nil
+	case SearchIndexing:
+		return dm, dm.continueSearchIndex(msg)
 
 	case tea.WindowSizeMsg:
 		dm.updateSize(msg.Width, msg.Height)
@@ -422,72 +647,14 @@ func (dm *DirModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (dm *DirModel) View() string {
 	h := lipgloss.Height
 
-	// Language select overlay
-	if dm.mode == SELECT_LANG {
-		var lines []string
-		title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).Render("Select Languages")
-		desc := lipgloss.NewStyle().Faint(true).Render("Space: toggle, Enter: confirm, Esc: cancel")
-		lines = append(lines, title)
-		lines = append(lines, desc)
-		// Calculate visible window height (excluding title/desc, at least 2 lines)
-		maxList := dm.height - 6
-		if maxList < 2 {
-			maxList = 2
-		}
-		start := 0
-		end := len(dm.languages)
-		if len(dm.languages) > maxList {
-			// Ensure highlighted item is visible
-			if dm.selectIndex < maxList/2 {
-				start = 0
-			} else if dm.selectIndex > len(dm.languages)-maxList/2 {
-				start = len(dm.languages) - maxList
-			} else {
-				start = dm.selectIndex - maxList/2
-			}
-			end = start + maxList
-			if end > len(dm.languages) {
-				end = len(dm.languages)
-			}
-		}
-		if start > 0 {
-			lines = append(lines, lipgloss.NewStyle().Faint(true).Render("..."))
-		}
-		for i := start; i < end; i++ {
-			lang := dm.languages[i]
-			cursor := "  "
-			if i == dm.selectIndex {
-				cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("#3a86ff")).Render("→ ")
-			}
-			var checked string
-			if dm.selectedLangs[lang] {
-				checked = lipgloss.NewStyle().Foreground(lipgloss.Color("#fb5607")).Render("[x]")
-			} else {
-				checked = lipgloss.NewStyle().Faint(true).Render("[ ]")
-			}
-			langStr := lang
-			if i == dm.selectIndex {
-				langStr = lipgloss.NewStyle().Bold(true).Render(lang)
-			}
-			lines = append(lines, cursor+checked+" "+langStr)
-		}
-		if end < len(dm.languages) {
-			lines = append(lines, lipgloss.NewStyle().Faint(true).Render("..."))
-		}
-		box := chartBoxStyle.Render(lipgloss.JoinVertical(lipgloss.Top, lines...))
-		boxW := lipgloss.Width(box)
-		boxH := lipgloss.Height(box)
-		dm.overlayBounds = overlayBounds{
-			kind:      "langselect",
-			x:         dm.width/2 - boxW/2,
-			y:         dm.height/2 - boxH/2,
-			w:         boxW,
-			h:         boxH,
-			langStart: start,
-			langEnd:   end,
-		}
+	if dm.mode == LANG_SUMMARY {
+		return dm.viewLangSummary()
+	}
+
+	// Language or owner select overlay
+	if dm.inSelectMode() {
 		bg := lipgloss.NewStyle().Width(dm.width).Height(dm.height).Render(" ")
-		return OverlayCenter(dm.width, dm.height, bg, box)
+		return OverlayCenter(dm.width, dm.height, bg, dm.viewSelectOverlay())
 	}
 
 	summary := dm.dirsSummary()
@@ -503,13 +670,13 @@ func (dm *DirModel) View() string {
 
 	rows := []string{keyBindings, summary}
 
-	// If the name filter is active, reserve space for it
-	var filterView string
-	if f, ok := dm.filters[filter.NameFilterID].(filter.Viewer); ok {
-		filterView = f.View()
-		if len(filterView) > 0 {
-			dirsTableHeight -= h(filterView)
-			rows = append(rows, filterView)
+	// If the name or query filter is active, reserve space for it
+	for _, id := range []filter.ID{filter.QueryFilterID, filter.NameFilterID} {
+		if f, ok := dm.filters[id].(filter.Viewer); ok {
+			if filterView := f.View(); len(filterView) > 0 {
+				dirsTableHeight -= h(filterView)
+				rows = append(rows, filterView)
+			}
 		}
 	}
 
@@ -522,7 +689,7 @@ func (dm *DirModel) View() string {
 		mainView = dm.viewTreemap(dirsTableHeight)
 	} else {
 		dm.dirsTable.SetHeight(dirsTableHeight)
-		dm.lastTableView = dm.dirsTable.View()
+		dm.lastTableView = dm.colorizePercentBars(dm.dirsTable.View())
 		mainView = dm.lastTableView
 	}
 	rows = append(rows, mainView)
@@ -559,6 +726,48 @@ func (dm *DirModel) View() string {
 		return OverlayCenter(dm.width, dm.height, bg, chart)
 	}
 
+	if dm.mode == PRESETS {
+		panel := dm.viewPresets()
+		panelW := lipgloss.Width(panel)
+		panelH := lipgloss.Height(panel)
+		dm.overlayBounds = overlayBounds{
+			kind: "presets",
+			x:    dm.width/2 - panelW/2,
+			y:    dm.height/2 - panelH/2,
+			w:    panelW,
+			h:    panelH,
+		}
+		return OverlayCenter(dm.width, dm.height, bg, panel)
+	}
+
+	if dm.mode == EXCLUDED {
+		panel := dm.viewExclusions()
+		panelW := lipgloss.Width(panel)
+		panelH := lipgloss.Height(panel)
+		dm.overlayBounds = overlayBounds{
+			kind: "exclusions",
+			x:    dm.width/2 - panelW/2,
+			y:    dm.height/2 - panelH/2,
+			w:    panelW,
+			h:    panelH,
+		}
+		return OverlayCenter(dm.width, dm.height, bg, panel)
+	}
+
+	if dm.showSelection {
+		panel := dm.viewSelection()
+		panelW := lipgloss.Width(panel)
+		panelH := lipgloss.Height(panel)
+		dm.overlayBounds = overlayBounds{
+			kind: "selection",
+			x:    dm.width/2 - panelW/2,
+			y:    dm.height/2 - panelH/2,
+			w:    panelW,
+			h:    panelH,
+		}
+		return OverlayCenter(dm.width, dm.height, bg, panel)
+	}
+
 	if dm.err != nil {
 		errorView := lipgloss.NewStyle().
 			Bold(true).
@@ -581,45 +790,29 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 
 	bk := parseBindingKey(msg)
 
-	// Language select mode
-	if dm.mode == SELECT_LANG {
-		switch bk {
-		case cancel:
-			return nil, false
-		case escape, toggleLangSelect, quit:
-			// Cancel: revert any unconfirmed language selections made this session.
-			dm.selectedLangs = copyLangSelection(dm.selectLangsSnapshot)
-			dm.selectLangsSnapshot = nil
-			dm.mode = READY
-			dm.selectMode = false
-			dm.updateTableData()
-			return nil, true
-		case "up", "k":
-			if dm.selectIndex > 0 {
-				dm.selectIndex--
-			}
-			return nil, true
-		case "down", "j":
-			if dm.selectIndex < len(dm.languages)-1 {
-				dm.selectIndex++
-			}
-			return nil, true
-		case " ":
-			if len(dm.languages) > 0 && dm.selectIndex < len(dm.languages) {
-				lang := dm.languages[dm.selectIndex]
-				dm.selectedLangs[lang] = !dm.selectedLangs[lang]
-			}
-			return nil, true
-		case enter:
-			// Confirm: keep current selections and clear the snapshot.
-			dm.selectLangsSnapshot = nil
-			dm.mode = READY
-			dm.selectMode = false
-			dm.updateTableData()
-			return nil, true
-		default:
-			return nil, true
-		}
+	// Language or owner select mode
+	if dm.inSelectMode() {
+		return dm.handleSelectKeys(bk)
+	}
+	if dm.mode == LANG_SUMMARY {
+		return dm.handleLangSummaryKeys(bk)
+	}
+	if dm.mode == EXCLUDED {
+		return dm.handleExclusionKeys(bk)
+	}
+	if dm.mode == PRESETS {
+		return dm.handlePresetKeys(msg, bk)
+	}
+	if (bk == savePreset || bk == openPresets) && dm.mode == READY {
+		dm.openPresets(bk == savePreset)
+		return nil, true
+	}
+	if dm.mode == QUERY {
+		return dm.handleQueryKeys(msg, bk)
+	}
+	if bk == editQuery && dm.mode == READY {
+		dm.openQuery()
+		return nil, true
 	}
 
 	// Quick search (/ key): activate name filter mode when not already filtering.
@@ -639,8 +832,10 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 
 	// Global search (Ctrl+P): activate fuzzy project-wide search
 	if bk == globalSearch {
-		dm.openGlobalSearch()
-		return nil, true
+		return dm.openGlobalSearch(), true
+	}
+	if bk == contentSearch && dm.mode != SEARCH {
+		return dm.openContentSearch(), true
 	}
 
 	// If in input mode, handle special keys
@@ -674,13 +869,17 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 		case enter:
 			// Handled by ViewModel to perform navigation.
 			return nil, false
+		case contentSearch:
+			return dm.toggleContentSearch(), true
+		case cycleSearchSort:
+			return dm.cycleSearchSort(), true
 		case "up", "k":
 			if dm.searchCursor > 0 {
 				dm.searchCursor--
 			}
 			return nil, true
 		case "down", "j":
-			if dm.searchCursor < len(dm.searchMatches)-1 {
+			if dm.searchCursor < dm.searchResultCount()-1 {
 				dm.searchCursor++
 			}
 			return nil, true
@@ -691,10 +890,10 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 			}
 			return nil, true
 		case "pgdown":
-			if len(dm.searchMatches) > 0 {
+			if dm.searchResultCount() > 0 {
 				dm.searchCursor += 10
-				if dm.searchCursor >= len(dm.searchMatches) {
-					dm.searchCursor = len(dm.searchMatches) - 1
+				if dm.searchCursor >= dm.searchResultCount() {
+					dm.searchCursor = dm.searchResultCount() - 1
 				}
 			}
 			return nil, true
@@ -702,8 +901,14 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 			dm.searchCursor = 0
 			return nil, true
 		case "end", "G":
-			if len(dm.searchMatches) > 0 {
-				dm.searchCursor = len(dm.searchMatches) - 1
+			if dm.searchResultCount() > 0 {
+				dm.searchCursor = dm.searchResultCount() - 1
+			}
+			return nil, true
+		case markSearchResult:
+			if e := dm.selectedSearchEntry(); e != nil {
+				dm.toggleMark(e)
+				dm.searchCursor = min(dm.searchCursor+1, dm.searchResultCount()-1)
 			}
 			return nil, true
 		}
@@ -711,8 +916,7 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 		// Pass other keys to the search input for typing.
 		var searchCmd tea.Cmd
 		dm.searchInput, searchCmd = dm.searchInput.Update(msg)
-		dm.updateSearchQuery()
-		return searchCmd, true
+		return tea.Batch(searchCmd, dm.queueSearchQuery()), true
 	}
 
 	// Treemap-specific navigation and toggling.
@@ -727,14 +931,22 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 		case toggleTree:
 			// Switch from treemap view back to tree table view.
 			dm.treemapMode = false
+			dm.icicleMode = false
 			dm.treeMode = true
 			dm.updateTableData()
 			return nil, true
 		case toggleTreemap:
 			dm.ToggleTreemapMode()
 			return nil, true
+		case toggleIcicle:
+			dm.ToggleIcicleMode()
+			return nil, true
 		case toggleTreemapColor:
-			dm.treemapColorByLang = !dm.treemapColorByLang
+			dm.cycleTreemapColor()
+			dm.updateTableData()
+			return nil, true
+		case cycleHeatMetric:
+			dm.cycleHeatMetric()
 			dm.updateTableData()
 			return nil, true
 		case cycleTreemapSize:
@@ -761,12 +973,67 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 		dm.selectIndex = 0
 		dm.selectLangsSnapshot = copyLangSelection(dm.selectedLangs)
 		return nil, true
+	case toggleOwnerSelect:
+		if !dm.hasOwners() {
+			dm.err = errNoOwners
+			return nil, true
+		}
+		dm.mode = SELECT_OWNER
+		dm.selectMode = true
+		dm.selectIndex = 0
+		dm.selectOwnersSnapshot = copyLangSelection(dm.selectedOwners)
+		return nil, true
+	case cycleChartBreakdown:
+		if !dm.showCart {
+			return nil, false
+		}
+		dm.cycleChartBreakdown()
+		return nil, true
+	case cycleChartMetric:
+		if !dm.showCart {
+			return nil, false
+		}
+		dm.cycleChartMetric()
+		return nil, true
+	case cycleChartStyle:
+		if !dm.showCart {
+			return nil, false
+		}
+		dm.cycleChartStyle()
+		return nil, true
+	case toggleAuthors:
+		return dm.toggleAuthors(), true
 	case toggleLangFilter:
 		// Send message to toggle language filter
 		dm.Update(CycleLangFilter{})
 		return nil, true
 	case toggleChart:
 		dm.showCart = !dm.showCart
+		dm.showSelection = false
+		return nil, true
+	case toggleMark:
+		dm.toggleMark(dm.SelectedEntry())
+		return nil, true
+	case toggleSelection:
+		dm.toggleSelectionPanel()
+		return nil, true
+	case clearMarks:
+		dm.clearMarks()
+		return nil, true
+	case toggleExclusion:
+		dm.toggleExclusion(dm.SelectedEntry())
+		return nil, true
+	case cycleFoldThreshold:
+		dm.cycleFoldThreshold()
+		return nil, true
+	case toggleExclusions:
+		dm.openExclusions()
+		return nil, true
+	case escape:
+		if !dm.showSelection {
+			return nil, false
+		}
+		dm.showSelection = false
 		return nil, true
 	case toggleHelp:
 		dm.fullHelp = !dm.fullHelp
@@ -777,6 +1044,30 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 	case toggleTreemap:
 		dm.ToggleTreemapMode()
 		return nil, true
+	case toggleFlat:
+		dm.ToggleFlatMode()
+		return nil, true
+	case toggleIcicle:
+		dm.ToggleIcicleMode()
+		return nil, true
+	case togglePivot:
+		dm.TogglePivotMode()
+		return nil, true
+	case cyclePercentStyle:
+		dm.cyclePercentStyle()
+		return nil, true
+	case toggleFilterTotals:
+		dm.toggleFilterTotals()
+		return nil, true
+	case toggleLangSummary:
+		dm.openLangSummary()
+		return nil, true
+	case togglePivotPercent:
+		if !dm.pivotMode {
+			return nil, false
+		}
+		dm.togglePivotPercent()
+		return nil, true
 	case cycleSortColumn:
 		dm.cycleSortColumn()
 		dm.updateTableData()
@@ -785,6 +1076,10 @@ func (dm *DirModel) handleKeyBindings(msg tea.KeyMsg) (tea.Cmd, bool) {
 		dm.toggleSortOrder()
 		dm.updateTableData()
 		return nil, true
+	case cycleTestScope:
+		dm.cycleScope()
+		dm.updateTableData()
+		return nil, true
 	}
 
 	return nil, false
@@ -855,6 +1150,13 @@ func (dm *DirModel) activeLang() string {
 	return ""
 }
 
+// hideEmptyEntries reports whether entries without any lines under the current
+// single-language filter, test scope, owner filter, filtered totals or
+// exclusions should be hidden from listings.
+func (dm *DirModel) hideEmptyEntries() bool {
+	return dm.activeLang() != "" || dm.scope != structure.ScopeAll || dm.statsOverlay != nil
+}
+
 // selectedLangs returns the list of languages selected in multi-select mode.
 func (dm *DirModel) selectedLangsList() []string {
 	if dm.selectedLangs == nil {
@@ -869,6 +1171,19 @@ func (dm *DirModel) selectedLangsList() []string {
 	return langs
 }
 
+// filteredLangs returns the languages of e that pass the language filter: the
+// selected languages, the single filtered language or all of e's languages.
+func (dm *DirModel) filteredLangs(e *structure.Entry) []string {
+	switch {
+	case dm.useMultiLangFilter():
+		return dm.selectedLangsList()
+	case dm.activeLang() != "":
+		return []string{dm.activeLang()}
+	default:
+		return e.Languages()
+	}
+}
+
 // statusLangLabel returns the human-readable language filter label shown in
 // the status bar: "All", the single filtered language, or the comma-separated
 // list of selected languages.
@@ -883,20 +1198,51 @@ func (dm *DirModel) statusLangLabel() string {
 }
 
 // comparableStats returns the CodeStats that should be used for both display
-// and sorting under the current language filter. For single-language filter it
-// returns that language's stats; for multi-language filter it aggregates the
-// selected languages; otherwise it returns the entry's total stats.
+// and sorting under the current language filter and test scope. For
+// single-language filter it returns that language's stats; for multi-language
+// filter it aggregates the selected languages; otherwise it returns the entry's
+// total stats.
 func (dm *DirModel) comparableStats(e *structure.Entry) structure.CodeStats {
+	return dm.scopedStats(e, dm.scope)
+}
+
+// scopedStats returns the stats of e under the current language and owner
+// filters, restricted to the given test/production scope.
+func (dm *DirModel) scopedStats(e *structure.Entry, scope structure.Scope) structure.CodeStats {
+	e = dm.statsEntry(e)
 	if !dm.useMultiLangFilter() {
-		return e.GetStats(dm.activeLang())
+		return e.GetScopedStats(dm.activeLang(), scope)
 	}
 	var sum structure.CodeStats
 	for _, lang := range dm.selectedLangsList() {
-		sum.Add(e.GetStats(lang))
+		sum.Add(e.GetScopedStats(lang, scope))
 	}
 	return sum
 }
 
+// testRatio returns the share of an entry's lines, under the current language
+// filter, that belong to test code. It ignores the active scope so the ratio
+// stays meaningful while viewing production-only or test-only stats.
+func (dm *DirModel) testRatio(e *structure.Entry) float64 {
+	total := dm.scopedStats(e, structure.ScopeAll).Total()
+	if total <= 0 {
+		return 0
+	}
+	return float64(dm.scopedStats(e, structure.ScopeTest).Total()) / float64(total)
+}
+
+// cycleScope switches between all code, production-only and test-only stats.
+func (dm *DirModel) cycleScope() {
+	switch dm.scope {
+	case structure.ScopeAll:
+		dm.scope = structure.ScopeProduction
+	case structure.ScopeProduction:
+		dm.scope = structure.ScopeTest
+	default:
+		dm.scope = structure.ScopeAll
+	}
+}
+
 // buildChildComparator returns a comparator for sorting child entries according
 // to the current SortState and language filter.
 func (dm *DirModel) buildChildComparator() func(a, b *structure.Entry) int {
@@ -907,14 +1253,16 @@ func (dm *DirModel) buildChildComparator() func(a, b *structure.Entry) int {
 	useMulti := dm.useMultiLangFilter()
 	activeLang := dm.activeLang()
 	selectedLangs := dm.selectedLangsList()
+	scope := dm.scope
 
 	getComparableStats := func(e *structure.Entry) structure.CodeStats {
+		e = dm.statsEntry(e)
 		if !useMulti {
-			return e.GetStats(activeLang)
+			return e.GetScopedStats(activeLang, scope)
 		}
 		var sum structure.CodeStats
 		for _, lang := range selectedLangs {
-			sum.Add(e.GetStats(lang))
+			sum.Add(e.GetScopedStats(lang, scope))
 		}
 		return sum
 	}
@@ -925,6 +1273,12 @@ func (dm *DirModel) buildChildComparator() func(a, b *structure.Entry) int {
 		}
 		return cmp.Compare(a, b)
 	}
+	cmpFloat := func(a, b float64) int {
+		if desc {
+			return cmp.Compare(b, a)
+		}
+		return cmp.Compare(a, b)
+	}
 	cmpStr := func(a, b string) int {
 		r := cmp.Compare(strings.ToLower(a), strings.ToLower(b))
 		if desc {
@@ -960,7 +1314,60 @@ func (dm *DirModel) buildChildComparator() func(a, b *structure.Entry) int {
 		return func(a, b *structure.Entry) int {
 			return cmpVal(getComparableStats(a).Complexity, getComparableStats(b).Complexity)
 		}
+	case SortByFiles:
+		return func(a, b *structure.Entry) int {
+			return cmpVal(getComparableStats(a).Files, getComparableStats(b).Files)
+		}
+	case SortByMaxComplexity:
+		return func(a, b *structure.Entry) int {
+			return cmpVal(getComparableStats(a).MaxComplexity, getComparableStats(b).MaxComplexity)
+		}
+	case SortByCommentRatio, SortByBlankRatio, SortByComplexityDensity:
+		// Entries without code have no ratio and sort as zero.
+		return func(a, b *structure.Entry) int {
+			ratioA, _ := ratioValue(getComparableStats(a), key)
+			ratioB, _ := ratioValue(getComparableStats(b), key)
+			return cmpFloat(ratioA, ratioB)
+		}
+	case SortByAvgLines:
+		return func(a, b *structure.Entry) int {
+			return cmpVal(avgLines(getComparableStats(a)), avgLines(getComparableStats(b)))
+		}
+	case SortByTestRatio:
+		return func(a, b *structure.Entry) int { return cmpFloat(dm.testRatio(a), dm.testRatio(b)) }
+	case SortByOwners:
+		return func(a, b *structure.Entry) int {
+			return cmpStr(strings.Join(entryOwners(a), ", "), strings.Join(entryOwners(b), ", "))
+		}
+	case SortByAuthors:
+		return func(a, b *structure.Entry) int {
+			return cmpStr(topAuthorsLabel(dm.statsEntry(a)), topAuthorsLabel(dm.statsEntry(b)))
+		}
+	case SortByCommits:
+		return func(a, b *structure.Entry) int {
+			return cmpVal(dm.statsEntry(a).Churn.Commits, dm.statsEntry(b).Churn.Commits)
+		}
+	case SortByChurn:
+		return func(a, b *structure.Entry) int {
+			return cmpVal(dm.statsEntry(a).Churn.Lines(), dm.statsEntry(b).Churn.Lines())
+		}
+	case SortByHotspot:
+		return func(a, b *structure.Entry) int { return cmpVal(dm.hotspot(a), dm.hotspot(b)) }
+	case SortByLastChanged:
+		// Entries without a known time sort as the oldest.
+		return func(a, b *structure.Entry) int {
+			return cmpVal(timeKey(dm.statsEntry(a).Times.LastChanged), timeKey(dm.statsEntry(b).Times.LastChanged))
+		}
+	case SortByAge:
+		return func(a, b *structure.Entry) int {
+			ageA, _ := dm.age(a)
+			ageB, _ := dm.age(b)
+			return cmpVal(int64(ageA), int64(ageB))
+		}
 	default:
+		if isPivotKey(key) {
+			return func(a, b *structure.Entry) int { return cmpFloat(dm.pivotCell(a, key), dm.pivotCell(b, key)) }
+		}
 		return func(a, b *structure.Entry) int { return cmpVal(a.TotalStats.Total(), b.TotalStats.Total()) }
 	}
 }
@@ -978,6 +1385,36 @@ func (dm *DirModel) cycleSortColumn() {
 		SortByPercent,
 		SortByComplexity,
 	}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		order = append(order, SortByComplexityDensity, SortByMaxComplexity)
+	}
+	order = append(order,
+		SortByTestRatio,
+		SortByFiles,
+		SortByAvgLines,
+		SortByCommentRatio,
+		SortByBlankRatio,
+	)
+	if dm.hasOwners() {
+		order = append(order, SortByOwners)
+	}
+	if dm.authorsMode {
+		order = append(order, SortByAuthors)
+	}
+	if dm.churnAvailable() {
+		order = append(order, SortByCommits, SortByChurn, SortByHotspot)
+	}
+	if dm.timesAvailable() {
+		order = append(order, SortByLastChanged, SortByAge)
+	}
+	if dm.pivotMode {
+		order = order[:0]
+		for _, c := range dm.pivotColumns() {
+			if c.SortKey != SortByNone {
+				order = append(order, c.SortKey)
+			}
+		}
+	}
 
 	idx := -1
 	for i, k := range order {
@@ -1000,7 +1437,7 @@ func (dm *DirModel) toggleSortOrder() {
 // ascending for text columns, descending for numeric columns.
 func defaultDescForSortKey(key SortKey) bool {
 	switch key {
-	case SortByName, SortByLanguages:
+	case SortByName, SortByLanguages, SortByOwners, SortByAuthors:
 		return false
 	default:
 		return true
@@ -1011,13 +1448,7 @@ func defaultDescForSortKey(key SortKey) bool {
 // current treemapSizeKey. The function respects the active language filter.
 func (dm *DirModel) treemapSizeFunc() func(*structure.Entry) int64 {
 	return func(e *structure.Entry) int64 {
-		stats := dm.comparableStats(e)
-		switch dm.treemapSizeKey {
-		case SortByComplexity:
-			return stats.Complexity
-		default:
-			return stats.Total()
-		}
+		return metricValue(dm.comparableStats(e), dm.treemapSizeKey)
 	}
 }
 
@@ -1028,7 +1459,7 @@ func (dm *DirModel) cycleTreemapSize() {
 	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
 		order = append(order, SortByComplexity)
 	}
-
+	order = append(order, SortByFiles)
 
 	idx := 0
 	for i, k := range order {
@@ -1046,11 +1477,21 @@ func metricValue(stats structure.CodeStats, key SortKey) int64 {
 	switch key {
 	case SortByComplexity:
 		return stats.Complexity
+	case SortByFiles:
+		return stats.Files
 	default:
 		return stats.Total()
 	}
 }
 
+// avgLines returns the average number of lines per file.
+func avgLines(stats structure.CodeStats) int64 {
+	if stats.Files == 0 {
+		return 0
+	}
+	return stats.Total() / stats.Files
+}
+
 // parentTotalForKey returns the total value of the current directory for the
 // metric associated with the given sort key. It is used as the denominator for
 // the "% of Parent" column.
@@ -1074,6 +1515,12 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 		shouldReset = resetCursor[0]
 	}
 
+	dm.syncStatsOverlay()
+	dm.syncHeatMax()
+	if dm.pivotMode {
+		dm.updatePivot()
+	}
+
 	// Sort child entries using the current column sort state.
 	dm.nav.Entry().SortChildBy(dm.buildChildComparator())
 	parentTotal := dm.parentTotalForKey(dm.sortState.Key)
@@ -1085,10 +1532,31 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 	maxNameWidth := lipgloss.Width(cols[2].Title)
 	tempLangsWidth := 24
 
-	if dm.treeMode {
+	if dm.flatMode {
+		for _, entry := range dm.flatFiles() {
+			stats := dm.comparableStats(entry)
+			name := dm.flatName(entry)
+			if lipgloss.Width(name) > maxNameWidth {
+				maxNameWidth = lipgloss.Width(name)
+			}
+			percent := 0.0
+			if parentTotal > 0 {
+				percent = (float64(metricValue(stats, dm.sortState.Key)) / float64(parentTotal)) * 100
+			}
+			langStr := strings.Join(entry.Languages(), ", ")
+			if dm.useMultiLangFilter() {
+				langStr = strings.Join(dm.selectedLangsList(), ", ")
+			}
+			if lipgloss.Width(langStr) > tempLangsWidth {
+				langStr = fmtName(langStr, tempLangsWidth)
+			}
+			rows = append(rows, dm.buildRow(cols, entry, name, langStr, stats, percent))
+			dm.tableEntries = append(dm.tableEntries, &tableEntry{entry: entry})
+		}
+	} else if dm.treeMode {
 		var addEntry func(entry *structure.Entry, depth int)
 		addEntry = func(entry *structure.Entry, depth int) {
-			if !dm.filters.Valid(entry) {
+			if !dm.listed(entry) {
 				return
 			}
 			if dm.useMultiLangFilter() {
@@ -1135,7 +1603,7 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 				return
 			}
 			stats := dm.comparableStats(entry)
-			if dm.activeLang() != "" && stats.Total() == 0 {
+			if dm.hideEmptyEntries() && stats.Total() == 0 {
 				return
 			}
 
@@ -1174,7 +1642,7 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 			rows = append(rows, dm.buildParentRow(cols))
 		}
 		for _, child := range dm.nav.Entry().Child {
-			if !dm.filters.Valid(child) {
+			if !dm.listed(child) {
 				continue
 			}
 			if dm.useMultiLangFilter() {
@@ -1215,7 +1683,7 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 				continue
 			}
 			stats := dm.comparableStats(child)
-			if dm.activeLang() != "" && stats.Total() == 0 {
+			if dm.hideEmptyEntries() && stats.Total() == 0 {
 				continue
 			}
 
@@ -1235,6 +1703,10 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 			rows = append(rows, dm.buildRow(cols, child, name, langStr, stats, percent))
 			dm.tableEntries = append(dm.tableEntries, &tableEntry{entry: child, depth: 0})
 		}
+		rows, dm.tableEntries = dm.foldSmallRows(cols, rows, dm.tableEntries, parentTotal)
+		if n := len(dm.tableEntries); n > 0 && dm.tableEntries[n-1].folded != nil {
+			maxNameWidth = max(maxNameWidth, lipgloss.Width(dm.tableEntries[n-1].entry.Name()))
+		}
 	}
 
 	// --- Step 2: Calculate and set final column widths ---
@@ -1274,9 +1746,16 @@ func (dm *DirModel) updateTableData(resetCursor ...bool) {
 
 	columns := make([]table.Column, len(cols))
 	for i, c := range cols {
+		if dm.flatMode && c.SortKey == SortByName {
+			// The flat listing shows paths relative to the current directory.
+			c.Title = "Path"
+		}
 		columns[i] = table.Column{Title: c.FmtName(dm.sortState), Width: minWidths[i]}
 	}
 
+	// Drop the old rows first: the table re-renders them on SetColumns and
+	// panics when a row has more cells than there are columns.
+	dm.dirsTable.SetRows(nil)
 	dm.dirsTable.SetColumns(columns)
 	dm.dirsTable.SetRows(rows)
 
@@ -1309,6 +1788,15 @@ func (dm *DirModel) dirsSummary() string {
 	}
 	if dm.treemapMode {
 		modeStr = "Treemap"
+		if dm.icicleMode {
+			modeStr = "Icicle"
+		}
+	}
+	if dm.flatMode {
+		modeStr = "Flat"
+	}
+	if dm.pivotMode {
+		modeStr = "Pivot"
 	}
 
 	codeStr := formatNumber(currentStats.Code)
@@ -1319,6 +1807,9 @@ func (dm *DirModel) dirsSummary() string {
 		case SortByComplexity:
 			metricName = "COMPLEXITY"
 			metricValue = currentStats.Complexity
+		case SortByFiles:
+			metricName = "FILES"
+			metricValue = currentStats.Files
 		}
 	}
 	metricStr := formatNumber(metricValue)
@@ -1352,14 +1843,66 @@ func (dm *DirModel) dirsSummary() string {
 		NewBarItem(dm.statusLangLabel(), "", 0),
 	)
 
+	if dm.scope != structure.ScopeAll {
+		items = append(items,
+			NewBarItem("SCOPE", "#ff006e", 0),
+			NewBarItem(dm.scope.String(), "", 0),
+		)
+	}
+
+	if dm.useOwnerFilter() {
+		items = append(items,
+			NewBarItem("OWNER", "#00b4d8", 0),
+			NewBarItem(dm.statusOwnerLabel(), "", 0),
+		)
+	}
+
+	if dm.authorsMode {
+		items = append(items,
+			NewBarItem("AUTHORS", "#ff85a1", 0),
+			NewBarItem(dm.statusAuthorsLabel(), "", 0),
+		)
+	}
+
+	if q, ok := dm.queryFilter(); ok && q.IsEnabled() && !q.IsEditing() {
+		items = append(items,
+			NewBarItem("QUERY", "#3a86ff", 0),
+			NewBarItem(q.Value(), "", 0),
+		)
+	}
+
+	if dm.fold != (foldThreshold{}) {
+		items = append(items,
+			NewBarItem("FOLD", "#adb5bd", 0),
+			NewBarItem(dm.fold.String(), "", 0),
+		)
+	}
+
+	if n := len(dm.excluded); n > 0 {
+		items = append(items,
+			NewBarItem("EXCLUDED", "#6c757d", 0),
+			NewBarItem(fmt.Sprintf("%d", n), "", 0),
+		)
+	}
+
+	if dm.filterTotals {
+		items = append(items,
+			NewBarItem("TOTALS", "#2a9d8f", 0),
+			NewBarItem(dm.statusFilterTotalsLabel(), "", 0),
+		)
+	}
+
+	if len(dm.marked) > 0 {
+		items = append(items,
+			NewBarItem("SELECTED", "#e76f51", 0),
+			NewBarItem(dm.statusSelectionLabel(), "", 0),
+		)
+	}
+
 	if dm.treemapMode && dm.width >= showSortMinWidth {
-		colorMode := "dir"
-		if dm.treemapColorByLang {
-			colorMode = "lang"
-		}
 		items = append(items,
 			NewBarItem("COLOR", "#8338ec", 0),
-			NewBarItem(colorMode, "", 0),
+			NewBarItem(dm.treemapColorLabel(), "", 0),
 		)
 	}
 
@@ -1376,6 +1919,12 @@ func (dm *DirModel) dirsSummary() string {
 		NewBarItem(metricName, "#ffbe0b", 0),
 		DefaultBarItem(metricStr),
 	)
+	if metricName != "FILES" {
+		items = append(items,
+			NewBarItem("FILES", "#8ac926", 0),
+			DefaultBarItem(formatNumber(currentStats.Files)),
+		)
+	}
 
 	return statusBarStyle.Margin(1, 0, 0, 0).Render(NewStatusBar(items, dm.width))
 }
@@ -1391,32 +1940,9 @@ func (dm *DirModel) filteredChildren() []*structure.Entry {
 	children := dm.nav.Entry().Child
 	result := make([]*structure.Entry, 0, len(children))
 	for _, child := range children {
-		if !dm.filters.Valid(child) {
-			continue
+		if dm.passesFilters(child) {
+			result = append(result, child)
 		}
-		if dm.useMultiLangFilter() {
-			activeLangs := dm.selectedLangsList()
-			has := false
-			for _, lang := range activeLangs {
-				if s := child.GetStats(lang); s.Total() > 0 {
-					has = true
-					break
-				}
-			}
-			if !has {
-				continue
-			}
-			stats := dm.comparableStats(child)
-			if stats.Total() == 0 {
-				continue
-			}
-		} else {
-			stats := dm.comparableStats(child)
-			if dm.activeLang() != "" && stats.Total() == 0 {
-				continue
-			}
-		}
-		result = append(result, child)
 	}
 
 	if len(result) > 1 {
@@ -1427,6 +1953,25 @@ func (dm *DirModel) filteredChildren() []*structure.Entry {
 	return result
 }
 
+// passesFilters reports whether an entry should be listed under the name,
+// language, scope and owner filters.
+func (dm *DirModel) passesFilters(e *structure.Entry) bool {
+	if !dm.listed(e) {
+		return false
+	}
+	if dm.useMultiLangFilter() {
+		has := false
+		for _, lang := range dm.selectedLangsList() {
+			if s := e.GetStats(lang); s.Total() > 0 {
+				has = true
+				break
+			}
+		}
+		return has && dm.comparableStats(e).Total() > 0
+	}
+	return !dm.hideEmptyEntries() || dm.comparableStats(e).Total() > 0
+}
+
 func (dm *DirModel) viewTreemap(availableHeight int) string {
 	children := dm.filteredChildren()
 	if len(children) == 0 {
@@ -1442,14 +1987,19 @@ func (dm *DirModel) viewTreemap(availableHeight int) string {
 
 	getSize := dm.treemapSizeFunc()
 
-	showLegend := dm.treemapColorByLang &&
+	coloring, scale := dm.treemapColoring()
+	showLegend := coloring.mode != treemapColorDir &&
 		dm.width > treemapLegendTotalWidth+minTreemapWidthWithoutLegend
 	canvasW := dm.width
 	if showLegend {
 		canvasW -= treemapLegendTotalWidth
 	}
 
-	view, blocks := Treemap(canvasW, h, children, getSize, dm.treemapSelected, dm.treemapColorByLang)
+	layout := Treemap
+	if dm.icicleMode {
+		layout = Icicle
+	}
+	view, blocks := layout(canvasW, h, children, getSize, dm.treemapSelected, coloring)
 	dm.treemapBlocks = blocks
 
 	// If a global search result was just applied in treemap mode, select the
@@ -1458,7 +2008,7 @@ func (dm *DirModel) viewTreemap(availableHeight int) string {
 		idx := dm.findTreemapBlockIndex(dm.pendingSearchTarget)
 		if idx >= 0 {
 			dm.treemapSelected = idx
-			view, blocks = Treemap(canvasW, h, children, getSize, dm.treemapSelected, dm.treemapColorByLang)
+			view, blocks = layout(canvasW, h, children, getSize, dm.treemapSelected, coloring)
 			dm.treemapBlocks = blocks
 		}
 		dm.pendingSearchTarget = nil
@@ -1466,12 +2016,17 @@ func (dm *DirModel) viewTreemap(availableHeight int) string {
 
 	if len(blocks) > 0 && dm.treemapSelected >= len(blocks) {
 		dm.treemapSelected = len(blocks) - 1
-		view, blocks = Treemap(canvasW, h, children, getSize, dm.treemapSelected, dm.treemapColorByLang)
+		view, blocks = layout(canvasW, h, children, getSize, dm.treemapSelected, coloring)
 		dm.treemapBlocks = blocks
 	}
 
 	if showLegend {
-		legend := buildTreemapLegend(dm.treemapBlocks, h, getSize)
+		var legend string
+		if coloring.mode == treemapColorHeat {
+			legend = buildHeatLegend(heatMetricLabel(dm.treemapHeatKey), scale, h)
+		} else {
+			legend = buildTreemapLegend(dm.treemapBlocks, h, getSize)
+		}
 		view = lipgloss.JoinHorizontal(lipgloss.Top, view, legend)
 	}
 
@@ -1518,31 +2073,174 @@ func (dm *DirModel) moveTreemapSelection(delta int) {
 	dm.treemapSelected = topIdxs[pos]
 }
 
+// chartValues returns the chart values of the current directory for the
+// current breakdown.
+func (dm *DirModel) chartValues() map[string]float64 {
+	return dm.chartValuesFor(dm.nav.entry)
+}
+
+// chartValuesFor returns the chart values of e for the current breakdown and
+// metric. The author breakdown only has line counts.
+func (dm *DirModel) chartValuesFor(e *structure.Entry) map[string]float64 {
+	entry := dm.statsEntry(e)
+	values := make(map[string]float64)
+	switch dm.chartBy {
+	case chartByAuthor:
+		for author, lines := range entry.LinesByAuthor {
+			values[author] = float64(lines)
+		}
+	case chartByOwner:
+		for owner, stats := range entry.StatsByOwner {
+			values[owner] = float64(chartMetricValue(stats, dm.chartMetric))
+		}
+	default:
+		for lang, stats := range entry.StatsByLang {
+			values[lang] = float64(chartMetricValue(stats, dm.chartMetric))
+		}
+	}
+	return values
+}
+
+// chartMetricValue returns the value of a chart metric for stats.
+func chartMetricValue(stats structure.CodeStats, key SortKey) int64 {
+	switch key {
+	case SortByCode:
+		return stats.Code
+	case SortByComments:
+		return stats.Comments
+	case SortByBlanks:
+		return stats.Blanks
+	default:
+		return metricValue(stats, key)
+	}
+}
+
+// chartMetricLabel returns the name and unit of the current chart metric.
+func (dm *DirModel) chartMetricLabel() (name, unit string) {
+	if dm.chartBy == chartByAuthor {
+		return "surviving lines", "lines"
+	}
+	switch dm.chartMetric {
+	case SortByCode:
+		return "code", "lines"
+	case SortByComments:
+		return "comments", "lines"
+	case SortByBlanks:
+		return "blanks", "lines"
+	case SortByComplexity:
+		return "complexity", ""
+	case SortByFiles:
+		return "files", "files"
+	default:
+		return "total lines", "lines"
+	}
+}
+
+// cycleChartBreakdown switches the pie chart between languages, owners and
+// authors, skipping breakdowns without data.
+func (dm *DirModel) cycleChartBreakdown() {
+	available := []chartBreakdown{chartByLang}
+	if dm.hasOwners() {
+		available = append(available, chartByOwner)
+	}
+	if dm.authorsMode {
+		available = append(available, chartByAuthor)
+	}
+	idx := slices.Index(available, dm.chartBy)
+	dm.chartBy = available[(idx+1)%len(available)]
+}
+
+// cycleChartMetric advances the chart metric through total lines, code,
+// comments, blanks, complexity (when the provider reports it) and files.
+func (dm *DirModel) cycleChartMetric() {
+	order := []SortKey{SortByTotal, SortByCode, SortByComments, SortByBlanks}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		order = append(order, SortByComplexity)
+	}
+	order = append(order, SortByFiles)
+	idx := slices.Index(order, dm.chartMetric)
+	dm.chartMetric = order[(idx+1)%len(order)]
+}
+
+// cycleChartStyle switches the chart between a pie, bars and stacked bars per
+// child.
+func (dm *DirModel) cycleChartStyle() {
+	dm.chartStyle = (dm.chartStyle + 1) % (chartStacked + 1)
+}
+
+// viewChart renders the chart overlay for the current breakdown, metric and
+// style.
 func (dm *DirModel) viewChart() string {
-	chartSectors := make([]RawChartSector, 0, len(dm.nav.entry.StatsByLang))
-	var totalCode float64
-	for lang, stats := range dm.nav.entry.StatsByLang {
-		if stats.Total() > 0 {
-			chartSectors = append(chartSectors, RawChartSector{
-				Label: lang,
-				Value: float64(stats.Total()),
-			})
-			totalCode += float64(stats.Total())
-		}
-	}
-
-	// Ensure the chart has a reasonable radius
-	radius := min(dm.width/4, dm.height/4) - 2
-
-	return chartBoxStyle.Render(
-		Chart(
-			dm.width/2,  // Chart area width
-			dm.height/2, // Chart area height
-			radius,
-			totalCode,
-			chartSectors,
-		),
-	)
+	metric, unit := dm.chartMetricLabel()
+	breakdown := "language"
+	switch dm.chartBy {
+	case chartByOwner:
+		breakdown = "owner"
+	case chartByAuthor:
+		breakdown = "author"
+	}
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).
+		Render(fmt.Sprintf("%s by %s", metric, breakdown)) +
+		lipgloss.NewStyle().Faint(true).Render(" · "+dm.chartStyle.String())
+
+	var body string
+	if dm.chartStyle == chartStacked {
+		children := dm.filteredChildren()
+		rows := make([]StackedRow, 0, len(children))
+		for _, child := range children {
+			name := child.Name()
+			if child.IsDir {
+				name += "/"
+			}
+			rows = append(rows, StackedRow{Label: name, Values: dm.chartValuesFor(child)})
+		}
+		total := func(r StackedRow) (sum float64) {
+			for _, v := range r.Values {
+				sum += v
+			}
+			return sum
+		}
+		rows = slices.DeleteFunc(rows, func(r StackedRow) bool { return total(r) == 0 })
+		slices.SortStableFunc(rows, func(a, b StackedRow) int { return cmp.Compare(total(b), total(a)) })
+		// Leave room for the title and the legend below the bars.
+		if limit := max(1, dm.height/2); len(rows) > limit {
+			rows = rows[:limit]
+		}
+		body = StackedBarChart(dm.width/2, rows, unit)
+	} else {
+		values := dm.chartValues()
+		chartSectors := make([]RawChartSector, 0, len(values))
+		var totalCode float64
+		for label, value := range values {
+			if value > 0 {
+				chartSectors = append(chartSectors, RawChartSector{
+					Label: label,
+					Value: value,
+				})
+				totalCode += value
+			}
+		}
+
+		if dm.chartStyle == chartBar {
+			body = BarChart(dm.width/2, totalCode, chartSectors, unit)
+		} else {
+			// Ensure the chart has a reasonable radius
+			radius := min(dm.width/4, dm.height/4) - 2
+			body = Chart(
+				dm.width/2,  // Chart area width
+				dm.height/2, // Chart area height
+				radius,
+				totalCode,
+				chartSectors,
+				unit,
+			)
+		}
+	}
+	if body == "" {
+		body = treemapEmptyStyle.Render(" (nothing to chart)")
+	}
+
+	return chartBoxStyle.Render(lipgloss.JoinVertical(lipgloss.Left, title, "", body))
 }
 
 func (dm *DirModel) updateSize(width, height int) {
@@ -1563,9 +2261,11 @@ func (dm *DirModel) ExitSearchMode() {
 
 // Global search helpers -----------------------------------------------------
 
-func (dm *DirModel) openGlobalSearch() {
+// openGlobalSearch opens the global search overlay. The search index is built
+// the first time, in steps for large trees; see buildSearchIndex.
+func (dm *DirModel) openGlobalSearch() tea.Cmd {
 	if dm.mode == SEARCH {
-		return
+		return nil
 	}
 	// Close other overlays/state before entering global search.
 	dm.showCart = false
@@ -1578,7 +2278,10 @@ func (dm *DirModel) openGlobalSearch() {
 	dm.searchCursor = 0
 	dm.searchOffset = 0
 	dm.searchMatches = nil
-	dm.updateSearchQuery()
+	if dm.searchIndex == nil {
+		return dm.buildSearchIndex()
+	}
+	return dm.queueSearchQuery()
 }
 
 func (dm *DirModel) closeGlobalSearch() {
@@ -1588,20 +2291,28 @@ func (dm *DirModel) closeGlobalSearch() {
 	dm.mode = READY
 	dm.searchInput.Blur()
 	dm.searchMatches = nil
+	dm.cancelSearch()
+	dm.cancelContentSearch()
+	dm.searchContent = false
 	dm.searchCursor = 0
 	dm.searchOffset = 0
 	dm.pendingSearchTarget = nil
 }
 
-func (dm *DirModel) updateSearchQuery() {
+// updateSearchQuery refreshes the results for the current query. Content
+// searches run in the background and are returned as a command.
+func (dm *DirModel) updateSearchQuery() tea.Cmd {
+	if dm.searchContent {
+		return dm.contentSearchCmd()
+	}
+	dm.cancelSearch()
 	if dm.searchIndex == nil {
 		dm.searchMatches = nil
-		return
+		return nil
 	}
 	query := dm.searchInput.Value()
-	dm.searchMatches = dm.searchIndex.Find(query)
-	dm.searchCursor = 0
-	dm.searchOffset = 0
+	dm.setSearchMatches(dm.searchIndex.FindTop(context.Background(), query, dm.searchFindLimit()))
+	return nil
 }
 
 // SelectedSearchMatch returns the currently highlighted search match, if any.
@@ -1615,6 +2326,10 @@ func (dm *DirModel) SelectedSearchMatch() *search.Match {
 // applySearchResult navigates to the selected search result and positions the
 // cursor accordingly. It closes the global search overlay.
 func (dm *DirModel) applySearchResult() {
+	if dm.searchContent {
+		dm.openContentResult()
+		return
+	}
 	match := dm.SelectedSearchMatch()
 	if match == nil {
 		dm.closeGlobalSearch()
@@ -1788,11 +2503,31 @@ func (dm *DirModel) viewSearchOverlay(bg string) string {
 		resultHeight = 1
 	}
 
+	// Content search shows the lines around the selected match below the
+	// results, separated by a blank line.
+	var contextLines []string
+	if dm.searchContent {
+		contextLines = dm.contentContextLines(innerWidth)
+		if len(contextLines) > 0 {
+			resultHeight = max(1, resultHeight-len(contextLines)-1)
+		}
+	}
+
+	count := dm.searchResultCount()
 	var resultLines []string
-	if len(dm.searchMatches) == 0 {
-		if dm.searchInput.Value() == "" {
+	if count == 0 {
+		switch {
+		case dm.searchIndexer != nil:
+			resultLines = append(resultLines, lipgloss.NewStyle().Faint(true).Render(
+				fmt.Sprintf("Indexing… %s entries", formatNumber(int64(dm.searchIndexer.Indexed())))))
+		case dm.searchPending || dm.searchContent && dm.contentSearching:
+			resultLines = append(resultLines, lipgloss.NewStyle().Faint(true).Render("Searching…"))
+		case dm.searchContent && utf8.RuneCountInString(search.ParseQuery(dm.searchInput.Value()).Text) < contentSearchMinQuery:
+			resultLines = append(resultLines, lipgloss.NewStyle().Faint(true).Render(
+				fmt.Sprintf("Type at least %d characters to search file contents", contentSearchMinQuery)))
+		case dm.searchInput.Value() == "":
 			resultLines = append(resultLines, lipgloss.NewStyle().Faint(true).Render("Type to search files and directories"))
-		} else {
+		default:
 			resultLines = append(resultLines, lipgloss.NewStyle().Faint(true).Render("No matches"))
 		}
 	} else {
@@ -1808,13 +2543,17 @@ func (dm *DirModel) viewSearchOverlay(bg string) string {
 		}
 
 		end := dm.searchOffset + resultHeight
-		if end > len(dm.searchMatches) {
-			end = len(dm.searchMatches)
+		if end > count {
+			end = count
 		}
 
 		for i := dm.searchOffset; i < end; i++ {
-			match := dm.searchMatches[i]
-			line := dm.renderSearchResult(match, i == dm.searchCursor, innerWidth)
+			var line string
+			if dm.searchContent {
+				line = dm.renderContentResult(dm.contentMatches[i], i == dm.searchCursor, innerWidth)
+			} else {
+				line = dm.renderSearchResult(dm.searchMatches[i], i == dm.searchCursor, innerWidth)
+			}
 			resultLines = append(resultLines, line)
 		}
 	}
@@ -1823,11 +2562,32 @@ func (dm *DirModel) viewSearchOverlay(bg string) string {
 	for len(resultLines) < resultHeight {
 		resultLines = append(resultLines, "")
 	}
+	if len(contextLines) > 0 {
+		resultLines = append(append(resultLines, ""), contextLines...)
+	}
 
-	status := fmt.Sprintf("%d/%d", dm.searchCursor+1, len(dm.searchMatches))
-	if len(dm.searchMatches) == 0 {
+	status := fmt.Sprintf("%d/%d", dm.searchCursor+1, count)
+	if count == 0 {
 		status = "0/0"
 	}
+	if dm.searchContent {
+		if count >= contentSearchLimit {
+			status += " (limit reached)"
+		}
+	} else {
+		if count >= searchResultLimit {
+			status += " (top matches)"
+		}
+		status = dm.searchSortLabel() + " · " + status
+	}
+	if dm.searchPending || dm.searchContent && dm.contentSearching {
+		status = "searching… " + status
+	}
+
+	title, desc := "Global Search", "d: f: lang: in: filters • Ctrl+G: contents • Ctrl+T: sort • Enter: jump • Tab: mark • Esc: close"
+	if dm.searchContent {
+		title, desc = "Content Search", "Ctrl+G: file names • Enter: open at line • Tab: mark • Esc: close"
+	}
 
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff"))
 	boxStyle := lipgloss.NewStyle().
@@ -1838,8 +2598,8 @@ func (dm *DirModel) viewSearchOverlay(bg string) string {
 		Height(boxHeight)
 
 	content := []string{
-		titleStyle.Render("Global Search"),
-		lipgloss.NewStyle().Faint(true).Render("Ctrl+P: open • Enter: jump • Esc: close"),
+		titleStyle.Render(title),
+		lipgloss.NewStyle().Faint(true).Render(desc),
 		inputView,
 		lipgloss.JoinVertical(lipgloss.Top, resultLines...),
 		lipgloss.NewStyle().Faint(true).Align(lipgloss.Right).Render(status),
@@ -1882,8 +2642,12 @@ func (dm *DirModel) renderSearchResult(match search.Match, selected bool, maxWid
 
 	icon := EntryIcon(match.Item.Entry)
 	prefix := icon + "  "
+	if dm.isMarked(match.Item.Entry) {
+		prefix = selectionMark + icon + " "
+	}
+	stats := dm.searchResultStats(match)
 	prefixWidth := lipgloss.Width(prefix)
-	available := maxWidth - prefixWidth
+	available := maxWidth - prefixWidth - lipgloss.Width(stats) - 2
 	if available < 5 {
 		available = 5
 	}
@@ -1902,6 +2666,9 @@ func (dm *DirModel) renderSearchResult(match search.Match, selected bool, maxWid
 	}
 
 	line := prefix + renderedPath
+	if gap := maxWidth - lipgloss.Width(line) - lipgloss.Width(stats); gap >= 2 {
+		line += strings.Repeat(" ", gap) + stats
+	}
 
 	if selected {
 		// Pad the line to fill the inner width so the selection background is consistent.
@@ -1966,34 +2733,6 @@ func (dm *DirModel) isInsideChartBox(x, y int) bool {
 	return dm.overlayBounds.kind == "chart" && dm.isInsideOverlay(x, y)
 }
 
-func (dm *DirModel) isInsideLangSelectBox(x, y int) bool {
-	return dm.overlayBounds.kind == "langselect" && dm.isInsideOverlay(x, y)
-}
-
-func (dm *DirModel) langSelectIndexAtY(y int) int {
-	b := dm.overlayBounds
-	if b.kind != "langselect" {
-		return -1
-	}
-	// Content starts one cell below the top border.
-	contentY := y - b.y - 1
-	if contentY < 2 {
-		return -1 // title or description line
-	}
-	listY := contentY - 2
-	if b.langStart > 0 {
-		if listY == 0 {
-			return -1 // "..." scroll indicator
-		}
-		listY--
-	}
-	idx := b.langStart + listY
-	if idx < b.langStart || idx >= b.langEnd || idx >= len(dm.languages) {
-		return -1
-	}
-	return idx
-}
-
 // tableRowAtY maps a terminal Y coordinate to a table row index.
 // It returns -1 when the coordinate is not over a data row.
 func (dm *DirModel) tableRowAtY(y int) int {
@@ -2169,37 +2908,3 @@ func (dm *DirModel) handleHeaderClick(x int) bool {
 	}
 	return false
 }
-
-// handleLangSelectMouse handles mouse events for the language selection overlay.
-func (dm *DirModel) handleLangSelectMouse(msg tea.MouseMsg) (tea.Cmd, bool) {
-	switch msg.Button {
-	case tea.MouseButtonWheelUp:
-		if dm.selectIndex > 0 {
-			dm.selectIndex--
-		}
-		return nil, true
-	case tea.MouseButtonWheelDown:
-		if dm.selectIndex < len(dm.languages)-1 {
-			dm.selectIndex++
-		}
-		return nil, true
-	case tea.MouseButtonLeft:
-		if msg.Action != tea.MouseActionPress {
-			return nil, false
-		}
-		if !dm.isInsideLangSelectBox(msg.X, msg.Y) {
-			dm.mode = READY
-			dm.selectMode = false
-			dm.updateTableData()
-			return nil, true
-		}
-		idx := dm.langSelectIndexAtY(msg.Y)
-		if idx >= 0 && idx < len(dm.languages) {
-			dm.selectIndex = idx
-			lang := dm.languages[idx]
-			dm.selectedLangs[lang] = !dm.selectedLangs[lang]
-		}
-		return nil, true
-	}
-	return nil, false
-}
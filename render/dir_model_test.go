@@ -1,18 +1,25 @@
 package render
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/stretchr/testify/require"
 
 	"github.com/zdyxry/tokui/filter"
 	"github.com/zdyxry/tokui/provider"
 	"github.com/zdyxry/tokui/structure"
+	"github.com/zdyxry/tokui/vcs"
 )
 
 func TestColumnFmtName(t *testing.T) {
@@ -85,7 +92,7 @@ func TestDirModelComparableStats(t *testing.T) {
 
 	t.Run("no filter uses total stats", func(t *testing.T) {
 		got := dm.comparableStats(root.Child[0])
-		want := structure.CodeStats{Code: 20, Comments: 5, Blanks: 5}
+		want := structure.CodeStats{Code: 20, Comments: 5, Blanks: 5, Files: 1}
 		if got != want {
 			t.Errorf("a.go total stats: got %+v, want %+v", got, want)
 		}
@@ -105,7 +112,7 @@ func TestDirModelComparableStats(t *testing.T) {
 		dm.selectedLangs["Go"] = true
 		dm.selectedLangs["Python"] = true
 		got := dm.comparableStats(root)
-		want := structure.CodeStats{Code: 60, Comments: 17, Blanks: 18}
+		want := structure.CodeStats{Code: 60, Comments: 17, Blanks: 18, Files: 3}
 		if got != want {
 			t.Errorf("root aggregated stats: got %+v, want %+v", got, want)
 		}
@@ -184,9 +191,10 @@ func TestDirModelBuildChildComparator(t *testing.T) {
 func TestDirModelCycleSortColumn(t *testing.T) {
 	dm := newTestDirModel()
 	// newTestDirModel initializes sortState to SortByTotal, so the first cycle
-	// Starting from SortByTotal, the cycle is Percent, Complexity,
-	// Name, Languages, Code, Comments, Blanks, then wraps back to Total.
-	order := []SortKey{SortByPercent, SortByComplexity, SortByName, SortByLanguages, SortByCode, SortByComments, SortByBlanks, SortByTotal}
+	// Starting from SortByTotal, the cycle is Percent, Complexity, Test ratio,
+	// Files, Lines/File, Comment ratio, Blank ratio, Name, Languages, Code,
+	// Comments, Blanks, then wraps back to Total.
+	order := []SortKey{SortByPercent, SortByComplexity, SortByTestRatio, SortByFiles, SortByAvgLines, SortByCommentRatio, SortByBlankRatio, SortByName, SortByLanguages, SortByCode, SortByComments, SortByBlanks, SortByTotal}
 
 	for i := 0; i < len(order)*2; i++ {
 		expected := order[i%len(order)]
@@ -317,6 +325,774 @@ func newTestNestedDirModel() *DirModel {
 	return dm
 }
 
+func TestDirModelFlatMode(t *testing.T) {
+	dm := newTestNestedDirModel()
+	dm.width = 120
+	dm.Update(ScanFinished{})
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("F")})
+	require.True(t, dm.flatMode)
+	require.Len(t, dm.tableEntries, 2, "flat mode lists files only")
+	require.Equal(t, "Path", dm.dirsTable.Columns()[2].Title)
+
+	dm.sortState = SortState{Key: SortByTotal, Desc: false}
+	dm.updateTableData()
+	require.Equal(t, "subdir/b.py", dm.dirsTable.Rows()[0][2])
+	require.Equal(t, "a.go", dm.dirsTable.Rows()[1][2])
+
+	// Language filters apply across the whole subtree.
+	dm.langFilterIdx = 0 // Go
+	dm.updateTableData()
+	require.Len(t, dm.tableEntries, 1)
+	require.Equal(t, "a.go", dm.tableEntries[0].entry.Name())
+	dm.langFilterIdx = -1
+	dm.updateTableData()
+
+	// Enter reveals the file in its directory.
+	dm.dirsTable.SetCursor(0)
+	vm := NewViewModel(dm.nav, dm)
+	vm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.False(t, dm.flatMode)
+	require.Equal(t, "root/subdir", dm.nav.Entry().Path)
+	require.Equal(t, "b.py", dm.SelectedEntry().Name())
+}
+
+func TestDirModelFlatModeExclusive(t *testing.T) {
+	dm := newTestNestedDirModel()
+	dm.Update(ScanFinished{})
+
+	dm.ToggleFlatMode()
+	dm.ToggleTreeMode()
+	require.True(t, dm.treeMode)
+	require.False(t, dm.flatMode)
+
+	dm.ToggleFlatMode()
+	require.False(t, dm.treeMode)
+	dm.ToggleTreemapMode()
+	require.False(t, dm.flatMode)
+}
+
+func TestDirModelIcicleMode(t *testing.T) {
+	dm := newTestNestedDirModel()
+	dm.width = 100
+	dm.height = 30
+	dm.Update(ScanFinished{})
+	dm.ToggleTreeMode()
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	require.True(t, dm.treemapMode)
+	require.True(t, dm.icicleMode)
+	require.False(t, dm.treeMode)
+	require.Contains(t, dm.View(), "Icicle")
+
+	// subdir and its file are laid out in two bands.
+	require.Len(t, dm.treemapBlocks, 3)
+	require.Equal(t, "a.go", dm.SelectedEntry().Name())
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	require.Equal(t, "subdir", dm.SelectedEntry().Name())
+
+	// Nested blocks drill down like treemap tiles.
+	dm.treemapSelected = slices.IndexFunc(dm.treemapBlocks, func(b treemapBlock) bool { return b.level == 1 })
+	vm := NewViewModel(dm.nav, dm)
+	vm.treemapDrillDown()
+	require.Equal(t, PREVIEW, dm.mode)
+	dm.ClosePreview()
+
+	// m switches to the treemap layout, I toggles the view off again.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	require.True(t, dm.treemapMode)
+	require.False(t, dm.icicleMode)
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	require.True(t, dm.icicleMode)
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	require.False(t, dm.treemapMode)
+	require.False(t, dm.icicleMode)
+}
+
+func TestDirModelSelection(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	api := structure.NewDirEntry("root/api")
+	goFile := structure.NewFileEntry("root/api/a.go", map[string]structure.CodeStats{"Go": {Code: 100}})
+	api.AddChild(goFile)
+	api.AddChild(structure.NewFileEntry("root/api/b.py", map[string]structure.CodeStats{"Python": {Code: 50}}))
+	root.AddChild(api)
+	web := structure.NewFileEntry("root/web.ts", map[string]structure.CodeStats{"TypeScript": {Code: 200}})
+	root.AddChild(web)
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.Update(ScanFinished{})
+	dm.updateSize(160, 30)
+
+	press := func(k string) { dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+	dm.dirsTable.SetCursor(dm.findChildIndex(api))
+	press("v")
+	require.True(t, dm.isMarked(api))
+	require.True(t, strings.HasPrefix(dm.dirsTable.Rows()[dm.findChildIndex(api)][0], selectionMark))
+
+	// A file inside a selected directory is not counted twice.
+	dm.toggleMark(goFile)
+	dm.toggleMark(web)
+	require.Equal(t, []*structure.Entry{api, web}, dm.markedRoots())
+	stats, byLang := dm.selectionStats()
+	require.Equal(t, int64(350), stats.Code)
+	require.Equal(t, int64(3), stats.Files)
+	require.Equal(t, int64(50), byLang["Python"].Code)
+	require.Contains(t, dm.View(), "SELECTED")
+
+	// The selection follows the language filter.
+	dm.langFilterIdx = slices.Index(dm.languages, "Go")
+	stats, byLang = dm.selectionStats()
+	require.Equal(t, int64(100), stats.Code)
+	require.Len(t, byLang, 1)
+	dm.langFilterIdx = -1
+
+	press("V")
+	require.True(t, dm.showSelection)
+	view := dm.View()
+	require.Contains(t, view, "Selection · 3 items")
+	require.Contains(t, view, "1 inside other selected directories")
+	dm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.False(t, dm.showSelection)
+
+	press("U")
+	require.Empty(t, dm.marked)
+	press("V")
+	require.False(t, dm.showSelection, "the panel needs a selection")
+
+	// Tab marks global search results.
+	dm.openGlobalSearch()
+	dm.searchInput.SetValue("web.ts")
+	dm.updateSearchQuery()
+	dm.Update(tea.KeyMsg{Type: tea.KeyTab})
+	require.True(t, dm.isMarked(web))
+	require.Equal(t, SEARCH, dm.mode)
+}
+
+func TestDirModelQueryFilter(t *testing.T) {
+	dm := newTestDirModel()
+	dm.Update(ScanFinished{})
+	dm.updateSize(160, 30)
+
+	names := func() []string {
+		var out []string
+		for _, te := range dm.tableEntries {
+			out = append(out, te.entry.Name())
+		}
+		return out
+	}
+	all := names()
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	require.Equal(t, QUERY, dm.mode)
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("lang:py")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyTab})
+	q, _ := dm.queryFilter()
+	require.Equal(t, "lang:Python", q.Value())
+	require.Equal(t, []string{"b.py"}, names(), "the table follows the query while typing")
+	require.Contains(t, dm.View(), "lang:Python")
+
+	// Keys typed into the query do not trigger shortcuts.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" /q")})
+	require.Equal(t, QUERY, dm.mode)
+	dm.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	dm.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	dm.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, READY, dm.mode)
+	require.Equal(t, []string{"b.py"}, names(), "the query stays applied")
+	require.Contains(t, dm.dirsSummary(), "QUERY")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, READY, dm.mode)
+	require.False(t, q.IsEnabled())
+	require.Equal(t, all, names())
+}
+
+func TestDirModelFilterTotals(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	svc := structure.NewDirEntry("root/svc")
+	svc.AddChild(structure.NewFileEntry("root/svc/main.go", map[string]structure.CodeStats{"Go": {Code: 300}}))
+	svc.AddChild(structure.NewFileEntry("root/svc/main_test.go", map[string]structure.CodeStats{"Go": {Code: 200}}))
+	gen := structure.NewDirEntry("root/gen")
+	gen.AddChild(structure.NewFileEntry("root/gen/api.pb.go", map[string]structure.CodeStats{"Go": {Code: 500}}))
+	root.AddChild(svc)
+	root.AddChild(gen)
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.Update(ScanFinished{})
+	dm.updateSize(160, 30)
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!name:_test !path:gen/")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, int64(1000), dm.comparableStats(root).Code, "filters only hide rows by default")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	require.True(t, dm.filterTotals)
+	require.Equal(t, int64(300), dm.comparableStats(root).Code)
+	require.Equal(t, int64(300), dm.comparableStats(svc).Code)
+	require.Equal(t, []*structure.Entry{svc}, dm.filteredChildren(), "directories without kept files are hidden")
+	require.Contains(t, dm.dirsSummary(), "TOTALS")
+
+	// Editing the filters recomputes the totals.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, int64(1000), dm.comparableStats(root).Code)
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	require.False(t, dm.filterTotals)
+	require.Nil(t, dm.statsOverlay)
+}
+
+func TestDirModelExclusions(t *testing.T) {
+	newTree := func(dir string) (*structure.Entry, *structure.Entry) {
+		root := structure.NewDirEntry(dir)
+		svc := structure.NewDirEntry(filepath.Join(dir, "svc"))
+		svc.AddChild(structure.NewFileEntry(filepath.Join(dir, "svc", "main.go"), map[string]structure.CodeStats{"Go": {Code: 300}}))
+		fixtures := structure.NewDirEntry(filepath.Join(dir, "svc", "fixtures"))
+		fixtures.AddChild(structure.NewFileEntry(filepath.Join(dir, "svc", "fixtures", "big.json"), map[string]structure.CodeStats{"JSON": {Code: 700}}))
+		svc.AddChild(fixtures)
+		root.AddChild(svc)
+		root.AggregateStats()
+		return root, svc
+	}
+	dir := t.TempDir()
+	root, svc := newTree(dir)
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.Update(ScanFinished{})
+	dm.updateSize(160, 30)
+
+	dm.nav.Down("svc", 0, 0)
+	dm.updateTableData(true)
+	fixtures := svc.GetChild("fixtures")
+	dm.dirsTable.SetCursor(dm.findChildIndex(fixtures))
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	require.True(t, dm.isExcludedPath(dm.excludedPaths(), fixtures.GetChild("big.json").Path))
+	require.Equal(t, int64(300), dm.comparableStats(root).Code, "ancestors are recomputed")
+	require.Equal(t, int64(300), dm.comparableStats(svc).Code)
+	require.NotContains(t, dm.filteredChildren(), fixtures)
+	require.Contains(t, dm.dirsSummary(), "EXCLUDED")
+
+	// Navigating keeps the overlay; only changed inputs recompute it.
+	shadow := dm.statsOverlay[svc]
+	dm.nav.Up()
+	dm.Update(ScanFinished{ResetCursor: true})
+	require.Same(t, shadow, dm.statsOverlay[svc])
+	dm.nav.Down("svc", 0, 0)
+	dm.Update(ScanFinished{ResetCursor: true})
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	require.Equal(t, EXCLUDED, dm.mode)
+	view := dm.View()
+	require.Contains(t, view, "/svc/fixtures/")
+	require.Contains(t, view, "tokei --exclude /svc/fixtures/")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	data, err := os.ReadFile(filepath.Join(dir, ignoreFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "\n/svc/fixtures/\n")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Empty(t, dm.excluded)
+	require.Nil(t, dm.statsOverlay)
+	require.Equal(t, int64(1000), dm.comparableStats(root).Code)
+	dm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, READY, dm.mode)
+
+	// The saved exclusions are applied to the next session.
+	root, _ = newTree(dir)
+	dm = NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.Update(ScanFinished{})
+	require.Len(t, dm.excluded, 1)
+	require.Equal(t, int64(300), dm.comparableStats(root).Code)
+}
+
+func TestDirModelFoldSmallRows(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	for name, code := range map[string]int64{"big.go": 1000, "mid.go": 900, "a.go": 5, "b.go": 3} {
+		root.AddChild(structure.NewFileEntry("root/"+name, map[string]structure.CodeStats{"Go": {Code: code}}))
+	}
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.SetMinLines(10)
+	vm := NewViewModel(dm.nav, dm)
+	dm.Update(ScanFinished{})
+	dm.updateSize(160, 30)
+
+	names := func() []string {
+		var out []string
+		for _, te := range dm.tableEntries {
+			out = append(out, te.entry.Name())
+		}
+		return out
+	}
+	require.Equal(t, []string{"big.go", "mid.go", "(2 smaller entries)"}, names())
+	fold := dm.dirsTable.Rows()[2]
+	require.Equal(t, "…", fold[0])
+	require.Contains(t, fold, "8", "the fold row sums the folded rows")
+	require.Contains(t, dm.dirsSummary(), "< 10 lines")
+
+	dm.dirsTable.SetCursor(2)
+	require.True(t, dm.IsFoldSelected())
+	require.Nil(t, dm.SelectedEntry())
+	vm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, []string{"big.go", "mid.go", "a.go", "b.go"}, names(), "Enter expands the fold")
+	require.Equal(t, root, dm.nav.Entry())
+
+	// z cycles the threshold: 1% and 5% of the parent, then off.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	require.Equal(t, foldThreshold{percent: 1}, dm.fold)
+	require.Equal(t, []string{"big.go", "mid.go", "(2 smaller entries)"}, names(), "a new threshold folds again")
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("z")})
+	require.Equal(t, foldThreshold{}, dm.fold)
+	require.Len(t, names(), 4)
+	require.NotContains(t, dm.dirsSummary(), "FOLD")
+}
+
+func TestDirModelPresets(t *testing.T) {
+	dir := t.TempDir()
+	newModel := func() *DirModel {
+		root := structure.NewDirEntry(dir)
+		root.AddChild(structure.NewFileEntry(filepath.Join(dir, "main.go"), map[string]structure.CodeStats{"Go": {Code: 300}}))
+		root.AddChild(structure.NewFileEntry(filepath.Join(dir, "app.py"), map[string]structure.CodeStats{"Python": {Code: 100}}))
+		root.AggregateStats()
+		dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+		dm.updateSize(160, 30)
+		return dm
+	}
+	dm := newModel()
+	dm.Update(ScanFinished{})
+
+	dm.selectedLangs = map[string]bool{"Go": true}
+	q, _ := dm.queryFilter()
+	q.SetValue("code>10")
+	q.Commit()
+	dm.sortState = SortState{Key: SortByName}
+	dm.ToggleTreemapMode()
+	dm.treemapColorMode = treemapColorLang
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	require.Equal(t, PRESETS, dm.mode)
+	require.True(t, dm.presetSaving)
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("go only")})
+	require.Contains(t, dm.View(), "treemap · Go · :code>10 · name ↑")
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, READY, dm.mode)
+	data, err := os.ReadFile(filepath.Join(dir, presetsFileName))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"name": "go only"`)
+
+	// Applying the preset restores the saved state.
+	dm.selectedLangs = nil
+	q.Reset()
+	dm.sortState = SortState{Key: SortByCode, Desc: true}
+	dm.ToggleTreemapMode()
+	dm.treemapColorMode = treemapColorDir
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	require.Equal(t, PRESETS, dm.mode)
+	require.Contains(t, dm.View(), "go only")
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, READY, dm.mode)
+	require.Equal(t, []string{"Go"}, dm.selectedLangsList())
+	require.Equal(t, "code>10", q.Value())
+	require.True(t, q.IsEnabled())
+	require.Equal(t, SortState{Key: SortByName}, dm.sortState)
+	require.True(t, dm.treemapMode)
+	require.Equal(t, treemapColorLang, dm.treemapColorMode)
+
+	// --preset applies it after the scan of the next session.
+	dm = newModel()
+	dm.SetStartPreset("go only")
+	dm.Update(ScanFinished{})
+	require.True(t, dm.treemapMode)
+	require.Equal(t, []string{"Go"}, dm.selectedLangsList())
+
+	dm = newModel()
+	dm.SetStartPreset("missing")
+	dm.Update(ScanFinished{})
+	require.EqualError(t, dm.err, `unknown preset "missing"`)
+
+	// d deletes the selected preset.
+	dm.err = nil
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	require.Empty(t, dm.presets)
+	data, err = os.ReadFile(filepath.Join(dir, presetsFileName))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "go only")
+}
+
+func TestDirModelContentSearch(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 60; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines[39] = "\treturn needle"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(strings.Join(lines, "\n")), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.py"), []byte("print('hay')\n"), 0o644))
+
+	root := structure.NewDirEntry(dir)
+	root.AddChild(structure.NewFileEntry(filepath.Join(dir, "main.go"), map[string]structure.CodeStats{"Go": {Code: 60}}))
+	root.AddChild(structure.NewFileEntry(filepath.Join(dir, "app.py"), map[string]structure.CodeStats{"Python": {Code: 1}}))
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.updateSize(160, 30)
+	dm.Update(ScanFinished{})
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	require.Equal(t, SEARCH, dm.mode)
+	require.True(t, dm.searchContent)
+	require.Contains(t, dm.View(), "Content Search")
+
+	dm.searchInput.SetValue("needl")
+	stale := dm.updateSearchQuery()
+	require.NotNil(t, stale)
+	dm.searchInput.SetValue("NEEDLE")
+	cmd := dm.updateSearchQuery()
+	require.NotNil(t, cmd)
+	require.True(t, dm.contentSearching)
+
+	// Results of a superseded query are dropped.
+	dm.Update(stale())
+	require.True(t, dm.contentSearching)
+	require.Empty(t, dm.contentMatches)
+
+	dm.searchInput.SetValue("needle")
+	dm.Update(dm.updateSearchQuery()())
+	require.False(t, dm.contentSearching)
+	require.Len(t, dm.contentMatches, 1)
+	require.Equal(t, 40, dm.contentMatches[0].Line)
+	view := dm.View()
+	require.Contains(t, view, "main.go:40")
+	require.Contains(t, view, "line 39")
+
+	// Ctrl+G switches back to file names and keeps the query.
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	require.False(t, dm.searchContent)
+	require.Empty(t, dm.contentMatches)
+	require.Equal(t, "needle", dm.searchInput.Value())
+	dm.Update(dm.toggleContentSearch()())
+
+	dm.applySearchResult()
+	require.Equal(t, PREVIEW, dm.mode)
+	require.False(t, dm.searchContent)
+	require.NotNil(t, dm.filePreview)
+	require.Equal(t, 39-dm.filePreview.viewport.Height/3, dm.filePreview.viewport.YOffset)
+}
+
+func TestDirModelGlobalSearchSortAndFilters(t *testing.T) {
+	dm := newTestDirModel()
+	dm.updateSize(160, 30)
+	dm.Update(ScanFinished{})
+	dm.openGlobalSearch()
+
+	paths := func() []string {
+		var p []string
+		for _, m := range dm.searchMatches {
+			p = append(p, m.Item.Path)
+		}
+		return p
+	}
+
+	dm.searchInput.SetValue("f: lang:go")
+	dm.updateSearchQuery()
+	require.Equal(t, []string{"a.go", "c.go"}, paths())
+
+	dm.searchInput.SetValue("f:")
+	dm.updateSearchQuery()
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	require.Equal(t, SortByCode, dm.searchSort)
+	require.Equal(t, []string{"c.go", "a.go", "b.py"}, paths())
+	view := dm.View()
+	require.Contains(t, view, "30 code")
+	require.Contains(t, view, "by code · 1/3")
+
+	// Without complexity from the provider the sort returns to match order.
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	require.Equal(t, SortByNone, dm.searchSort)
+	require.Equal(t, []string{"a.go", "b.py", "c.go"}, paths())
+}
+
+func TestDirModelGlobalSearchQueue(t *testing.T) {
+	dm := newTestDirModel()
+	dm.Update(ScanFinished{})
+	require.Nil(t, dm.searchIndex, "the index is built when the search opens")
+	dm.openGlobalSearch()
+	require.NotNil(t, dm.searchIndex)
+
+	// Small indexes are searched on every keystroke.
+	dm.searchInput.SetValue("b.py")
+	require.Nil(t, dm.queueSearchQuery())
+	require.False(t, dm.searchPending)
+	require.NotEmpty(t, dm.searchMatches)
+	dm.closeGlobalSearch()
+
+	root := structure.NewDirEntry("root")
+	big := structure.NewDirEntry("root/big")
+	root.AddChild(big)
+	for i := range searchSyncItems {
+		big.AddChild(structure.NewFileEntry(fmt.Sprintf("root/big/file_%d.go", i), map[string]structure.CodeStats{"Go": {Code: 1}}))
+	}
+	root.AggregateStats()
+	dm = NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.updateSize(160, 30)
+	dm.Update(ScanFinished{})
+
+	// Large trees are indexed in steps, between which the UI updates.
+	step := dm.openGlobalSearch()
+	require.Nil(t, dm.searchIndex)
+	require.Contains(t, dm.View(), "Indexing… 20,000 entries")
+	dm.searchInput.SetValue("file_12")
+	require.Nil(t, dm.queueSearchQuery(), "queries typed while indexing wait for the index")
+	for dm.searchIndex == nil {
+		msg, ok := step().(SearchIndexing)
+		require.True(t, ok)
+		_, step = dm.Update(msg)
+	}
+	require.Nil(t, dm.searchIndexer)
+	require.Equal(t, searchSyncItems+2, dm.searchIndex.Items())
+	require.NotNil(t, step, "the query typed meanwhile runs once indexed")
+
+	// Large ones wait for a pause in typing and drop superseded queries.
+	dm.searchInput.SetValue("file_1")
+	first := dm.queueSearchQuery()
+	require.NotNil(t, first)
+	require.True(t, dm.searchPending)
+	dm.searchInput.SetValue("file_123")
+	second := dm.queueSearchQuery()
+	require.Nil(t, dm.runQueuedSearch(first().(SearchDebounced)))
+
+	run := dm.runQueuedSearch(second().(SearchDebounced))
+	require.NotNil(t, run)
+	require.Contains(t, dm.View(), "searching…")
+	dm.Update(run())
+	require.False(t, dm.searchPending)
+	require.NotEmpty(t, dm.searchMatches)
+	require.LessOrEqual(t, len(dm.searchMatches), searchResultLimit)
+	require.Equal(t, "big/file_123.go", dm.searchMatches[0].Item.Path)
+
+	// Sorting by a metric needs every match and searches in the background too.
+	sorted := dm.cycleSearchSort()
+	require.NotNil(t, sorted)
+	require.True(t, dm.searchPending)
+	dm.Update(dm.runQueuedSearch(sorted().(SearchDebounced))())
+	require.False(t, dm.searchPending)
+	require.NotEmpty(t, dm.searchMatches)
+
+	// Results of a search still running when the overlay closes are dropped.
+	dm.searchInput.SetValue("file_9")
+	stale := dm.runQueuedSearch(dm.queueSearchQuery()().(SearchDebounced))
+	dm.closeGlobalSearch()
+	dm.Update(stale())
+	require.Empty(t, dm.searchMatches)
+}
+
+func TestDirModelPercentBars(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	api := structure.NewDirEntry("root/api")
+	api.AddChild(structure.NewFileEntry("root/api/main", map[string]structure.CodeStats{"Go": {Code: 300}, "Python": {Code: 100}}))
+	root.AddChild(api)
+	root.AddChild(structure.NewFileEntry("root/web.ts", map[string]structure.CodeStats{"TypeScript": {Code: 560}}))
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.Update(ScanFinished{})
+	dm.updateSize(120, 24)
+
+	cell := func(name string) string {
+		idx := slices.IndexFunc(dm.visibleColumns(), func(c Column) bool { return c.SortKey == SortByPercent })
+		return dm.dirsTable.Rows()[dm.findChildIndex(root.GetChild(name))][idx]
+	}
+	require.Equal(t, "41.67 %", cell("api"))
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	require.Equal(t, percentBar, dm.percentStyle)
+	require.Equal(t, "██▌     41.7%", cell("api"))
+	require.Equal(t, "███▌    58.3%", cell("web.ts"))
+	require.Contains(t, dm.View(), "41.7%")
+
+	// Stacked bars split each bar by the languages' share of the sort metric.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	require.Equal(t, percentStacked, dm.percentStyle)
+	require.Equal(t, []lipgloss.Color{langColor("Go"), langColor("Go"), langColor("Python")}, dm.barSegmentColors(api, 3))
+	require.Contains(t, dm.View(), "41.7%")
+
+	// Bars are colored after rendering, except on the selected row.
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+	dm.updateTableData()
+	view := dm.colorizePercentBars(dm.dirsTable.View())
+	require.Contains(t, view, lipgloss.NewStyle().Foreground(langColor("Python")).Render("▌"))
+	require.Contains(t, view, "███▌", "the selected web.ts row keeps its plain bar")
+
+	// The language filter limits the segments to the filtered languages.
+	dm.langFilterIdx = slices.Index(dm.languages, "Python")
+	dm.updateTableData()
+	require.Equal(t, []lipgloss.Color{langColor("Python"), langColor("Python")}, dm.barSegmentColors(api, 2))
+	require.Equal(t, "██████ 100.0%", cell("api"), "the bar follows the filtered totals")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	require.Equal(t, percentNumber, dm.percentStyle)
+}
+
+func TestPercentBarGlyphs(t *testing.T) {
+	require.Equal(t, "       ", percentBarGlyphs(0, 7))
+	require.Equal(t, "███▌   ", percentBarGlyphs(50, 7))
+	require.Equal(t, "███████", percentBarGlyphs(100, 7))
+	require.Equal(t, "███████", percentBarGlyphs(120, 7), "overflow is clamped")
+	require.Equal(t, "▏  ", percentBarGlyphs(4, 3))
+}
+
+func TestDirModelPivotMode(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	for _, svc := range []struct {
+		name  string
+		langs map[string]structure.CodeStats
+	}{
+		{"api", map[string]structure.CodeStats{"Go": {Code: 300}, "Python": {Code: 100}}},
+		{"web", map[string]structure.CodeStats{"TypeScript": {Code: 500}, "Shell": {Code: 10}}},
+		{"jobs", map[string]structure.CodeStats{"Python": {Code: 50}}},
+	} {
+		dir := structure.NewDirEntry("root/" + svc.name)
+		dir.AddChild(structure.NewFileEntry("root/"+svc.name+"/main", svc.langs))
+		root.AddChild(dir)
+	}
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.width = 76 // room for three language columns
+	dm.Update(ScanFinished{})
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	require.True(t, dm.pivotMode)
+	require.Equal(t, []string{"TypeScript", "Go", "Python"}, dm.pivotLangs)
+	titles := make([]string, 0)
+	for _, c := range dm.visibleColumns()[2:] {
+		titles = append(titles, c.Title)
+	}
+	require.Equal(t, []string{"Name", "TypeScript", "Go", "Python", "Other", "Code"}, titles)
+	require.Contains(t, dm.View(), "Pivot")
+
+	cell := func(name string, key SortKey) string {
+		idx := slices.IndexFunc(dm.visibleColumns(), func(c Column) bool { return c.SortKey == key })
+		return dm.dirsTable.Rows()[dm.findChildIndex(root.GetChild(name))][idx]
+	}
+	require.Equal(t, "█ 500", cell("web", pivotLangKey("TypeScript")))
+	require.Equal(t, "", cell("web", pivotLangKey("Go")), "languages a row lacks stay blank")
+	require.Equal(t, "▒ 10", cell("web", SortByPivotOther), "Shell has no column of its own")
+
+	// Sorting by a language column answers "who still has Python".
+	dm.sortState = SortState{Key: pivotLangKey("Python"), Desc: true}
+	dm.updateTableData()
+	require.Equal(t, "api", dm.tableEntries[0].entry.Name())
+	require.Equal(t, "jobs", dm.tableEntries[1].entry.Name())
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("%")})
+	require.Equal(t, "jobs", dm.tableEntries[0].entry.Name(), "jobs is all Python")
+	require.Equal(t, "█ 100.0 %", cell("jobs", pivotLangKey("Python")))
+	require.Equal(t, "▒ 25.0 %", cell("api", pivotLangKey("Python")))
+
+	// Leaving the matrix drops the pivot sort key.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("P")})
+	require.False(t, dm.pivotMode)
+	require.Equal(t, SortByCode, dm.sortState.Key)
+	dm.TogglePivotMode()
+	dm.ToggleTreeMode()
+	require.False(t, dm.pivotMode, "tree mode replaces the matrix")
+}
+
+func TestDirModelLangSummary(t *testing.T) {
+	dm := newTestDirModel()
+	dm.width = 120
+	dm.height = 20
+	dm.Update(ScanFinished{})
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	require.Equal(t, LANG_SUMMARY, dm.mode)
+	require.Len(t, dm.langSummary, 2)
+	require.Equal(t, "Go", dm.langSummary[0].lang, "sorted by code")
+	require.Equal(t, int64(2), dm.langSummary[0].stats.Files)
+	require.InDelta(t, 80.0/95*100, dm.langSummary[0].percent, 1e-9)
+	view := dm.View()
+	require.Contains(t, view, "Languages in root")
+	require.Contains(t, view, "Code ▼")
+
+	// Sorting keeps the cursor on the selected language.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	require.Equal(t, "Python", dm.langSummary[dm.langSummaryCursor].lang)
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	require.Equal(t, "Python", dm.langSummary[0].lang)
+	require.Equal(t, 0, dm.langSummaryCursor)
+
+	// Enter applies the language as the active filter.
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, READY, dm.mode)
+	require.Equal(t, "Python", dm.activeLang())
+	require.Len(t, dm.tableEntries, 1)
+
+	// Esc leaves without changing the filter.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")})
+	dm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, READY, dm.mode)
+	require.Equal(t, "Python", dm.activeLang())
+}
+
+func TestDirModelLangSummaryMouse(t *testing.T) {
+	dm := newTestDirModel()
+	dm.width = 120
+	dm.height = 20
+	dm.Update(ScanFinished{})
+	dm.openLangSummary()
+	dm.View()
+
+	// Clicking the "Language" header sorts by name.
+	dm.handleLangSummaryMouse(tea.MouseMsg{X: 2, Y: 1, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	require.Equal(t, SortByName, dm.langSummarySort.Key)
+
+	row := tea.MouseMsg{X: 2, Y: langSummaryHeaderHeight + 1, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress}
+	dm.handleLangSummaryMouse(row)
+	require.Equal(t, 1, dm.langSummaryCursor)
+	dm.handleLangSummaryMouse(row)
+	require.Equal(t, READY, dm.mode)
+	require.Equal(t, "Python", dm.activeLang())
+}
+
+func TestDirModelChartMetricAndStyle(t *testing.T) {
+	dm := newTestNestedDirModel()
+	dm.width = 120
+	dm.height = 40
+	dm.Update(ScanFinished{})
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	require.True(t, dm.showCart)
+
+	// f cycles through the metrics, b through the chart styles.
+	want := []SortKey{SortByCode, SortByComments, SortByBlanks, SortByFiles, SortByTotal}
+	for _, key := range want {
+		dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+		require.Equal(t, key, dm.chartMetric)
+	}
+	dm.chartMetric = SortByComments
+	require.Equal(t, map[string]float64{"Go": 5, "Python": 2}, dm.chartValues())
+	require.Contains(t, dm.viewChart(), "comments by language")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	require.Equal(t, chartBar, dm.chartStyle)
+	require.Contains(t, dm.viewChart(), "5.0 lines (71.4%)")
+
+	// Stacked bars show one row per child with its own language mix.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	require.Equal(t, chartStacked, dm.chartStyle)
+	view := dm.viewChart()
+	require.Contains(t, view, "a.go")
+	require.Contains(t, view, "subdir/")
+	require.Equal(t, map[string]float64{"Python": 2}, dm.chartValuesFor(dm.nav.Entry().GetChild("subdir")))
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	require.Equal(t, chartPie, dm.chartStyle)
+}
+
 func TestDirModelApplySearchResultFromTreeMode(t *testing.T) {
 	dm := newTestNestedDirModel()
 	dm.Update(ScanFinished{})
@@ -949,40 +1725,40 @@ func TestDirModelMouseHelpers(t *testing.T) {
 	}
 
 	dm.overlayBounds.kind = "langselect"
-	if !dm.isInsideLangSelectBox(15, 10) {
+	if !dm.isInsideSelectBox(15, 10) {
 		t.Error("expected inside language select box")
 	}
 }
 
-func TestDirModelLangSelectIndexAtY(t *testing.T) {
+func TestDirModelSelectIndexAtY(t *testing.T) {
 	dm := newTestDirModel()
 	dm.languages = []string{"A", "B", "C", "D", "E"}
 
 	t.Run("no scroll offset", func(t *testing.T) {
-		dm.overlayBounds = overlayBounds{kind: "langselect", x: 0, y: 5, w: 20, h: 10, langStart: 0, langEnd: 3}
-		if got := dm.langSelectIndexAtY(5 + 1); got != -1 { // title line
+		dm.overlayBounds = overlayBounds{kind: "langselect", x: 0, y: 5, w: 20, h: 10, listStart: 0, listEnd: 3}
+		if got := dm.selectIndexAtY(5 + 1); got != -1 { // title line
 			t.Errorf("title line = %d, want -1", got)
 		}
-		if got := dm.langSelectIndexAtY(5 + 3); got != 0 { // first list item
+		if got := dm.selectIndexAtY(5 + 3); got != 0 { // first list item
 			t.Errorf("first item = %d, want 0", got)
 		}
-		if got := dm.langSelectIndexAtY(5 + 4); got != 1 {
+		if got := dm.selectIndexAtY(5 + 4); got != 1 {
 			t.Errorf("second item = %d, want 1", got)
 		}
-		if got := dm.langSelectIndexAtY(5 + 6); got != -1 { // past last visible item
+		if got := dm.selectIndexAtY(5 + 6); got != -1 { // past last visible item
 			t.Errorf("past end = %d, want -1", got)
 		}
 	})
 
 	t.Run("with scroll offset", func(t *testing.T) {
-		dm.overlayBounds = overlayBounds{kind: "langselect", x: 0, y: 5, w: 20, h: 10, langStart: 2, langEnd: 5}
-		if got := dm.langSelectIndexAtY(5 + 2); got != -1 { // "..." line
+		dm.overlayBounds = overlayBounds{kind: "langselect", x: 0, y: 5, w: 20, h: 10, listStart: 2, listEnd: 5}
+		if got := dm.selectIndexAtY(5 + 2); got != -1 { // "..." line
 			t.Errorf("ellipsis line = %d, want -1", got)
 		}
-		if got := dm.langSelectIndexAtY(5 + 4); got != 2 { // first visible item
+		if got := dm.selectIndexAtY(5 + 4); got != 2 { // first visible item
 			t.Errorf("first visible item = %d, want 2", got)
 		}
-		if got := dm.langSelectIndexAtY(5 + 6); got != 4 { // last visible item
+		if got := dm.selectIndexAtY(5 + 6); got != 4 { // last visible item
 			t.Errorf("last visible item = %d, want 4", got)
 		}
 	})
@@ -1039,12 +1815,12 @@ func TestTreemapColorModeToggle(t *testing.T) {
 	dm.height = 30
 	dm.updateTableData()
 
-	if dm.treemapColorByLang {
+	if dm.treemapColorMode != treemapColorDir {
 		t.Fatal("expected default directory color mode")
 	}
 
 	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
-	if !dm.treemapColorByLang {
+	if dm.treemapColorMode != treemapColorLang {
 		t.Fatal("expected language color mode after pressing c")
 	}
 	if !strings.Contains(dm.View(), "Languages") {
@@ -1052,19 +1828,67 @@ func TestTreemapColorModeToggle(t *testing.T) {
 	}
 
 	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
-	if dm.treemapColorByLang {
-		t.Fatal("expected directory color mode after second c")
+	if dm.treemapColorMode != treemapColorHeat {
+		t.Fatal("expected heat color mode after second c")
+	}
+	view := dm.View()
+	if strings.Contains(view, "Languages") {
+		t.Fatal("expected language legend to hide in heat color mode")
+	}
+	if !strings.Contains(view, "tests") || !strings.Contains(view, "n/a") {
+		t.Fatal("expected heat legend to show in heat color mode")
+	}
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	if dm.treemapColorMode != treemapColorDir {
+		t.Fatal("expected directory color mode after third c")
 	}
 	if strings.Contains(dm.View(), "Languages") {
 		t.Fatal("expected legend to hide in directory color mode")
 	}
 }
 
+func TestTreemapHeatMetricCycle(t *testing.T) {
+	dm := newTestDirModel()
+	dm.Update(ScanFinished{})
+	dm.treemapMode = true
+	dm.width = 100
+	dm.height = 30
+	dm.updateTableData()
+
+	metrics := dm.heatMetrics()
+	require.Equal(t, []SortKey{SortByTestRatio, SortByCommentRatio, SortByBlankRatio, SortByFiles}, metrics)
+
+	// C enters heat mode straight from directory colors, then advances the
+	// metric and wraps around.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	require.Equal(t, treemapColorHeat, dm.treemapColorMode)
+	require.Equal(t, SortByTestRatio, dm.treemapHeatKey)
+	for _, want := range append(metrics[1:], metrics[0]) {
+		dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+		require.Equal(t, want, dm.treemapHeatKey)
+	}
+
+	// The selected metric survives leaving heat mode.
+	dm.treemapHeatKey = SortByFiles
+	dm.cycleTreemapColor()
+	require.Equal(t, treemapColorDir, dm.treemapColorMode)
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}})
+	require.Equal(t, SortByFiles, dm.treemapHeatKey)
+
+	scale := dm.heatScale(SortByFiles)
+	require.Equal(t, sequentialPalette, scale.palette)
+	require.Equal(t, "1", scale.hot)
+	require.Equal(t, "0", scale.cold)
+	require.InDelta(t, 1.0, scale.value(dm.nav.Entry().GetChild("a.go")), 1e-9)
+	require.Contains(t, dm.View(), "files")
+}
+
 func TestTreemapLegendAutoShow(t *testing.T) {
 	dm := newTestDirModel()
 	dm.Update(ScanFinished{})
 	dm.treemapMode = true
-	dm.treemapColorByLang = true
+	dm.treemapColorMode = treemapColorLang
 	dm.width = 100
 	dm.height = 30
 	dm.updateTableData()
@@ -1079,7 +1903,7 @@ func TestTreemapViewHeight(t *testing.T) {
 	dm := newTestDirModel()
 	dm.Update(ScanFinished{})
 	dm.treemapMode = true
-	dm.treemapColorByLang = true
+	dm.treemapColorMode = treemapColorLang
 	dm.width = 100
 	dm.height = 30
 	dm.updateTableData()
@@ -1111,7 +1935,8 @@ func TestNewDirModel_DynamicColumns(t *testing.T) {
 	want := []SortKey{
 		SortByNone, SortByNone, SortByName, SortByLanguages,
 		SortByCode, SortByComments, SortByBlanks, SortByTotal,
-		SortByPercent, SortByComplexity,
+		SortByPercent, SortByComplexity, SortByComplexityDensity, SortByMaxComplexity,
+		SortByTestRatio, SortByFiles, SortByAvgLines, SortByCommentRatio, SortByBlankRatio,
 	}
 	if len(got) != len(want) {
 		t.Fatalf("expected columns %v, got %v", want, got)
@@ -1123,15 +1948,120 @@ func TestNewDirModel_DynamicColumns(t *testing.T) {
 	}
 }
 
+func TestDirModelFileCounts(t *testing.T) {
+	dm := newTestNestedDirModel()
+	dm.width = 140
+	dm.Update(ScanFinished{})
+
+	cols := dm.visibleColumns()
+	filesIdx := slices.IndexFunc(cols, func(c Column) bool { return c.SortKey == SortByFiles })
+	avgIdx := slices.IndexFunc(cols, func(c Column) bool { return c.SortKey == SortByAvgLines })
+	require.GreaterOrEqual(t, filesIdx, 0)
+	require.GreaterOrEqual(t, avgIdx, 0)
+
+	dm.sortState = SortState{Key: SortByFiles, Desc: true}
+	dm.updateTableData()
+	row := dm.dirsTable.Rows()[dm.findChildIndex(dm.nav.Entry().GetChild("a.go"))]
+	require.Equal(t, "1", row[filesIdx])
+	require.Equal(t, "30", row[avgIdx])
+	// Sorting by files makes the percent column a share of the files.
+	require.Equal(t, "50.00 %", row[slices.IndexFunc(cols, func(c Column) bool { return c.SortKey == SortByPercent })])
+
+	// The chart and the treemap can count files instead of lines.
+	dm.chartMetric = SortByFiles
+	require.Equal(t, map[string]float64{"Go": 1, "Python": 1}, dm.chartValues())
+	dm.treemapSizeKey = SortByFiles
+	require.Equal(t, int64(1), dm.treemapSizeFunc()(dm.nav.Entry().GetChild("subdir")))
+
+	require.Contains(t, dm.dirsSummary(), "FILES")
+}
+
 func TestVisibleColumns_Wide(t *testing.T) {
 	dm := NewDirModel(NewCodeNavigation(structure.NewTree(structure.NewDirEntry("root"))), sccProviderInfo(), false, false)
-	dm.width = 120
+	dm.width = 160
 	dm.sortState = SortState{Key: SortByTotal, Desc: true}
 
 	cols := dm.visibleColumns()
 	if len(cols) != len(dm.columns) {
-		t.Errorf("expected all %d columns at width 120, got %d", len(dm.columns), len(cols))
+		t.Errorf("expected all %d columns at width 160, got %d", len(dm.columns), len(cols))
+	}
+}
+
+func TestDirModelRatioColumns(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	root.AddChild(structure.NewFileEntry("root/dense.go", map[string]structure.CodeStats{
+		"Go": {Code: 100, Comments: 2, Blanks: 18, Complexity: 30, MaxComplexity: 30},
+	}))
+	root.AddChild(structure.NewFileEntry("root/doc.go", map[string]structure.CodeStats{
+		"Go": {Code: 50, Comments: 25, Blanks: 25, Complexity: 5, MaxComplexity: 5},
+	}))
+	root.AddChild(structure.NewFileEntry("root/empty.go", map[string]structure.CodeStats{"Go": {}}))
+	root.AggregateStats()
+
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), sccProviderInfo(), false, false)
+	dm.width = 160
+	dm.Update(ScanFinished{})
+
+	cols := dm.visibleColumns()
+	cell := func(name string, key SortKey) string {
+		row := dm.dirsTable.Rows()[dm.findChildIndex(root.GetChild(name))]
+		return row[slices.IndexFunc(cols, func(c Column) bool { return c.SortKey == key })]
 	}
+	require.Equal(t, "2.0 %", cell("dense.go", SortByCommentRatio))
+	require.Equal(t, "15.0 %", cell("dense.go", SortByBlankRatio))
+	require.Equal(t, "300.0", cell("dense.go", SortByComplexityDensity))
+	require.Equal(t, "30", cell("dense.go", SortByMaxComplexity))
+	require.Equal(t, "", cell("empty.go", SortByCommentRatio), "no ratio without code")
+
+	dm.sortState = SortState{Key: SortByCommentRatio, Desc: true}
+	dm.updateTableData()
+	require.Equal(t, "doc.go", dm.tableEntries[0].entry.Name())
+
+	// Underdocumented code is hot; entries without code are unknown.
+	scale := dm.heatScale(SortByCommentRatio)
+	require.Equal(t, divergingPalette, scale.palette)
+	require.Equal(t, "0 %", scale.hot)
+	heat := scale.value
+	require.InDelta(t, 0.96, heat(root.GetChild("dense.go")), 1e-9)
+	require.Equal(t, 0.0, heat(root.GetChild("doc.go")))
+	require.Equal(t, -1.0, heat(root.GetChild("empty.go")))
+	require.Contains(t, dm.heatMetrics(), SortByComplexityDensity)
+}
+
+func TestDirModelHeatMaxCached(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	pkg := structure.NewDirEntry("root/pkg")
+	small := structure.NewFileEntry("root/pkg/small.go", map[string]structure.CodeStats{"Go": {Code: 10, MaxComplexity: 5}})
+	pkg.AddChild(small)
+	root.AddChild(pkg)
+	big := structure.NewFileEntry("root/big.go", map[string]structure.CodeStats{"Go": {Code: 10, MaxComplexity: 30}})
+	root.AddChild(big)
+	root.AggregateStats()
+
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), sccProviderInfo(), false, false)
+	dm.width = 160
+	dm.Update(ScanFinished{})
+	dm.treemapColorMode = treemapColorHeat
+	dm.treemapHeatKey = SortByMaxComplexity
+	dm.updateTableData()
+	require.Equal(t, 30.0, dm.heatMax)
+	require.Equal(t, "30", dm.heatScale(SortByMaxComplexity).hot)
+
+	// Rendering uses the cached maximum instead of walking the tree again.
+	big.StatsByLang["Go"] = structure.CodeStats{Code: 10, MaxComplexity: 60}
+	big.TotalStats = big.StatsByLang["Go"]
+	require.Equal(t, "30", dm.heatScale(SortByMaxComplexity).hot)
+
+	// Loaded stats invalidate the cache.
+	dm.applyStatsOverlay()
+	dm.updateTableData()
+	require.Equal(t, "60", dm.heatScale(SortByMaxComplexity).hot)
+
+	// The maximum follows the current directory.
+	dm.nav.Down("pkg", 0, 0)
+	dm.updateTableData()
+	require.Equal(t, 5.0, dm.heatMax)
+	require.Equal(t, "5", dm.heatScale(SortByMaxComplexity).hot)
 }
 
 func TestVisibleColumns_NarrowHidesOptional(t *testing.T) {
@@ -1225,7 +2155,7 @@ func TestCycleTreemapSize_WithSCC(t *testing.T) {
 		true,
 	)
 
-	order := []SortKey{SortByTotal, SortByComplexity}
+	order := []SortKey{SortByTotal, SortByComplexity, SortByFiles}
 	for i := 0; i < len(order)*2; i++ {
 		expected := order[i%len(order)]
 		if dm.treemapSizeKey != expected {
@@ -1243,10 +2173,12 @@ func TestCycleTreemapSize_WithTokei(t *testing.T) {
 		true,
 	)
 
-	for i := 0; i < 3; i++ {
+	// Complexity is skipped without scc.
+	order := []SortKey{SortByFiles, SortByTotal}
+	for i := 0; i < len(order)*2; i++ {
 		dm.cycleTreemapSize()
-		if dm.treemapSizeKey != SortByTotal {
-			t.Errorf("tokei provider should only support Total size, got %q", dm.treemapSizeKey)
+		if dm.treemapSizeKey != order[i%len(order)] {
+			t.Errorf("cycle %d: expected size key %q, got %q", i, order[i%len(order)], dm.treemapSizeKey)
 		}
 	}
 }
@@ -1414,3 +2346,323 @@ func TestDirModelHandleTableMouseRoutesHeaderClicks(t *testing.T) {
 		t.Errorf("expected sort key to change to %q, got %q", SortByName, dm.sortState.Key)
 	}
 }
+
+func TestDirModelTestScope(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	prod := structure.NewFileEntry("root/a.go", map[string]structure.CodeStats{"Go": {Code: 30}})
+	test := structure.NewFileEntry("root/a_test.go", map[string]structure.CodeStats{"Go": {Code: 10}})
+	test.SetTest(true)
+	root.AddChild(prod)
+	root.AddChild(test)
+	root.AggregateStats()
+
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.width = 120
+	dm.height = 30
+	dm.Update(ScanFinished{})
+
+	if got := dm.testRatio(root); got != 0.25 {
+		t.Fatalf("expected root test ratio 0.25, got %v", got)
+	}
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'T'}})
+	require.Equal(t, structure.ScopeProduction, dm.scope)
+	require.Equal(t, int64(30), dm.comparableStats(root).Code)
+	require.Len(t, dm.tableEntries, 1, "test-only file should be hidden in production scope")
+	require.Contains(t, dm.dirsSummary(), "prod")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'T'}})
+	require.Equal(t, structure.ScopeTest, dm.scope)
+	require.Equal(t, int64(10), dm.comparableStats(root).Code)
+	require.Len(t, dm.tableEntries, 1)
+	require.Equal(t, test, dm.tableEntries[0].entry)
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'T'}})
+	require.Equal(t, structure.ScopeAll, dm.scope)
+	require.Len(t, dm.tableEntries, 2)
+}
+
+func TestBuildChildComparator_TestRatio(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	for _, name := range []string{"low", "high"} {
+		dir := structure.NewDirEntry("root/" + name)
+		dir.AddChild(structure.NewFileEntry("root/"+name+"/a.go", map[string]structure.CodeStats{"Go": {Code: 10}}))
+		test := structure.NewFileEntry("root/"+name+"/a_test.go", map[string]structure.CodeStats{"Go": {Code: 1}})
+		if name == "high" {
+			test = structure.NewFileEntry("root/"+name+"/a_test.go", map[string]structure.CodeStats{"Go": {Code: 30}})
+		}
+		test.SetTest(true)
+		dir.AddChild(test)
+		root.AddChild(dir)
+	}
+	root.AggregateStats()
+
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.sortState = SortState{Key: SortByTestRatio, Desc: true}
+	root.SortChildBy(dm.buildChildComparator())
+
+	require.Equal(t, "high", root.Child[0].Name())
+	require.Equal(t, "low", root.Child[1].Name())
+}
+
+type testOwners map[string][]string
+
+func (o testOwners) Owners(relPath string) []string { return o[relPath] }
+
+func TestDirModelOwnerFilter(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	api := structure.NewDirEntry("root/api")
+	api.AddChild(structure.NewFileEntry("root/api/a.go", map[string]structure.CodeStats{"Go": {Code: 10}}))
+	api.AddChild(structure.NewFileEntry("root/api/b.go", map[string]structure.CodeStats{"Go": {Code: 20}}))
+	root.AddChild(api)
+	root.AddChild(structure.NewFileEntry("root/main.py", map[string]structure.CodeStats{"Python": {Code: 5}}))
+	root.AggregateStats()
+	tree := structure.NewTree(root)
+	tree.ApplyOwners(testOwners{"api/a.go": {"@api"}, "api/b.go": {"@core"}})
+
+	dm := NewDirModel(NewCodeNavigation(tree), provider.Info{Name: "test"}, false, false)
+	dm.width = 140
+	dm.height = 30
+	dm.Update(ScanFinished{})
+
+	require.Equal(t, []string{"(unowned)", "@api", "@core"}, dm.owners)
+	require.Contains(t, dm.visibleColumns(), Column{Title: "Owners", SortKey: SortByOwners})
+	require.Equal(t, []string{"@core", "@api"}, entryOwners(api))
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'O'}})
+	require.Equal(t, SELECT_OWNER, dm.mode)
+	dm.Update(tea.KeyMsg{Type: tea.KeyDown})
+	dm.Update(tea.KeyMsg{Type: tea.KeySpace}) // @api
+	dm.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, READY, dm.mode)
+
+	require.Equal(t, int64(10), dm.comparableStats(root).Code)
+	require.Equal(t, int64(10), dm.comparableStats(api).Code)
+	require.Len(t, dm.tableEntries, 1, "unowned main.py should be hidden")
+	require.Contains(t, dm.dirsSummary(), "@api")
+
+	// Escape reverts an unconfirmed change.
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'O'}})
+	dm.Update(tea.KeyMsg{Type: tea.KeySpace}) // (unowned)
+	dm.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, int64(10), dm.comparableStats(root).Code)
+
+	// The chart toggles between languages and owners.
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'o'}})
+	require.Equal(t, chartByOwner, dm.chartBy)
+	require.Contains(t, dm.viewChart(), "@api")
+}
+
+func TestDirModelOwnerSelectWithoutOwners(t *testing.T) {
+	dm := newTestDirModel()
+	dm.Update(ScanFinished{})
+
+	require.NotContains(t, dm.visibleColumns(), Column{Title: "Owners", SortKey: SortByOwners})
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'O'}})
+	require.Equal(t, READY, dm.mode)
+	require.ErrorIs(t, dm.err, errNoOwners)
+}
+
+func TestDirModelAuthorsMode(t *testing.T) {
+	dm := newTestDirModel()
+	dm.width = 140
+	dm.height = 30
+	dm.Update(ScanFinished{})
+	root := dm.nav.Entry()
+
+	cmd := dm.toggleAuthors()
+	require.True(t, dm.authorsMode)
+	require.NotNil(t, cmd, "expected blame to be scheduled")
+	require.True(t, dm.authorsLoading)
+	require.Nil(t, dm.authorsCmd(), "blame must not be scheduled twice")
+	require.Contains(t, dm.visibleColumns(), Column{Title: "Top Authors", SortKey: SortByAuthors})
+	require.Contains(t, dm.dirsSummary(), "loading")
+
+	dm.Update(AuthorsLoaded{Root: root, Lines: map[string]map[string]int64{
+		"root/a.go": {"alice": 20, "bob": 10},
+		"root/c.go": {"bob": 50},
+	}})
+	require.False(t, dm.authorsLoading)
+	require.True(t, dm.blamedDirs[root])
+	require.Nil(t, dm.authorsCmd())
+	require.Equal(t, "bob 75%, alice 25%", topAuthorsLabel(root))
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	dm.cycleChartBreakdown()
+	require.Equal(t, chartByAuthor, dm.chartBy)
+	require.Contains(t, dm.viewChart(), "alice")
+
+	dm.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'A'}})
+	require.False(t, dm.authorsMode)
+	require.Equal(t, chartByLang, dm.chartBy)
+	require.NotContains(t, dm.columns, Column{Title: "Top Authors", SortKey: SortByAuthors})
+}
+
+func TestDirModelAuthorsSubdirectory(t *testing.T) {
+	root := structure.NewDirEntry("root")
+	pkg := structure.NewDirEntry("root/pkg")
+	pkg.AddChild(structure.NewFileEntry("root/pkg/a.go", map[string]structure.CodeStats{"Go": {Code: 20}}))
+	root.AddChild(pkg)
+	root.AddChild(structure.NewFileEntry("root/main.go", map[string]structure.CodeStats{"Go": {Code: 5}}))
+	root.AggregateStats()
+	dm := NewDirModel(NewCodeNavigation(structure.NewTree(root)), provider.Info{Name: "test"}, false, false)
+	dm.Update(ScanFinished{})
+
+	dm.nav.Down("pkg", 0, 0)
+	require.NotNil(t, dm.toggleAuthors())
+	require.Nil(t, dm.authorsCmd(), "pkg is blamed once")
+
+	// Leaving pkg cancels its blame and blames the directory the user is in.
+	dm.nav.Up()
+	require.NotNil(t, dm.authorsCmd())
+	require.True(t, dm.authorsLoading)
+	require.Equal(t, root, dm.authorsRoot)
+	require.NotNil(t, dm.authorsCancel)
+	require.Nil(t, dm.applyAuthors(AuthorsLoaded{Root: pkg, Err: context.Canceled}))
+	require.True(t, dm.authorsLoading, "the canceled blame does not end the running one")
+
+	// A superseded blame that finished anyway is merged without ending the
+	// running one.
+	require.Nil(t, dm.applyAuthors(AuthorsLoaded{Root: pkg, Lines: map[string]map[string]int64{
+		"root/pkg/a.go": {"alice": 20},
+	}}))
+	require.True(t, dm.authorsLoading)
+	require.Equal(t, root, dm.authorsRoot)
+	require.Equal(t, int64(20), root.LinesByAuthor["alice"], "ancestors are re-aggregated")
+
+	dm.toggleAuthors()
+	require.False(t, dm.authorsLoading)
+	require.Nil(t, dm.authorsCancel)
+	dm.Update(AuthorsLoaded{Root: root, Lines: map[string]map[string]int64{
+		"root/main.go": {"bob": 5},
+	}})
+	require.False(t, dm.blamedDirs[root], "results after turning authors off are dropped")
+	require.Nil(t, root.GetChild("main.go").LinesByAuthor)
+}
+
+func TestDirModelAuthorsLoadError(t *testing.T) {
+	dm := newTestDirModel()
+	dm.Update(ScanFinished{})
+
+	dm.toggleAuthors()
+	dm.Update(AuthorsLoaded{Root: dm.nav.Entry(), Err: errors.New("not a git repository")})
+	require.False(t, dm.authorsMode)
+	require.False(t, dm.authorsLoading)
+	require.ErrorContains(t, dm.err, "not a git repository")
+}
+
+func TestDirModelChurn(t *testing.T) {
+	dm := newTestDirModel()
+	dm.width = 160
+	dm.height = 30
+	dm.Update(ScanFinished{})
+	require.Nil(t, dm.Init(), "churn is disabled without a window")
+	require.NotContains(t, dm.heatMetrics(), SortByHotspot)
+
+	// History is only read once a churn metric is selected.
+	dm.SetChurnWindow("6.months")
+	require.Nil(t, dm.churnCmd())
+	require.Contains(t, dm.heatMetrics(), SortByHotspot, "churn metrics are offered before loading")
+	dm.sortState = SortState{Key: SortByCommits, Desc: true}
+	require.NotNil(t, dm.churnCmd())
+	require.Nil(t, dm.churnCmd(), "churn must not be loaded twice")
+
+	dm.Update(ChurnLoaded{Churn: map[string]structure.Churn{
+		"a.go": {Commits: 1, Added: 30},
+		"b.py": {Commits: 5, Added: 10, Removed: 5},
+	}})
+	require.True(t, dm.hasChurn)
+	require.False(t, dm.churnLoading)
+	for _, key := range []SortKey{SortByCommits, SortByChurn, SortByHotspot} {
+		require.True(t, slices.ContainsFunc(dm.visibleColumns(), func(c Column) bool { return c.SortKey == key }), key)
+	}
+
+	// Hotspot weights commits by lines of code: b.py 5*10 beats a.go 1*20.
+	dm.sortState = SortState{Key: SortByHotspot, Desc: true}
+	dm.updateTableData()
+	require.Equal(t, "b.py", dm.tableEntries[0].entry.Name())
+	require.Equal(t, int64(50), dm.hotspot(dm.tableEntries[0].entry))
+
+	heat := dm.heatScale(SortByHotspot).value
+	root := dm.nav.Entry()
+	require.InDelta(t, 1.0, heat(root.GetChild("b.py")), 1e-9)
+	require.Equal(t, 0.0, heat(root.GetChild("c.go")))
+
+	// The treemap color cycle offers the hotspot metric once churn is loaded.
+	metrics := dm.heatMetrics()
+	require.Equal(t, SortByHotspot, metrics[len(metrics)-1])
+	dm.cycleHeatMetric()
+	require.Equal(t, treemapColorHeat, dm.treemapColorMode)
+	require.Equal(t, SortByTestRatio, dm.treemapHeatKey)
+	for range len(metrics) - 1 {
+		dm.cycleHeatMetric()
+	}
+	require.Equal(t, SortByHotspot, dm.treemapHeatKey)
+	require.Equal(t, "hotspot", dm.treemapColorLabel())
+	dm.cycleHeatMetric()
+	require.Equal(t, SortByTestRatio, dm.treemapHeatKey, "heat metrics wrap around")
+}
+
+func TestDirModelTimes(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2024, 1, n, 0, 0, 0, 0, time.UTC) }
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return day(31) }
+
+	dm := newTestDirModel()
+	dm.width = 160
+	dm.height = 30
+	dm.Update(ScanFinished{})
+
+	// Times are only loaded once a time metric is selected.
+	dm.EnableFileTimes()
+	require.Nil(t, dm.timesCmd())
+	dm.sortState = SortState{Key: SortByAge, Desc: true}
+	require.NotNil(t, dm.timesCmd())
+	require.Nil(t, dm.timesCmd(), "times must not be loaded twice")
+
+	dm.Update(TimesLoaded{Times: map[string]structure.Times{
+		"root/a.go": {Created: day(1), LastChanged: day(21)},
+		"root/b.py": {Created: day(11), LastChanged: day(30)},
+	}})
+	require.True(t, dm.hasTimes)
+	require.False(t, dm.timesLoading)
+	for _, key := range []SortKey{SortByLastChanged, SortByAge} {
+		require.True(t, slices.ContainsFunc(dm.visibleColumns(), func(c Column) bool { return c.SortKey == key }), key)
+	}
+
+	dm.sortState = SortState{Key: SortByAge, Desc: true}
+	dm.updateTableData()
+	require.Equal(t, "a.go", dm.tableEntries[0].entry.Name())
+	require.Equal(t, "30d", dm.dirsTable.Rows()[0][slices.IndexFunc(dm.visibleColumns(), func(c Column) bool { return c.SortKey == SortByAge })])
+
+	dm.sortState = SortState{Key: SortByLastChanged, Desc: true}
+	dm.updateTableData()
+	require.Equal(t, "b.py", dm.tableEntries[0].entry.Name())
+	require.Equal(t, "c.go", dm.tableEntries[2].entry.Name(), "unknown times sort as oldest")
+
+	// Stale files are hot; files without history are unknown.
+	heat := dm.heatScale(SortByLastChanged).value
+	root := dm.nav.Entry()
+	require.InDelta(t, 1.0, heat(root.GetChild("a.go")), 1e-9)
+	require.Less(t, heat(root.GetChild("b.py")), 0.5)
+	require.Equal(t, -1.0, heat(root.GetChild("c.go")))
+	require.Contains(t, dm.heatMetrics(), SortByAge)
+}
+
+func TestDirModelChurnNotRepository(t *testing.T) {
+	dm := newTestDirModel()
+	dm.Update(ScanFinished{})
+	dm.SetChurnWindow("6.months")
+	dm.sortState = SortState{Key: SortByHotspot, Desc: true}
+	dm.treemapColorMode, dm.treemapHeatKey = treemapColorHeat, SortByChurn
+	require.NotNil(t, dm.churnCmd())
+
+	dm.Update(ChurnLoaded{Err: fmt.Errorf("x: %w", vcs.ErrNotRepository)})
+	require.False(t, dm.hasChurn)
+	require.ErrorIs(t, dm.err, vcs.ErrNotRepository)
+	require.False(t, dm.churnAvailable(), "churn metrics are withdrawn")
+	require.Equal(t, SortByTotal, dm.sortState.Key)
+	require.Equal(t, SortByTestRatio, dm.treemapHeatKey)
+	require.Nil(t, dm.churnCmd())
+}
@@ -0,0 +1,248 @@
+package render
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// ignoreFileName is the file below the analysis root that exclusions are
+// loaded from and saved to. It holds one root-relative path per line, in the
+// anchored gitignore form also accepted by tokei's --exclude.
+const ignoreFileName = ".tokuiignore"
+
+// toggleExclusion excludes e from the analysis, or restores it. Excluded
+// entries disappear and every total above them is recomputed without them.
+func (dm *DirModel) toggleExclusion(e *structure.Entry) {
+	if e == nil || e == dm.nav.tree.Root() || dm.IsParentSelected() {
+		return
+	}
+	if dm.excluded == nil {
+		dm.excluded = make(map[*structure.Entry]bool)
+	}
+	if dm.excluded[e] {
+		delete(dm.excluded, e)
+	} else {
+		dm.excluded[e] = true
+	}
+	dm.applyStatsOverlay()
+	dm.updateTableData()
+}
+
+// excludedPaths returns the paths of the excluded entries.
+func (dm *DirModel) excludedPaths() map[string]bool {
+	paths := make(map[string]bool, len(dm.excluded))
+	for e := range dm.excluded {
+		paths[e.Path] = true
+	}
+	return paths
+}
+
+// isExcludedPath reports whether path or a directory above it, up to the
+// analysis root, is one of the excluded paths.
+func (dm *DirModel) isExcludedPath(excluded map[string]bool, path string) bool {
+	if len(excluded) == 0 {
+		return false
+	}
+	root := dm.nav.tree.Root().Path
+	for p := path; p != root; {
+		if excluded[p] {
+			return true
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	return false
+}
+
+// exclusionList returns the excluded entries sorted by path.
+func (dm *DirModel) exclusionList() []*structure.Entry {
+	list := make([]*structure.Entry, 0, len(dm.excluded))
+	for e := range dm.excluded {
+		list = append(list, e)
+	}
+	slices.SortFunc(list, func(a, b *structure.Entry) int { return cmp.Compare(a.Path, b.Path) })
+	return list
+}
+
+// exclusionPattern returns the anchored pattern of e, e.g. "/gen/" for a
+// directory or "/scripts/bench.py" for a file.
+func (dm *DirModel) exclusionPattern(e *structure.Entry) string {
+	rel := e.Path
+	if r, err := filepath.Rel(dm.nav.tree.Root().Path, e.Path); err == nil {
+		rel = r
+	}
+	p := "/" + filepath.ToSlash(rel)
+	if e.IsDir {
+		p += "/"
+	}
+	return p
+}
+
+// exclusionPatterns returns the patterns of all exclusions, including those
+// loaded from the ignore file that match nothing in the tree.
+func (dm *DirModel) exclusionPatterns() []string {
+	patterns := slices.Clone(dm.unresolvedExclusions)
+	for _, e := range dm.exclusionList() {
+		patterns = append(patterns, dm.exclusionPattern(e))
+	}
+	slices.Sort(patterns)
+	return patterns
+}
+
+// ignoreFilePath returns the path of the ignore file of the analysis root.
+func (dm *DirModel) ignoreFilePath() string {
+	return filepath.Join(dm.nav.tree.Root().Path, ignoreFileName)
+}
+
+// loadExclusions reads the ignore file once, after the first scan. A missing
+// file is not an error.
+func (dm *DirModel) loadExclusions() error {
+	if dm.exclusionsLoaded {
+		return nil
+	}
+	dm.exclusionsLoaded = true
+
+	f, err := os.Open(dm.ignoreFilePath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if e := dm.lookupRelPath(strings.Trim(line, "/")); e != nil && e != dm.nav.tree.Root() {
+			if dm.excluded == nil {
+				dm.excluded = make(map[*structure.Entry]bool)
+			}
+			dm.excluded[e] = true
+		} else {
+			dm.unresolvedExclusions = append(dm.unresolvedExclusions, line)
+		}
+	}
+	return scanner.Err()
+}
+
+// lookupRelPath finds the entry at a slash-separated path below the root.
+func (dm *DirModel) lookupRelPath(rel string) *structure.Entry {
+	e := dm.nav.tree.Root()
+	for _, part := range strings.Split(rel, "/") {
+		if part == "" {
+			continue
+		}
+		if e = e.GetChild(part); e == nil {
+			return nil
+		}
+	}
+	return e
+}
+
+// saveExclusions writes the exclusions to the ignore file.
+func (dm *DirModel) saveExclusions() error {
+	var sb strings.Builder
+	sb.WriteString("# Paths excluded by tokui, relative to this directory.\n")
+	for _, p := range dm.exclusionPatterns() {
+		sb.WriteString(p + "\n")
+	}
+	return os.WriteFile(dm.ignoreFilePath(), []byte(sb.String()), 0o644)
+}
+
+// tokeiExcludeArgs renders the exclusions as tokei arguments.
+func (dm *DirModel) tokeiExcludeArgs() string {
+	args := make([]string, 0, len(dm.excluded)+len(dm.unresolvedExclusions))
+	for _, p := range dm.exclusionPatterns() {
+		args = append(args, "--exclude "+p)
+	}
+	return strings.Join(args, " ")
+}
+
+// openExclusions shows the exclusions overlay.
+func (dm *DirModel) openExclusions() {
+	dm.mode = EXCLUDED
+	dm.exclusionIndex = 0
+	dm.exclusionNote = ""
+}
+
+// handleExclusionKeys handles key presses while the exclusions overlay is
+// open: restoring exclusions and saving them.
+func (dm *DirModel) handleExclusionKeys(bk bindingKey) (tea.Cmd, bool) {
+	list := dm.exclusionList()
+	switch bk {
+	case cancel:
+		return nil, false
+	case escape, toggleExclusions, quit:
+		dm.mode = READY
+	case "up", "k":
+		dm.exclusionIndex = max(0, dm.exclusionIndex-1)
+	case "down", "j":
+		dm.exclusionIndex = max(0, min(len(list)-1, dm.exclusionIndex+1))
+	case enter, " ", toggleExclusion:
+		if dm.exclusionIndex < len(list) {
+			delete(dm.excluded, list[dm.exclusionIndex])
+			dm.exclusionIndex = max(0, min(len(list)-2, dm.exclusionIndex))
+			dm.applyStatsOverlay()
+			dm.updateTableData()
+		}
+	case saveExclusions:
+		if err := dm.saveExclusions(); err != nil {
+			dm.exclusionNote = fmt.Sprintf("Error: %v", err)
+		} else {
+			dm.exclusionNote = "Saved to " + dm.ignoreFilePath()
+		}
+	}
+	return nil, true
+}
+
+// viewExclusions renders the exclusions overlay.
+func (dm *DirModel) viewExclusions() string {
+	list := dm.exclusionList()
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).
+		Render(fmt.Sprintf("Excluded · %d", len(list)))
+	desc := lipgloss.NewStyle().Faint(true).Render("Enter/x: restore, w: save " + ignoreFileName + ", Esc: close")
+	lines := []string{title, desc, ""}
+
+	width := dm.width / 2
+	if len(list) == 0 {
+		lines = append(lines, treemapEmptyStyle.Render(" (nothing excluded; press x on a row)"))
+	}
+	for i, e := range list {
+		cursor := "  "
+		name := dm.exclusionPattern(e)
+		if i == dm.exclusionIndex {
+			cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("#3a86ff")).Render("→ ")
+			name = lipgloss.NewStyle().Bold(true).Render(name)
+		}
+		lines = append(lines, cursor+fmtName(name, width)+"  "+
+			lipgloss.NewStyle().Faint(true).Render(formatNumber(e.TotalStats.Total())+" lines"))
+	}
+	for _, p := range dm.unresolvedExclusions {
+		lines = append(lines, "  "+lipgloss.NewStyle().Faint(true).Render(p+" (not found)"))
+	}
+	if args := dm.tokeiExcludeArgs(); args != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Faint(true).Render("tokei "+args))
+	}
+	if dm.exclusionNote != "" {
+		lines = append(lines, "", dm.exclusionNote)
+	}
+	return chartBoxStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
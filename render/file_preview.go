@@ -186,6 +186,12 @@ func (fp *FilePreview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return fp, cmd
 }
 
+// ScrollToLine scrolls so that the 1-based line n sits in the upper third of
+// the preview.
+func (fp *FilePreview) ScrollToLine(n int) {
+	fp.viewport.SetYOffset(max(0, n-1-fp.viewport.Height/3))
+}
+
 // View renders the file preview
 func (fp *FilePreview) View() string {
 	if !fp.ready {
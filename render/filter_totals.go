@@ -0,0 +1,63 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/zdyxry/tokui/filter"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// toggleFilterTotals switches between filters that only hide rows and filters
+// that apply to whole subtrees. With filtered totals, the name and query
+// filters are evaluated on files, and directory totals, percentages and the
+// status bar count only the files that pass them.
+func (dm *DirModel) toggleFilterTotals() {
+	dm.filterTotals = !dm.filterTotals
+	dm.applyStatsOverlay()
+	dm.updateTableData(true)
+}
+
+// filtersActive reports whether the name or query filter restricts entries.
+func (dm *DirModel) filtersActive() bool {
+	if f, ok := dm.filters[filter.NameFilterID].(*filter.NameFilter); ok && f.IsEnabled() && f.Value() != "" {
+		return true
+	}
+	if q, ok := dm.queryFilter(); ok && q.IsEnabled() && q.Value() != "" {
+		return true
+	}
+	return false
+}
+
+// filtersSignature identifies the state of the name and query filters as far
+// as they affect the filtered totals.
+func (dm *DirModel) filtersSignature() string {
+	if !dm.filterTotals {
+		return ""
+	}
+	var name, query string
+	if f, ok := dm.filters[filter.NameFilterID].(*filter.NameFilter); ok && f.IsEnabled() {
+		name = f.Value()
+	}
+	if q, ok := dm.queryFilter(); ok && q.IsEnabled() {
+		query = q.Value()
+	}
+	return fmt.Sprintf("%q %q", name, query)
+}
+
+// listed reports whether e passes the name and query filters of the listing.
+// With filtered totals, directories are listed by what they contain rather
+// than by their own name, and hidden once no file in them passes.
+func (dm *DirModel) listed(e *structure.Entry) bool {
+	if dm.filterTotals && e.IsDir {
+		return true
+	}
+	return dm.filters.Valid(e)
+}
+
+// statusFilterTotalsLabel describes the filtered totals for the status bar.
+func (dm *DirModel) statusFilterTotalsLabel() string {
+	if !dm.filtersActive() {
+		return "no filter"
+	}
+	return "filtered"
+}
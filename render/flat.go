@@ -0,0 +1,84 @@
+package render
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+// ToggleFlatMode switches between the regular listing and the flat listing of
+// every file below the current directory.
+func (dm *DirModel) ToggleFlatMode() {
+	dm.flatMode = !dm.flatMode
+	if dm.flatMode {
+		// Flat, tree, treemap and pivot mode are mutually exclusive at the
+		// view level.
+		dm.treeMode = false
+		dm.treemapMode = false
+		dm.pivotMode = false
+		dm.icicleMode = false
+	}
+	dm.dirsTable.SetCursor(0)
+	dm.updateTableData()
+}
+
+// flatFiles returns every file below the current directory that passes the
+// active filters, sorted globally by the current sort column.
+func (dm *DirModel) flatFiles() []*structure.Entry {
+	entry := dm.nav.Entry()
+	if entry == nil || !entry.IsDir {
+		return nil
+	}
+	var files []*structure.Entry
+	var walk func(e *structure.Entry)
+	walk = func(e *structure.Entry) {
+		for _, child := range e.Child {
+			if child.IsDir {
+				walk(child)
+				continue
+			}
+			if dm.passesFilters(child) {
+				files = append(files, child)
+			}
+		}
+	}
+	walk(entry)
+	slices.SortStableFunc(files, dm.buildChildComparator())
+	return files
+}
+
+// flatName returns the path of a file relative to the current directory, as
+// shown in the flat listing.
+func (dm *DirModel) flatName(e *structure.Entry) string {
+	rel, err := filepath.Rel(dm.nav.Entry().Path, e.Path)
+	if err != nil {
+		return e.Name()
+	}
+	return filepath.ToSlash(rel)
+}
+
+// revealSelected leaves flat mode and navigates to the directory containing
+// the selected file, with the cursor on the file.
+func (dm *DirModel) revealSelected() {
+	target := dm.SelectedEntry()
+	root := dm.nav.tree.Root()
+	if target == nil || root == nil {
+		return
+	}
+	rel, err := filepath.Rel(root.Path, target.Path)
+	if err != nil {
+		return
+	}
+	found := dm.nav.NavigateToPath(filepath.ToSlash(rel))
+	if found == nil {
+		return
+	}
+
+	dm.flatMode = false
+	dm.updateTableData(true)
+	if idx := dm.findChildIndex(found); idx >= 0 {
+		dm.nav.cursor = idx
+		dm.dirsTable.SetCursor(idx)
+	}
+}
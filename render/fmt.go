@@ -1,7 +1,9 @@
 package render
 
 import (
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
@@ -59,3 +61,19 @@ func fmtName(name string, maxWidth int) string {
 	truncatedText := lipgloss.NewStyle().MaxWidth(targetTextVisualWidth).Render(name)
 	return truncatedText + ellipsis
 }
+
+// formatAge renders a duration compactly with a unit suited to its size, such
+// as "5h", "12d", "7mo" or "2.5y".
+func formatAge(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d < day:
+		return fmt.Sprintf("%dh", int64(d/time.Hour))
+	case d < 60*day:
+		return fmt.Sprintf("%dd", int64(d/day))
+	case d < 2*365*day:
+		return fmt.Sprintf("%dmo", int64(d/(30*day)))
+	default:
+		return fmt.Sprintf("%.1fy", float64(d)/float64(365*day))
+	}
+}
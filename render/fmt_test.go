@@ -3,6 +3,7 @@ package render
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -32,6 +33,25 @@ func TestFormatNumber(t *testing.T) {
 	}
 }
 
+func TestFormatAge(t *testing.T) {
+	const day = 24 * time.Hour
+	cases := []struct {
+		in   time.Duration
+		want string
+	}{
+		{5 * time.Hour, "5h"},
+		{12 * day, "12d"},
+		{210 * day, "7mo"},
+		{912 * day, "2.5y"},
+	}
+
+	for _, c := range cases {
+		if got := formatAge(c.in); got != c.want {
+			t.Errorf("formatAge(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
 func TestTruncateVisual(t *testing.T) {
 	cases := []struct {
 		s       string
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// foldThreshold is the size below which rows are folded into a single
+// "(N smaller entries)" row. A zero value disables folding.
+type foldThreshold struct {
+	lines   int64   // fewer total lines than this
+	percent float64 // smaller share of the parent than this, in percent
+}
+
+func (t foldThreshold) String() string {
+	switch {
+	case t.lines > 0:
+		return fmt.Sprintf("< %s lines", formatNumber(t.lines))
+	case t.percent > 0:
+		return fmt.Sprintf("< %g%%", t.percent)
+	default:
+		return "off"
+	}
+}
+
+// foldPercentPresets are the share-of-parent thresholds cycled with z.
+var foldPercentPresets = []float64{1, 5}
+
+// foldMinRows is the fewest rows worth folding; a single small row is shown
+// as is.
+const foldMinRows = 2
+
+// SetMinLines sets the line threshold below which rows are folded, and
+// starts with it active. Zero disables it.
+func (dm *DirModel) SetMinLines(n int64) {
+	dm.minLines = max(0, n)
+	dm.fold = foldThreshold{lines: dm.minLines}
+}
+
+// foldPresets returns the thresholds cycled with z, starting with off.
+func (dm *DirModel) foldPresets() []foldThreshold {
+	presets := []foldThreshold{{}}
+	if dm.minLines > 0 {
+		presets = append(presets, foldThreshold{lines: dm.minLines})
+	}
+	for _, p := range foldPercentPresets {
+		presets = append(presets, foldThreshold{percent: p})
+	}
+	return presets
+}
+
+// cycleFoldThreshold switches to the next fold threshold. Directories
+// expanded under the previous threshold are folded again.
+func (dm *DirModel) cycleFoldThreshold() {
+	presets := dm.foldPresets()
+	i := slices.Index(presets, dm.fold)
+	dm.fold = presets[(i+1)%len(presets)]
+	dm.unfolded = nil
+	dm.updateTableData(true)
+}
+
+// isSmall reports whether stats fall below the fold threshold.
+func (dm *DirModel) isSmall(stats structure.CodeStats, parentTotal int64) bool {
+	if dm.fold.lines > 0 {
+		return stats.Total() < dm.fold.lines
+	}
+	if dm.fold.percent > 0 && parentTotal > 0 {
+		return float64(metricValue(stats, dm.sortState.Key))/float64(parentTotal)*100 < dm.fold.percent
+	}
+	return false
+}
+
+// foldSmallRows replaces the rows below the fold threshold with a single row
+// summing them, appended after the remaining rows. Directories whose fold has
+// been expanded are listed in full.
+func (dm *DirModel) foldSmallRows(cols []Column, rows []table.Row, entries []*tableEntry, parentTotal int64) ([]table.Row, []*tableEntry) {
+	dir := dm.nav.Entry()
+	if dm.fold == (foldThreshold{}) || dm.unfolded[dir] {
+		return rows, entries
+	}
+
+	var small []*structure.Entry
+	keepRows := make([]table.Row, 0, len(rows))
+	keepEntries := make([]*tableEntry, 0, len(entries))
+	for i, te := range entries {
+		if !te.isParent && dm.isSmall(dm.comparableStats(te.entry), parentTotal) {
+			small = append(small, te.entry)
+			continue
+		}
+		keepRows = append(keepRows, rows[i])
+		keepEntries = append(keepEntries, te)
+	}
+	if len(small) < foldMinRows {
+		return rows, entries
+	}
+
+	folded := &structure.Entry{
+		Path:        filepath.Join(dir.Path, fmt.Sprintf("(%d smaller entries)", len(small))),
+		StatsByLang: make(map[string]structure.CodeStats),
+	}
+	for _, e := range small {
+		folded.TotalStats.Add(dm.comparableStats(e))
+		s := dm.statsEntry(e)
+		for _, lang := range dm.filteredLangs(s) {
+			ls := folded.StatsByLang[lang]
+			ls.Add(s.GetScopedStats(lang, dm.scope))
+			folded.StatsByLang[lang] = ls
+		}
+	}
+
+	percent := 0.0
+	if parentTotal > 0 {
+		percent = float64(metricValue(folded.TotalStats, dm.sortState.Key)) / float64(parentTotal) * 100
+	}
+	langStr := strings.Join(folded.Languages(), ", ")
+	row := dm.buildRow(cols, folded, folded.Name(), langStr, folded.TotalStats, percent)
+	row[0] = "…"
+	row[1] = dir.Path
+
+	keepRows = append(keepRows, row)
+	keepEntries = append(keepEntries, &tableEntry{entry: folded, folded: small})
+	return keepRows, keepEntries
+}
+
+// IsFoldSelected reports whether the "(N smaller entries)" row is selected.
+func (dm *DirModel) IsFoldSelected() bool {
+	if dm.treemapMode {
+		return false
+	}
+	cursor := dm.dirsTable.Cursor()
+	return cursor >= 0 && cursor < len(dm.tableEntries) && dm.tableEntries[cursor].folded != nil
+}
+
+// expandFold lists the folded rows of the current directory in full until
+// the threshold changes.
+func (dm *DirModel) expandFold() {
+	if dm.unfolded == nil {
+		dm.unfolded = make(map[*structure.Entry]bool)
+	}
+	dm.unfolded[dm.nav.Entry()] = true
+	dm.updateTableData()
+}
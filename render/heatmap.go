@@ -0,0 +1,441 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zdyxry/tokui/provider"
+	"github.com/zdyxry/tokui/structure"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// divergingPalette runs from blue through yellow to red. It suits metrics
+// where both ends of the scale are notable, such as test or comment ratios.
+var divergingPalette = []lipgloss.Color{
+	"#2c7bb6",
+	"#abd9e9",
+	"#ffffbf",
+	"#fdae61",
+	"#d7191c",
+}
+
+// sequentialPalette runs from pale yellow to dark red. It suits magnitudes
+// where only the high end is notable, such as complexity or churn.
+var sequentialPalette = []lipgloss.Color{
+	"#ffffcc",
+	"#fed976",
+	"#fd8d3c",
+	"#e31a1c",
+	"#800026",
+}
+
+// heatUnknownColor is used for tiles whose metric is not available.
+var heatUnknownColor = lipgloss.Color("#7F8C8D")
+
+// heatColor maps v in [0, 1] onto palette by linear interpolation between
+// neighboring palette stops. Index 0 of the palette is the coolest (0.0), the
+// last entry the hottest (1.0). Negative values mean "no data".
+func heatColor(palette []lipgloss.Color, v float64) lipgloss.Color {
+	if v < 0 {
+		return heatUnknownColor
+	}
+	if v > 1 {
+		v = 1
+	}
+	pos := v * float64(len(palette)-1)
+	lo := int(pos)
+	if lo >= len(palette)-1 {
+		return palette[len(palette)-1]
+	}
+	return blendColors(palette[lo], palette[lo+1], pos-float64(lo))
+}
+
+// blendColors linearly interpolates between two hex colors. t=0 returns a and
+// t=1 returns b. Colors that are not in #rrggbb form are returned unchanged.
+func blendColors(a, b lipgloss.Color, t float64) lipgloss.Color {
+	ar, ag, ab, ok1 := parseHexColor(a)
+	br, bg, bb, ok2 := parseHexColor(b)
+	if !ok1 || !ok2 {
+		return a
+	}
+	mix := func(x, y int64) int64 {
+		return x + int64(float64(y-x)*t)
+	}
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", mix(ar, br), mix(ag, bg), mix(ab, bb)))
+}
+
+func parseHexColor(c lipgloss.Color) (r, g, b int64, ok bool) {
+	s := string(c)
+	if len(s) != 7 || s[0] != '#' {
+		return 0, 0, 0, false
+	}
+	var err1, err2, err3 error
+	r, err1 = strconv.ParseInt(s[1:3], 16, 64)
+	g, err2 = strconv.ParseInt(s[3:5], 16, 64)
+	b, err3 = strconv.ParseInt(s[5:7], 16, 64)
+	return r, g, b, err1 == nil && err2 == nil && err3 == nil
+}
+
+// heatScale places entries on the [0, 1] color scale of a heat metric.
+type heatScale struct {
+	value   func(*structure.Entry) float64 // negative when the metric is unknown
+	palette []lipgloss.Color
+	// hot and cold label the ends of the scale in the legend.
+	hot, cold string
+}
+
+// heatMetricLabel returns the status bar label for a heat metric.
+func heatMetricLabel(key SortKey) string {
+	switch key {
+	case SortByTestRatio:
+		return "tests"
+	case SortByHotspot:
+		return "hotspot"
+	case SortByLastChanged:
+		return "staleness"
+	default:
+		return string(key)
+	}
+}
+
+// heatScale returns the scale for the given metric. Higher values are
+// rendered hotter.
+func (dm *DirModel) heatScale(key SortKey) heatScale {
+	value := dm.heatFileValue(key)
+	switch key {
+	case SortByTestRatio:
+		// Untested code is the hot spot: a test ratio of 0 maps to the top of
+		// the scale.
+		return heatScale{
+			value: func(e *structure.Entry) float64 {
+				if dm.scopedStats(e, structure.ScopeAll).Total() == 0 {
+					return -1
+				}
+				return 1 - dm.testRatio(e)
+			},
+			palette: divergingPalette,
+			hot:     "0 %",
+			cold:    "100 %",
+		}
+	case SortByCommentRatio:
+		// Underdocumented code is the hot spot.
+		maxValue := dm.heatFileMax(key)
+		return heatScale{
+			value:   invertHeat(linearHeat(value, maxValue)),
+			palette: divergingPalette,
+			hot:     "0 %",
+			cold:    fmt.Sprintf("%.0f %%", maxValue*100),
+		}
+	case SortByBlankRatio:
+		maxValue := dm.heatFileMax(key)
+		return heatScale{
+			value:   linearHeat(value, maxValue),
+			palette: sequentialPalette,
+			hot:     fmt.Sprintf("%.0f %%", maxValue*100),
+			cold:    "0 %",
+		}
+	case SortByComplexityDensity:
+		maxValue := dm.heatFileMax(key)
+		return heatScale{
+			value:   linearHeat(value, maxValue),
+			palette: sequentialPalette,
+			hot:     fmt.Sprintf("%.0f/KLOC", maxValue),
+			cold:    "0",
+		}
+	case SortByMaxComplexity:
+		return dm.logScale(value, dm.heatFileMax(key), func(v float64) string { return fmt.Sprintf("%.0f", v) })
+	case SortByFiles:
+		// File counts only add up in directories, so the scale is relative to
+		// the largest tile rather than the largest file.
+		value := func(e *structure.Entry) (float64, bool) {
+			return float64(dm.comparableStats(e).Files), true
+		}
+		return dm.logScale(value, dm.maxChildValue(value), func(v float64) string { return formatNumber(int64(v)) })
+	case SortByChurn:
+		return dm.logScale(value, dm.heatFileMax(key), func(v float64) string { return fmt.Sprintf("%.1f×", v) })
+	case SortByHotspot:
+		return dm.logScale(value, dm.heatFileMax(key), func(v float64) string { return fmt.Sprintf("%.1f", v) })
+	case SortByLastChanged, SortByAge:
+		return dm.logScale(value, dm.heatFileMax(key), func(v float64) string {
+			return formatAge(time.Duration(v * float64(24*time.Hour)))
+		})
+	default:
+		return heatScale{palette: sequentialPalette}
+	}
+}
+
+// heatFileValue returns the per-file value of the heat metrics whose scale is
+// relative to the largest file below the current directory, and nil for the
+// others.
+func (dm *DirModel) heatFileValue(key SortKey) func(*structure.Entry) (float64, bool) {
+	switch key {
+	case SortByCommentRatio, SortByBlankRatio, SortByComplexityDensity:
+		return dm.ratioValueFunc(key)
+	case SortByMaxComplexity:
+		return func(e *structure.Entry) (float64, bool) {
+			stats := dm.comparableStats(e)
+			return float64(stats.MaxComplexity), stats.Code > 0
+		}
+	case SortByChurn:
+		return func(e *structure.Entry) (float64, bool) {
+			s := dm.statsEntry(e)
+			if s.TotalStats.Code == 0 {
+				return 0, false
+			}
+			return float64(s.Churn.Lines()) / float64(s.TotalStats.Code), true
+		}
+	case SortByHotspot:
+		return dm.hotspotHeatValue
+	case SortByLastChanged:
+		return durationHeat(dm.sinceChange)
+	case SortByAge:
+		return durationHeat(dm.age)
+	default:
+		return nil
+	}
+}
+
+// heatMaxSignature identifies everything the maximum of a heat metric is
+// computed from: the current directory, the metric, the test scope, the
+// language selection and the stats overlay inputs.
+func (dm *DirModel) heatMaxSignature(key SortKey) string {
+	return fmt.Sprintf("%p\x00%s\x00%d\x00%s\x00%s\x00\x00%s",
+		dm.nav.Entry(), key, dm.scope, dm.activeLang(),
+		strings.Join(dm.selectedLangsList(), "\x00"), dm.overlaySignature())
+}
+
+// syncHeatMax recomputes the maximum of the treemap heat metric when its
+// inputs changed since it was last computed, so that rendering the heat map
+// does not walk the tree. Stats loaded into the tree invalidate it through
+// applyStatsOverlay.
+func (dm *DirModel) syncHeatMax() {
+	if dm.treemapColorMode != treemapColorHeat {
+		return
+	}
+	value := dm.heatFileValue(dm.treemapHeatKey)
+	if value == nil {
+		return
+	}
+	if sig := dm.heatMaxSignature(dm.treemapHeatKey); sig != dm.heatMaxInputs {
+		dm.heatMax = dm.maxFileValue(value)
+		dm.heatMaxInputs = sig
+	}
+}
+
+// heatFileMax returns the largest value of a heat metric among the files below
+// the current directory: the one cached by syncHeatMax when it is current,
+// otherwise a fresh walk.
+func (dm *DirModel) heatFileMax(key SortKey) float64 {
+	if dm.heatMaxInputs != "" && dm.heatMaxInputs == dm.heatMaxSignature(key) {
+		return dm.heatMax
+	}
+	return dm.maxFileValue(dm.heatFileValue(key))
+}
+
+// logScale returns a sequential heat scale placing entries on a log scale up
+// to maxValue, so a single outlier does not wash out everything else. Entries
+// without a value are shown as unknown.
+func (dm *DirModel) logScale(value func(*structure.Entry) (float64, bool), maxValue float64, format func(float64) string) heatScale {
+	scale := math.Log1p(maxValue)
+	return heatScale{
+		value: func(e *structure.Entry) float64 {
+			v, ok := value(e)
+			if !ok {
+				return -1
+			}
+			if scale == 0 {
+				return 0
+			}
+			return min(1, math.Log1p(max(0, v))/scale)
+		},
+		palette: sequentialPalette,
+		hot:     format(maxValue),
+		cold:    format(0),
+	}
+}
+
+// linearHeat places entries linearly between zero and maxValue.
+func linearHeat(value func(*structure.Entry) (float64, bool), maxValue float64) func(*structure.Entry) float64 {
+	return func(e *structure.Entry) float64 {
+		v, ok := value(e)
+		if !ok {
+			return -1
+		}
+		if maxValue <= 0 {
+			return 0
+		}
+		return min(1, v/maxValue)
+	}
+}
+
+// invertHeat flips a heat function so low values are hot.
+func invertHeat(heat func(*structure.Entry) float64) func(*structure.Entry) float64 {
+	return func(e *structure.Entry) float64 {
+		v := heat(e)
+		if v < 0 {
+			return v
+		}
+		return 1 - v
+	}
+}
+
+// maxFileValue returns the largest value among the files below the current
+// directory. Heat scales are relative to it so they adapt while navigating.
+func (dm *DirModel) maxFileValue(value func(*structure.Entry) (float64, bool)) float64 {
+	var maxValue float64
+	var walk func(e *structure.Entry)
+	walk = func(e *structure.Entry) {
+		if !e.IsDir {
+			if v, ok := value(e); ok {
+				maxValue = max(maxValue, v)
+			}
+			return
+		}
+		for _, child := range e.Child {
+			walk(child)
+		}
+	}
+	if entry := dm.nav.Entry(); entry != nil {
+		walk(entry)
+	}
+	return maxValue
+}
+
+// maxChildValue returns the largest value among the current directory's
+// children.
+func (dm *DirModel) maxChildValue(value func(*structure.Entry) (float64, bool)) float64 {
+	var maxValue float64
+	for _, child := range dm.filteredChildren() {
+		if v, ok := value(child); ok {
+			maxValue = max(maxValue, v)
+		}
+	}
+	return maxValue
+}
+
+// treemapColoring returns the coloring configuration for the treemap view
+// and, in heat mode, the scale it is based on.
+func (dm *DirModel) treemapColoring() (treemapColoring, heatScale) {
+	c := treemapColoring{mode: dm.treemapColorMode}
+	var scale heatScale
+	if c.mode == treemapColorHeat {
+		scale = dm.heatScale(dm.treemapHeatKey)
+		c.heat = scale.value
+		c.palette = scale.palette
+	}
+	return c, scale
+}
+
+// heatMetrics returns the metrics the treemap can be colored by, in cycling
+// order. Metrics backed by optional data are only offered once it is loaded.
+func (dm *DirModel) heatMetrics() []SortKey {
+	metrics := []SortKey{SortByTestRatio, SortByCommentRatio, SortByBlankRatio}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		metrics = append(metrics, SortByComplexityDensity, SortByMaxComplexity)
+	}
+	metrics = append(metrics, SortByFiles)
+	if dm.churnAvailable() {
+		metrics = append(metrics, SortByChurn, SortByHotspot)
+	}
+	if dm.timesAvailable() {
+		metrics = append(metrics, SortByLastChanged, SortByAge)
+	}
+	return metrics
+}
+
+// cycleTreemapColor advances the treemap coloring through directory colors,
+// language colors and the selected heat metric.
+func (dm *DirModel) cycleTreemapColor() {
+	switch dm.treemapColorMode {
+	case treemapColorDir:
+		dm.treemapColorMode = treemapColorLang
+	case treemapColorLang:
+		dm.treemapColorMode = treemapColorHeat
+	default:
+		dm.treemapColorMode = treemapColorDir
+	}
+}
+
+// cycleHeatMetric switches the treemap to heat coloring, advancing to the next
+// available metric when it is already active.
+func (dm *DirModel) cycleHeatMetric() {
+	metrics := dm.heatMetrics()
+	if dm.treemapColorMode != treemapColorHeat {
+		dm.treemapColorMode = treemapColorHeat
+		if slices.Contains(metrics, dm.treemapHeatKey) {
+			return
+		}
+		dm.treemapHeatKey = metrics[0]
+		return
+	}
+	idx := slices.Index(metrics, dm.treemapHeatKey)
+	dm.treemapHeatKey = metrics[(idx+1)%len(metrics)]
+}
+
+// treemapColorLabel returns the status bar label for the treemap color mode.
+func (dm *DirModel) treemapColorLabel() string {
+	switch dm.treemapColorMode {
+	case treemapColorLang:
+		return "lang"
+	case treemapColorHeat:
+		return heatMetricLabel(dm.treemapHeatKey)
+	default:
+		return "dir"
+	}
+}
+
+// buildHeatLegend renders a side panel showing the color scale of the active
+// heat metric, hottest at the top.
+func buildHeatLegend(label string, scale heatScale, height int) string {
+	if height < 3 {
+		return ""
+	}
+	contentWidth := treemapLegendWidth - 2
+	contentHeight := height - 2 // subtract top/bottom border
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ebbd34"))
+	lineStyle := lipgloss.NewStyle().Width(contentWidth)
+
+	lines := make([]string, 0, contentHeight)
+	lines = append(lines, titleStyle.Render(fmtName(label, contentWidth)))
+
+	// One line per step, leaving room for the title and the unknown swatch.
+	steps := min(8, contentHeight-3)
+	for i := 0; i < steps; i++ {
+		v := 1.0
+		if steps > 1 {
+			v = 1 - float64(i)/float64(steps-1)
+		}
+		swatch := lipgloss.NewStyle().Foreground(heatColor(scale.palette, v)).Render("██ ")
+		var text string
+		switch {
+		case i == 0:
+			text = scale.hot
+		case i == steps-1:
+			text = scale.cold
+		}
+		lines = append(lines, lineStyle.Render(swatch+fmtName(text, contentWidth-3)))
+	}
+	if contentHeight-len(lines) >= 2 {
+		lines = append(lines, lineStyle.Render(""))
+		unknown := lipgloss.NewStyle().Foreground(heatUnknownColor).Render("██ ")
+		lines = append(lines, lineStyle.Render(unknown+"n/a"))
+	}
+	for len(lines) < contentHeight {
+		lines = append(lines, lineStyle.Render(""))
+	}
+	if len(lines) > contentHeight {
+		lines = lines[:contentHeight]
+	}
+
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Width(treemapLegendWidth).
+		Height(contentHeight).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,225 @@
+package render
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// icicleMaxDepth caps how many levels of the subtree the icicle view shows.
+const icicleMaxDepth = 6
+
+// icicleMinBandHeight is the minimum height of one level band. Fewer levels
+// are shown on short canvases.
+const icicleMinBandHeight = 2
+
+// ToggleIcicleMode switches the block view to the icicle layout, or leaves it
+// when the icicle is already shown. The icicle shares selection, coloring and
+// drill-down with the treemap, so it is a layout of the treemap view.
+func (dm *DirModel) ToggleIcicleMode() {
+	if dm.treemapMode && dm.icicleMode {
+		dm.treemapMode = false
+		dm.icicleMode = false
+	} else {
+		dm.treemapMode = true
+		dm.icicleMode = true
+		dm.treeMode = false
+		dm.flatMode = false
+		dm.pivotMode = false
+	}
+	dm.treemapSelected = 0
+	dm.updateTableData()
+}
+
+// Icicle renders the current directory's subtree as an icicle chart: the
+// children fill the top band in proportion to their size, and each directory's
+// own children fill the band below it, up to icicleMaxDepth levels. Unlike the
+// treemap it shows the nesting depth directly.
+//
+// It returns the rendered string and the layout blocks, which share the
+// treemap's selection and hit-testing.
+func Icicle(width, height int, children []*structure.Entry, getSize func(*structure.Entry) int64, selectedIdx int, coloring treemapColoring) (string, []treemapBlock) {
+	if width <= 0 || height <= 0 {
+		return "", nil
+	}
+
+	depth := subtreeDepth(children, getSize, icicleMaxDepth)
+	if depth == 0 {
+		return treemapEmptyStyle.Render(" (no items to display)"), nil
+	}
+	depth = min(depth, max(1, height/icicleMinBandHeight))
+	bands := icicleBands(height, depth)
+
+	var blocks []treemapBlock
+	tops := 0
+	var layout func(entries []*structure.Entry, x, w, level, topIdx int, parentColor lipgloss.Color)
+	layout = func(entries []*structure.Entry, x, w, level, topIdx int, parentColor lipgloss.Color) {
+		if level >= len(bands) || w <= 0 {
+			return
+		}
+		items := make([]treemapItem, 0, len(entries))
+		var total int64
+		for _, c := range entries {
+			if sz := getSize(c); sz > 0 {
+				items = append(items, treemapItem{entry: c, size: sz})
+				total += sz
+			}
+		}
+		sort.SliceStable(items, func(i, j int) bool { return items[i].size > items[j].size })
+
+		// Place each item by its cumulative share so the widths add up to the
+		// parent exactly; items narrower than a cell are dropped.
+		var running int64
+		for i, it := range items {
+			x0 := x + int(float64(running)/float64(total)*float64(w)+0.5)
+			running += it.size
+			x1 := x + int(float64(running)/float64(total)*float64(w)+0.5)
+			if x1 <= x0 {
+				continue
+			}
+
+			idx := topIdx
+			var color lipgloss.Color
+			switch {
+			case level == 0:
+				idx = tops
+				tops++
+				color = treemapColorFor(it.entry, idx, coloring)
+			case coloring.mode != treemapColorDir:
+				color = treemapColorFor(it.entry, i, coloring)
+			default:
+				// Same shading as nested treemap tiles: children keep the
+				// parent's hue and siblings alternate slightly.
+				shift := float64(i%2)*0.06 - 0.03
+				color = adjustColor(parentColor, -0.05+shift)
+			}
+			blocks = append(blocks, treemapBlock{
+				entry:  it.entry,
+				rect:   treemapRect{x: x0, y: bands[level].y, w: x1 - x0, h: bands[level].h},
+				label:  buildLabel(it.entry, it.size),
+				level:  level,
+				color:  color,
+				topIdx: idx,
+			})
+			if it.entry.IsDir {
+				layout(it.entry.Child, x0, x1-x0, level+1, idx, color)
+			}
+		}
+	}
+	layout(children, 0, width, 0, 0, "")
+
+	if len(blocks) == 0 {
+		return treemapEmptyStyle.Render(" (no items to display)"), nil
+	}
+
+	grid := make([][]treemapCell, height)
+	for y := range grid {
+		grid[y] = make([]treemapCell, width)
+		for x := range grid[y] {
+			grid[y][x] = treemapCell{ch: ' '}
+		}
+	}
+	for i, b := range blocks {
+		color := b.color
+		if i == selectedIdx {
+			color = treemapSelectedBorder
+		}
+		fillRect(grid, b.rect, color)
+		placeIcicleLabel(grid, b, i == selectedIdx, coloring.mode)
+	}
+
+	lines := make([]string, height)
+	for y := range grid {
+		var sb strings.Builder
+		for _, cell := range grid[y] {
+			style := lipgloss.NewStyle().Background(cell.bg)
+			if cell.bold {
+				style = style.Bold(true)
+			}
+			if cell.fg != "" {
+				style = style.Foreground(cell.fg)
+			}
+			sb.WriteString(style.Render(string(cell.ch)))
+		}
+		lines[y] = sb.String()
+	}
+	return strings.Join(lines, "\n"), blocks
+}
+
+// icicleBand is the vertical extent of one level of the icicle.
+type icicleBand struct {
+	y, h int
+}
+
+// icicleBands splits height into depth bands, giving the remainder to the
+// upper levels.
+func icicleBands(height, depth int) []icicleBand {
+	bands := make([]icicleBand, depth)
+	y := 0
+	for i := range bands {
+		h := height / depth
+		if i < height%depth {
+			h++
+		}
+		bands[i] = icicleBand{y: y, h: h}
+		y += h
+	}
+	return bands
+}
+
+// subtreeDepth returns how many levels below the given entries have a
+// positive size, up to limit.
+func subtreeDepth(entries []*structure.Entry, getSize func(*structure.Entry) int64, limit int) int {
+	if limit == 0 {
+		return 0
+	}
+	depth := 0
+	for _, e := range entries {
+		if getSize(e) <= 0 {
+			continue
+		}
+		d := 1
+		if e.IsDir {
+			d += subtreeDepth(e.Child, getSize, limit-1)
+		}
+		depth = max(depth, d)
+		if depth == limit {
+			break
+		}
+	}
+	return depth
+}
+
+// placeIcicleLabel writes a block's label on its first row. A separator in the
+// first column keeps neighboring blocks of similar colors apart.
+func placeIcicleLabel(grid [][]treemapCell, b treemapBlock, selected bool, mode treemapColorMode) {
+	fg := lipgloss.Color("#262626")
+	if mode == treemapColorLang && !selected {
+		fg = lipgloss.Color("#FFFFFF")
+	}
+	for y := b.rect.y; y < b.rect.y+b.rect.h; y++ {
+		setCell(grid, b.rect.x, y, '▏', lipgloss.Color("#262626"), "")
+	}
+
+	runes := []rune(b.label)
+	maxRunes := b.rect.w - 1
+	if maxRunes <= 0 {
+		return
+	}
+	if len(runes) > maxRunes {
+		if maxRunes > 3 {
+			runes = append(runes[:maxRunes-1], '…')
+		} else {
+			runes = runes[:maxRunes]
+		}
+	}
+	bold := selected || (b.entry != nil && b.entry.IsDir)
+	for i, ch := range runes {
+		setCell(grid, b.rect.x+1+i, b.rect.y, ch, fg, "")
+		if x := b.rect.x + 1 + i; x < len(grid[b.rect.y]) {
+			grid[b.rect.y][x].bold = bold
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/structure"
+)
+
+func newIcicleTree() []*structure.Entry {
+	root := structure.NewDirEntry("root")
+	cmd := structure.NewDirEntry("root/cmd")
+	cmd.AddChild(structure.NewFileEntry("root/cmd/main.go", map[string]structure.CodeStats{"Go": {Code: 200}}))
+	cmd.AddChild(structure.NewFileEntry("root/cmd/app.go", map[string]structure.CodeStats{"Go": {Code: 100}}))
+	root.AddChild(cmd)
+	root.AddChild(structure.NewFileEntry("root/a.go", map[string]structure.CodeStats{"Go": {Code: 100}}))
+	root.AggregateStats()
+	return root.Child
+}
+
+func TestIcicleLayout(t *testing.T) {
+	children := newIcicleTree()
+	getSize := func(e *structure.Entry) int64 { return e.TotalStats.Total() }
+	view, blocks := Icicle(40, 10, children, getSize, 0, treemapColoring{})
+
+	if lipgloss.Width(view) != 40 || lipgloss.Height(view) != 10 {
+		t.Fatalf("expected a 40x10 canvas, got %dx%d", lipgloss.Width(view), lipgloss.Height(view))
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("expected 4 blocks, got %d", len(blocks))
+	}
+
+	// cmd takes three quarters of the top band; its children split that width
+	// in the band below.
+	cmd := blocks[0]
+	if cmd.entry.Name() != "cmd" || cmd.level != 0 || cmd.rect != (treemapRect{0, 0, 30, 5}) {
+		t.Fatalf("unexpected cmd block %+v", cmd)
+	}
+	main := blocks[1]
+	if main.entry.Name() != "main.go" || main.level != 1 || main.topIdx != 0 || main.rect != (treemapRect{0, 5, 20, 5}) {
+		t.Fatalf("unexpected main.go block %+v", main)
+	}
+	if blocks[2].rect != (treemapRect{20, 5, 10, 5}) {
+		t.Fatalf("unexpected app.go block %+v", blocks[2])
+	}
+	a := blocks[3]
+	if a.entry.Name() != "a.go" || a.topIdx != 1 || a.rect != (treemapRect{30, 0, 10, 5}) {
+		t.Fatalf("unexpected a.go block %+v", a)
+	}
+
+	if !strings.Contains(view, "cmd/") || !strings.Contains(view, "main.go") {
+		t.Fatal("expected labels for directories and files")
+	}
+	if got := treemapBlockAt(blocks, 25, 7); got != 2 {
+		t.Fatalf("expected click in the second band to hit app.go, got %d", got)
+	}
+}
+
+func TestIcicleLimitsDepthToHeight(t *testing.T) {
+	children := newIcicleTree()
+	getSize := func(e *structure.Entry) int64 { return e.TotalStats.Total() }
+	_, blocks := Icicle(40, 3, children, getSize, 0, treemapColoring{})
+
+	for _, b := range blocks {
+		if b.level != 0 {
+			t.Fatalf("expected only the top level on a short canvas, got %+v", b)
+		}
+		if b.rect.h != 3 {
+			t.Fatalf("expected the only band to fill the canvas, got %+v", b.rect)
+		}
+	}
+}
+
+func TestIcicleEmpty(t *testing.T) {
+	view, blocks := Icicle(40, 10, nil, func(e *structure.Entry) int64 { return 0 }, 0, treemapColoring{})
+	if view == "" || len(blocks) != 0 {
+		t.Fatalf("expected empty-state view without blocks, got %d blocks", len(blocks))
+	}
+}
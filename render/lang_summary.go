@@ -0,0 +1,290 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/provider"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// langSummaryRow is one language of the language summary screen.
+type langSummaryRow struct {
+	lang    string
+	stats   structure.CodeStats
+	percent float64 // share of all lines in the current directory
+}
+
+// langSummaryHeaderHeight is the number of lines above the first row: the
+// title and the two-line table header.
+const langSummaryHeaderHeight = 1 + tableHeaderHeight
+
+// openLangSummary shows the language summary of the current directory.
+func (dm *DirModel) openLangSummary() {
+	dm.mode = LANG_SUMMARY
+	if dm.langSummarySort.Key == SortByNone {
+		dm.langSummarySort = SortState{Key: SortByCode, Desc: true}
+	}
+	dm.langSummaryCursor = 0
+	dm.updateLangSummary()
+}
+
+// closeLangSummary returns to the directory view.
+func (dm *DirModel) closeLangSummary() {
+	dm.mode = READY
+	dm.langSummary = nil
+}
+
+// updateLangSummary collects the languages of the current directory under the
+// test scope and owner filter, sorted by the summary's sort column. The cursor
+// stays on the selected language.
+func (dm *DirModel) updateLangSummary() {
+	var selected string
+	if dm.langSummaryCursor < len(dm.langSummary) {
+		selected = dm.langSummary[dm.langSummaryCursor].lang
+	}
+
+	entry := dm.statsEntry(dm.nav.Entry())
+	total := entry.GetScopedStats("", dm.scope).Total()
+	dm.langSummary = dm.langSummary[:0]
+	for _, lang := range entry.Languages() {
+		stats := entry.GetScopedStats(lang, dm.scope)
+		if stats.Total() == 0 {
+			continue
+		}
+		row := langSummaryRow{lang: lang, stats: stats}
+		if total > 0 {
+			row.percent = float64(stats.Total()) * 100 / float64(total)
+		}
+		dm.langSummary = append(dm.langSummary, row)
+	}
+
+	key, desc := dm.langSummarySort.Key, dm.langSummarySort.Desc
+	slices.SortStableFunc(dm.langSummary, func(a, b langSummaryRow) int {
+		var c int
+		switch key {
+		case SortByName:
+			c = cmp.Compare(strings.ToLower(a.lang), strings.ToLower(b.lang))
+		case SortByPercent:
+			c = cmp.Compare(a.percent, b.percent)
+		default:
+			c = cmp.Compare(langSummaryValue(a.stats, key), langSummaryValue(b.stats, key))
+		}
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(a.lang, b.lang)
+		}
+		return c
+	})
+
+	dm.langSummaryCursor = max(0, slices.IndexFunc(dm.langSummary, func(r langSummaryRow) bool { return r.lang == selected }))
+}
+
+// langSummaryValue returns the numeric value of a summary column.
+func langSummaryValue(stats structure.CodeStats, key SortKey) int64 {
+	switch key {
+	case SortByFiles:
+		return stats.Files
+	case SortByCode:
+		return stats.Code
+	case SortByComments:
+		return stats.Comments
+	case SortByBlanks:
+		return stats.Blanks
+	case SortByComplexity:
+		return stats.Complexity
+	default:
+		return stats.Total()
+	}
+}
+
+// langSummaryColumns returns the columns of the language summary. Complexity
+// is only shown when the provider reports it.
+func (dm *DirModel) langSummaryColumns() []Column {
+	cols := []Column{
+		{Title: "Language", SortKey: SortByName, Width: 20},
+		{Title: "Files", SortKey: SortByFiles, Width: 10},
+		{Title: "Code", SortKey: SortByCode, Width: 12},
+		{Title: "Comments", SortKey: SortByComments, Width: 12},
+		{Title: "Blanks", SortKey: SortByBlanks, Width: 12},
+		{Title: "Total", SortKey: SortByTotal, Width: 12},
+		{Title: "% of Total", SortKey: SortByPercent, Width: 12},
+	}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		cols = append(cols, Column{Title: "Complexity", SortKey: SortByComplexity, Width: 12})
+	}
+	return cols
+}
+
+// langSummaryCells formats a summary row for the given columns.
+func langSummaryCells(cols []Column, row langSummaryRow) []string {
+	cells := make([]string, len(cols))
+	for i, c := range cols {
+		switch c.SortKey {
+		case SortByName:
+			cells[i] = row.lang
+		case SortByPercent:
+			cells[i] = fmt.Sprintf("%.2f %%", row.percent)
+		default:
+			cells[i] = strconv.FormatInt(langSummaryValue(row.stats, c.SortKey), 10)
+		}
+	}
+	return cells
+}
+
+// cycleLangSummarySort advances the summary's sort column.
+func (dm *DirModel) cycleLangSummarySort() {
+	cols := dm.langSummaryColumns()
+	idx := slices.IndexFunc(cols, func(c Column) bool { return c.SortKey == dm.langSummarySort.Key })
+	next := cols[(idx+1)%len(cols)].SortKey
+	dm.langSummarySort = SortState{Key: next, Desc: defaultDescForSortKey(next)}
+	dm.updateLangSummary()
+}
+
+// applyLangSummarySelection makes the selected language the active language
+// filter and returns to the directory view.
+func (dm *DirModel) applyLangSummarySelection() {
+	if dm.langSummaryCursor >= len(dm.langSummary) {
+		dm.closeLangSummary()
+		return
+	}
+	lang := dm.langSummary[dm.langSummaryCursor].lang
+	if idx := slices.Index(dm.languages, lang); idx >= 0 {
+		dm.langFilterIdx = idx
+		dm.selectedLangs = make(map[string]bool)
+	}
+	dm.closeLangSummary()
+	dm.updateTableData()
+}
+
+// handleLangSummaryKeys handles key presses on the language summary screen.
+func (dm *DirModel) handleLangSummaryKeys(bk bindingKey) (tea.Cmd, bool) {
+	switch bk {
+	case cancel:
+		return nil, false
+	case escape, quit, toggleLangSummary:
+		dm.closeLangSummary()
+	case "up", "k":
+		dm.langSummaryCursor = max(0, dm.langSummaryCursor-1)
+	case "down", "j":
+		dm.langSummaryCursor = max(0, min(len(dm.langSummary)-1, dm.langSummaryCursor+1))
+	case "home", "g":
+		dm.langSummaryCursor = 0
+	case "end", "G":
+		dm.langSummaryCursor = max(0, len(dm.langSummary)-1)
+	case cycleSortColumn:
+		dm.cycleLangSummarySort()
+	case toggleSortOrder:
+		dm.langSummarySort.Desc = !dm.langSummarySort.Desc
+		dm.updateLangSummary()
+	case enter:
+		dm.applyLangSummarySelection()
+	}
+	return nil, true
+}
+
+// handleLangSummaryMouse handles mouse events on the language summary screen:
+// the wheel moves the cursor, a click on the header sorts by that column, a
+// click on a row selects it and a double-click applies it.
+func (dm *DirModel) handleLangSummaryMouse(msg tea.MouseMsg) (tea.Cmd, bool) {
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		dm.langSummaryCursor = max(0, dm.langSummaryCursor-1)
+		return nil, true
+	case tea.MouseButtonWheelDown:
+		dm.langSummaryCursor = max(0, min(len(dm.langSummary)-1, dm.langSummaryCursor+1))
+		return nil, true
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return nil, false
+		}
+		if msg.Y >= 1 && msg.Y < langSummaryHeaderHeight {
+			x := 0
+			for _, c := range dm.langSummaryColumns() {
+				if msg.X >= x && msg.X < x+c.Width {
+					if dm.langSummarySort.Key == c.SortKey {
+						dm.langSummarySort.Desc = !dm.langSummarySort.Desc
+					} else {
+						dm.langSummarySort = SortState{Key: c.SortKey, Desc: defaultDescForSortKey(c.SortKey)}
+					}
+					dm.updateLangSummary()
+					break
+				}
+				x += c.Width
+			}
+			return nil, true
+		}
+		row := dm.langSummaryStart + msg.Y - langSummaryHeaderHeight
+		if msg.Y < langSummaryHeaderHeight || row >= len(dm.langSummary) {
+			return nil, true
+		}
+		now := time.Now()
+		double := !dm.lastClick.time.IsZero() && now.Sub(dm.lastClick.time) < doubleClickThreshold && dm.lastClick.row == row
+		dm.lastClick = mouseClick{time: now, row: row}
+		dm.langSummaryCursor = row
+		if double {
+			dm.lastClick = mouseClick{}
+			dm.applyLangSummarySelection()
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// viewLangSummary renders the language summary screen.
+func (dm *DirModel) viewLangSummary() string {
+	cols := dm.langSummaryColumns()
+	render := func(cells []string) string {
+		var sb strings.Builder
+		for i, c := range cols {
+			cell := cells[i]
+			if i == 0 {
+				cell = fmtName(cell, c.Width-1)
+			}
+			sb.WriteString(lipgloss.NewStyle().Width(c.Width).MaxWidth(c.Width).Inline(true).Render(cell))
+		}
+		return sb.String()
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff"))
+	title := titleStyle.Render(fmt.Sprintf("Languages in %s", dm.nav.Entry().Name()))
+	title += lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("  %d languages", len(dm.langSummary)))
+
+	headers := make([]string, len(cols))
+	for i, c := range cols {
+		headers[i] = c.FmtName(dm.langSummarySort)
+	}
+	lines := []string{title, TableHeaderStyle.Render(render(headers))}
+
+	// Keep the cursor inside the visible window of rows.
+	footer := helpDescStyle.Render("↑/↓: move  s/S: sort  Enter: filter by language  Esc: back")
+	visible := max(1, dm.height-langSummaryHeaderHeight-1)
+	start := min(dm.langSummaryStart, dm.langSummaryCursor)
+	if dm.langSummaryCursor >= start+visible {
+		start = dm.langSummaryCursor - visible + 1
+	}
+	start = max(0, min(start, len(dm.langSummary)-visible))
+	dm.langSummaryStart = start
+
+	for i := start; i < min(len(dm.langSummary), start+visible); i++ {
+		line := render(langSummaryCells(cols, dm.langSummary[i]))
+		if i == dm.langSummaryCursor {
+			line = SelectedRowStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(dm.langSummary) == 0 {
+		lines = append(lines, treemapEmptyStyle.Render(" (no languages)"))
+	}
+
+	body := lipgloss.NewStyle().Height(dm.height - 1).Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	return lipgloss.JoinVertical(lipgloss.Left, body, footer)
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+// emptyEntry stands in for entries that have no stats left under the owner
+// filter, the filtered totals or exclusions.
+var emptyEntry = &structure.Entry{}
+
+// updateOwners collects the owners known for the whole tree. Owners are global
+// so the filter keeps its meaning while navigating. The "Owners" column is
+// added once ownership data is available.
+func (dm *DirModel) updateOwners() {
+	root := dm.nav.tree.Root()
+	if root == nil {
+		return
+	}
+	dm.owners = make([]string, 0, len(root.StatsByOwner))
+	for owner := range root.StatsByOwner {
+		dm.owners = append(dm.owners, owner)
+	}
+	sort.Strings(dm.owners)
+
+	if dm.hasOwners() && !slices.ContainsFunc(dm.columns, func(c Column) bool { return c.SortKey == SortByOwners }) {
+		dm.columns = append(dm.columns, Column{Title: "Owners", SortKey: SortByOwners})
+	}
+}
+
+// hasOwners reports whether ownership data is available.
+func (dm *DirModel) hasOwners() bool {
+	return len(dm.owners) > 0
+}
+
+// useOwnerFilter returns true when one or more owners are selected via the
+// owner selection overlay.
+func (dm *DirModel) useOwnerFilter() bool {
+	return len(dm.selectedOwnersList()) > 0
+}
+
+// selectedOwnersList returns the owners selected in the owner overlay.
+func (dm *DirModel) selectedOwnersList() []string {
+	owners := make([]string, 0)
+	for _, owner := range dm.owners {
+		if dm.selectedOwners[owner] {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// applyStatsOverlay recomputes the stats overlay so that every entry only
+// counts the files owned by one of the selected owners that are not excluded
+// and, while filtered totals are on, pass the name and query filters.
+func (dm *DirModel) applyStatsOverlay() {
+	byFilters := dm.filterTotals && dm.filtersActive()
+	dm.overlayInputs = dm.overlaySignature()
+	dm.heatMaxInputs = ""
+	if !dm.useOwnerFilter() && !byFilters && len(dm.excluded) == 0 {
+		dm.statsOverlay = nil
+		return
+	}
+	selected := dm.selectedOwnersList()
+	dm.statsOverlay = structure.AggregateFiltered(dm.nav.tree.Root(), dm.excluded, func(e *structure.Entry) bool {
+		if byFilters && !dm.filters.Valid(e) {
+			return false
+		}
+		if len(selected) == 0 {
+			return true
+		}
+		for _, owner := range selected {
+			if _, ok := e.StatsByOwner[owner]; ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// overlaySignature identifies everything the stats overlay is computed from:
+// the tree, the selected owners, the exclusions and the filtered totals.
+func (dm *DirModel) overlaySignature() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%p\x00", dm.nav.tree.Root())
+	sb.WriteString(strings.Join(dm.selectedOwnersList(), "\x00"))
+	sb.WriteString("\x00\x00")
+	sb.WriteString(strings.Join(dm.exclusionPatterns(), "\x00"))
+	sb.WriteString("\x00\x00")
+	sb.WriteString(dm.filtersSignature())
+	return sb.String()
+}
+
+// syncStatsOverlay recomputes the stats overlay when its inputs changed since
+// it was last computed. Stats loaded into the tree call applyStatsOverlay
+// directly instead.
+func (dm *DirModel) syncStatsOverlay() {
+	if dm.overlaySignature() != dm.overlayInputs {
+		dm.applyStatsOverlay()
+	}
+}
+
+// statsEntry returns the entry whose stats should be shown for e: e itself, or
+// its recomputed copy while the owner filter or filtered totals are active.
+func (dm *DirModel) statsEntry(e *structure.Entry) *structure.Entry {
+	if dm.statsOverlay == nil {
+		return e
+	}
+	if s, ok := dm.statsOverlay[e]; ok {
+		return s
+	}
+	return emptyEntry
+}
+
+// entryOwners returns the owners of an entry ordered by the amount of code
+// they own, largest first.
+func entryOwners(e *structure.Entry) []string {
+	owners := make([]string, 0, len(e.StatsByOwner))
+	for owner := range e.StatsByOwner {
+		owners = append(owners, owner)
+	}
+	slices.SortFunc(owners, func(a, b string) int {
+		if c := cmp.Compare(e.StatsByOwner[b].Code, e.StatsByOwner[a].Code); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	return owners
+}
+
+// statusOwnerLabel returns the comma-separated list of selected owners shown
+// in the status bar.
+func (dm *DirModel) statusOwnerLabel() string {
+	return strings.Join(dm.selectedOwnersList(), ", ")
+}
@@ -0,0 +1,149 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// percentStyle is how the "% of Parent" column is rendered.
+type percentStyle int
+
+const (
+	percentNumber  percentStyle = iota // plain percentage
+	percentBar                         // block bar followed by the percentage
+	percentStacked                     // bar split into language shares
+)
+
+func (s percentStyle) String() string {
+	switch s {
+	case percentBar:
+		return "bar"
+	case percentStacked:
+		return "stacked"
+	default:
+		return "number"
+	}
+}
+
+// percentBarWidth is the number of cells of the inline bar. With the
+// percentage after it, the cell leaves a gap within the column's minimum width.
+const percentBarWidth = 6
+
+// barEighths are the partial blocks for the last cell of a bar, indexed by
+// eighths of a cell.
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// cyclePercentStyle switches the "% of Parent" column between a number, a bar
+// and a bar stacked by language.
+func (dm *DirModel) cyclePercentStyle() {
+	dm.percentStyle = (dm.percentStyle + 1) % 3
+	dm.updateTableData()
+}
+
+// formatPercent renders a "% of Parent" cell in the current style.
+func (dm *DirModel) formatPercent(percent float64) string {
+	if dm.percentStyle == percentNumber {
+		return fmt.Sprintf("%.2f %%", percent)
+	}
+	return percentBarGlyphs(percent, percentBarWidth) + fmt.Sprintf(" %5.1f%%", percent)
+}
+
+// percentBarGlyphs renders percent as a bar of width cells with eighth-cell
+// resolution, padded with spaces to the full width.
+func percentBarGlyphs(percent float64, width int) string {
+	eighths := int(math.Round(min(100, max(0, percent)) / 100 * float64(width*8)))
+	bar := strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+	return bar + strings.Repeat(" ", width-utf8.RuneCountInString(bar))
+}
+
+// barSegmentColors assigns each of the given number of bar cells the color of
+// a language of e, in proportion to the languages' share of the sorted metric.
+// The largest language comes first. Only the languages of the active language
+// filter are considered.
+func (dm *DirModel) barSegmentColors(e *structure.Entry, cells int) []lipgloss.Color {
+	s := dm.statsEntry(e)
+	langs := dm.filteredLangs(s)
+
+	type share struct {
+		lang  string
+		value int64
+	}
+	var shares []share
+	var total int64
+	for _, lang := range langs {
+		if v := metricValue(s.GetScopedStats(lang, dm.scope), dm.sortState.Key); v > 0 {
+			shares = append(shares, share{lang, v})
+			total += v
+		}
+	}
+	if total == 0 || cells <= 0 {
+		return nil
+	}
+	slices.SortStableFunc(shares, func(a, b share) int {
+		if c := cmp.Compare(b.value, a.value); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.lang, b.lang)
+	})
+
+	// Each cell takes the color of the language covering its center.
+	colors := make([]lipgloss.Color, cells)
+	var running int64
+	i := 0
+	for c := range colors {
+		center := (float64(c) + 0.5) / float64(cells) * float64(total)
+		for i < len(shares)-1 && float64(running+shares[i].value) < center {
+			running += shares[i].value
+			i++
+		}
+		colors[c] = langColor(shares[i].lang)
+	}
+	return colors
+}
+
+// colorizePercentBars colors the stacked bars of a rendered table view. The
+// table truncates cells without regard for escape sequences, so the bars are
+// rendered as plain glyphs and colored afterwards. The selected row keeps its
+// plain highlight.
+func (dm *DirModel) colorizePercentBars(view string) string {
+	if dm.percentStyle != percentStacked {
+		return view
+	}
+	col := slices.IndexFunc(dm.visibleColumns(), func(c Column) bool { return c.SortKey == SortByPercent })
+	cursorLine := dm.findCursorLineInView(view)
+	if col < 0 || cursorLine < 0 {
+		return view
+	}
+
+	rows := dm.dirsTable.Rows()
+	cursor := dm.dirsTable.Cursor()
+	lines := strings.Split(view, "\n")
+	for i := tableHeaderHeight; i < len(lines); i++ {
+		r := cursor + (i - tableHeaderHeight - cursorLine)
+		if r == cursor || r < 0 || r >= len(rows) || r >= len(dm.tableEntries) || dm.tableEntries[r].isParent {
+			continue
+		}
+		cell := []rune(rows[r][col])
+		glyphs := strings.TrimRight(string(cell[:min(len(cell), percentBarWidth)]), " ")
+		if glyphs == "" {
+			continue
+		}
+		colors := dm.barSegmentColors(dm.tableEntries[r].entry, utf8.RuneCountInString(glyphs))
+		if colors == nil {
+			continue
+		}
+		var sb strings.Builder
+		for j, ch := range []rune(glyphs) {
+			sb.WriteString(lipgloss.NewStyle().Foreground(colors[j]).Render(string(ch)))
+		}
+		lines[i] = strings.Replace(lines[i], glyphs, sb.String(), 1)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,162 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+// pivotLangPrefix marks the sort keys of the per-language pivot columns.
+const pivotLangPrefix = "lang:"
+
+// pivotMaxLangs caps the number of language columns in the pivot matrix.
+const pivotMaxLangs = 8
+
+// pivotShades shade pivot cells by magnitude, from the smallest share to the
+// largest.
+var pivotShades = []string{"░", "▒", "▓", "█"}
+
+// pivotLangKey returns the sort key of the pivot column for lang.
+func pivotLangKey(lang string) SortKey {
+	return SortKey(pivotLangPrefix + lang)
+}
+
+// pivotLang returns the language of a pivot column sort key.
+func pivotLang(key SortKey) (string, bool) {
+	return strings.CutPrefix(string(key), pivotLangPrefix)
+}
+
+// isPivotKey reports whether key sorts by a pivot matrix cell.
+func isPivotKey(key SortKey) bool {
+	_, ok := pivotLang(key)
+	return ok || key == SortByPivotOther
+}
+
+// TogglePivotMode switches the table between the regular columns and the
+// directory × language matrix.
+func (dm *DirModel) TogglePivotMode() {
+	dm.pivotMode = !dm.pivotMode
+	if dm.pivotMode {
+		// The matrix lists the current directory's children only.
+		dm.treeMode = false
+		dm.treemapMode = false
+		dm.icicleMode = false
+		dm.flatMode = false
+	} else if isPivotKey(dm.sortState.Key) {
+		dm.sortState = SortState{Key: SortByCode, Desc: true}
+	}
+	dm.updateTableData()
+}
+
+// togglePivotPercent switches pivot cells between lines of code and the
+// share of each row's code.
+func (dm *DirModel) togglePivotPercent() {
+	dm.pivotPercent = !dm.pivotPercent
+	dm.updateTableData()
+}
+
+// updatePivot picks the languages shown as pivot columns: the ones with the
+// most code in the current directory, as many as fit the terminal. It also
+// records the largest cell value, which the shading is relative to.
+func (dm *DirModel) updatePivot() {
+	entry := dm.statsEntry(dm.nav.Entry())
+	code := func(lang string) int64 { return entry.GetScopedStats(lang, dm.scope).Code }
+	langs := slices.DeleteFunc(dm.filteredLangs(entry), func(lang string) bool { return code(lang) == 0 })
+	slices.SortStableFunc(langs, func(a, b string) int {
+		if c := cmp.Compare(code(b), code(a)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	// Name, Other and Code take about 40 cells; each language column 12.
+	limit := max(1, min(pivotMaxLangs, (dm.width-40)/12))
+	dm.pivotLangs = langs[:min(len(langs), limit)]
+
+	dm.pivotMax = 0
+	for _, child := range dm.filteredChildren() {
+		for _, lang := range dm.pivotLangs {
+			dm.pivotMax = max(dm.pivotMax, dm.pivotCell(child, pivotLangKey(lang)))
+		}
+		dm.pivotMax = max(dm.pivotMax, dm.pivotCell(child, SortByPivotOther))
+	}
+}
+
+// pivotColumns returns the columns of the pivot matrix.
+func (dm *DirModel) pivotColumns() []Column {
+	cols := slices.Clone(dm.columns[:3])
+	for _, lang := range dm.pivotLangs {
+		cols = append(cols, Column{Title: lang, SortKey: pivotLangKey(lang)})
+	}
+	return append(cols,
+		Column{Title: "Other", SortKey: SortByPivotOther},
+		Column{Title: "Code", SortKey: SortByCode},
+	)
+}
+
+// pivotCode returns the lines of code of e in the language of a pivot column,
+// or in the languages without a column of their own for "Other".
+func (dm *DirModel) pivotCode(e *structure.Entry, key SortKey) int64 {
+	s := dm.statsEntry(e)
+	if lang, ok := pivotLang(key); ok {
+		return s.GetScopedStats(lang, dm.scope).Code
+	}
+	other := dm.comparableStats(e).Code
+	for _, lang := range dm.pivotLangs {
+		other -= s.GetScopedStats(lang, dm.scope).Code
+	}
+	return max(0, other)
+}
+
+// pivotCell returns the value shown in a pivot cell: lines of code, or the
+// share of the row's code in percent mode.
+func (dm *DirModel) pivotCell(e *structure.Entry, key SortKey) float64 {
+	code := dm.pivotCode(e, key)
+	if !dm.pivotPercent {
+		return float64(code)
+	}
+	total := dm.comparableStats(e).Code
+	if total == 0 {
+		return 0
+	}
+	return float64(code) * 100 / float64(total)
+}
+
+// formatPivotCell renders a pivot cell with a shade glyph showing its
+// magnitude. Empty cells stay blank so the languages a row contains stand out.
+func (dm *DirModel) formatPivotCell(e *structure.Entry, key SortKey) string {
+	v := dm.pivotCell(e, key)
+	if v == 0 {
+		return ""
+	}
+	scale := 100.0
+	if !dm.pivotPercent {
+		scale = dm.pivotMax
+	}
+	shade := pivotShade(v, scale, !dm.pivotPercent)
+
+	text := strconv.FormatInt(int64(v), 10)
+	if dm.pivotPercent {
+		text = fmt.Sprintf("%.1f %%", v)
+	}
+	return shade + " " + text
+}
+
+// pivotShade picks the shade glyph for v out of maxValue. Line counts use a
+// log scale so a single large service does not wash out all other cells.
+func pivotShade(v, maxValue float64, logScale bool) string {
+	if maxValue <= 0 {
+		return pivotShades[0]
+	}
+	frac := v / maxValue
+	if logScale {
+		frac = math.Log1p(v) / math.Log1p(maxValue)
+	}
+	idx := int(frac * float64(len(pivotShades)))
+	return pivotShades[min(len(pivotShades)-1, max(0, idx))]
+}
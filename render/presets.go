@@ -0,0 +1,330 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/filter"
+)
+
+// presetsFileName is the file below the analysis root that presets are
+// stored in, so each repository keeps its own.
+const presetsFileName = ".tokuipresets.json"
+
+// Preset is a named view state: filters, sorting, the view and the treemap
+// settings.
+type Preset struct {
+	Name         string   `json:"name"`
+	View         string   `json:"view"` // see viewName
+	Langs        []string `json:"langs,omitempty"`
+	Lang         string   `json:"lang,omitempty"` // single language filter
+	NameFilter   string   `json:"nameFilter,omitempty"`
+	Query        string   `json:"query,omitempty"`
+	Sort         SortKey  `json:"sort"`
+	Desc         bool     `json:"desc"`
+	TreemapSize  SortKey  `json:"treemapSize"`
+	TreemapColor string   `json:"treemapColor"` // dir, lang or heat
+	TreemapHeat  SortKey  `json:"treemapHeat"`
+}
+
+// treemapColorNames are the stored names of the treemap color modes.
+var treemapColorNames = map[treemapColorMode]string{
+	treemapColorDir:  "dir",
+	treemapColorLang: "lang",
+	treemapColorHeat: "heat",
+}
+
+// SetStartPreset sets the preset applied once the tree has been scanned.
+func (dm *DirModel) SetStartPreset(name string) {
+	dm.startPreset = name
+}
+
+// presetsPath returns the path of the presets file of the analysis root.
+func (dm *DirModel) presetsPath() string {
+	return filepath.Join(dm.nav.tree.Root().Path, presetsFileName)
+}
+
+// loadPresets reads the presets of the repository. A missing file holds no
+// presets.
+func (dm *DirModel) loadPresets() ([]Preset, error) {
+	data, err := os.ReadFile(dm.presetsPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var presets []Preset
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("%s: %w", presetsFileName, err)
+	}
+	return presets, nil
+}
+
+// savePresets writes the presets of the repository.
+func (dm *DirModel) savePresets(presets []Preset) error {
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dm.presetsPath(), append(data, '\n'), 0o644)
+}
+
+// viewName names the active view: nav, tree, treemap, icicle, flat or pivot.
+func (dm *DirModel) viewName() string {
+	switch {
+	case dm.icicleMode:
+		return "icicle"
+	case dm.treemapMode:
+		return "treemap"
+	case dm.treeMode:
+		return "tree"
+	case dm.flatMode:
+		return "flat"
+	case dm.pivotMode:
+		return "pivot"
+	default:
+		return "nav"
+	}
+}
+
+// currentPreset captures the current view state under the given name.
+func (dm *DirModel) currentPreset(name string) Preset {
+	p := Preset{
+		Name:         name,
+		View:         dm.viewName(),
+		Langs:        dm.selectedLangsList(),
+		Lang:         dm.activeLang(),
+		Sort:         dm.sortState.Key,
+		Desc:         dm.sortState.Desc,
+		TreemapSize:  dm.treemapSizeKey,
+		TreemapColor: treemapColorNames[dm.treemapColorMode],
+		TreemapHeat:  dm.treemapHeatKey,
+	}
+	if f, ok := dm.filters[filter.NameFilterID].(*filter.NameFilter); ok && f.IsEnabled() {
+		p.NameFilter = f.Value()
+	}
+	if q, ok := dm.queryFilter(); ok && q.IsEnabled() {
+		p.Query = q.Value()
+	}
+	return p
+}
+
+// applyPreset restores the view state of p. Languages missing from the tree
+// are dropped.
+func (dm *DirModel) applyPreset(p Preset) {
+	dm.treeMode = p.View == "tree"
+	dm.treemapMode = p.View == "treemap" || p.View == "icicle"
+	dm.icicleMode = p.View == "icicle"
+	dm.flatMode = p.View == "flat"
+	dm.pivotMode = p.View == "pivot"
+	dm.treemapSelected = 0
+
+	dm.selectedLangs = make(map[string]bool)
+	for _, lang := range p.Langs {
+		if slices.Contains(dm.languages, lang) {
+			dm.selectedLangs[lang] = true
+		}
+	}
+	dm.langFilterIdx = slices.Index(dm.languages, p.Lang)
+
+	if f, ok := dm.filters[filter.NameFilterID].(*filter.NameFilter); ok {
+		f.Reset()
+		if p.NameFilter != "" {
+			f.Toggle()
+			f.SetValue(p.NameFilter)
+		}
+	}
+	if q, ok := dm.queryFilter(); ok {
+		q.Reset()
+		if p.Query != "" {
+			q.SetValue(p.Query)
+			q.Commit()
+		}
+	}
+
+	if p.Sort != SortByNone {
+		dm.sortState = SortState{Key: p.Sort, Desc: p.Desc}
+	}
+	if p.TreemapSize != SortByNone {
+		dm.treemapSizeKey = p.TreemapSize
+	}
+	for mode, name := range treemapColorNames {
+		if name == p.TreemapColor {
+			dm.treemapColorMode = mode
+		}
+	}
+	if p.TreemapHeat != SortByNone {
+		dm.treemapHeatKey = p.TreemapHeat
+	}
+
+	dm.mode = READY
+	dm.applyStatsOverlay()
+	dm.updateTableData(true)
+}
+
+// applyStartPreset applies the preset given on the command line, once.
+func (dm *DirModel) applyStartPreset() error {
+	name := dm.startPreset
+	if name == "" {
+		return nil
+	}
+	dm.startPreset = ""
+	presets, err := dm.loadPresets()
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(presets, func(p Preset) bool { return p.Name == name })
+	if i < 0 {
+		return fmt.Errorf("unknown preset %q", name)
+	}
+	dm.applyPreset(presets[i])
+	return nil
+}
+
+// newPresetInput creates the text input naming a preset being saved.
+func newPresetInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "preset name"
+	ti.Prompt = "Name: "
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#3a86ff"))
+	return ti
+}
+
+// openPresets shows the preset picker. When saving, the overlay asks for the
+// name of the current view state first.
+func (dm *DirModel) openPresets(saving bool) {
+	presets, err := dm.loadPresets()
+	if err != nil {
+		dm.err = err
+		return
+	}
+	dm.mode = PRESETS
+	dm.presets = presets
+	dm.presetIndex = 0
+	dm.presetSaving = saving
+	dm.presetInput = newPresetInput()
+	if saving {
+		dm.presetInput.Focus()
+	}
+}
+
+// handlePresetKeys handles key presses while the preset overlay is open.
+func (dm *DirModel) handlePresetKeys(msg tea.KeyMsg, bk bindingKey) (tea.Cmd, bool) {
+	if bk == cancel {
+		return nil, false
+	}
+	if dm.presetSaving {
+		switch bk {
+		case escape:
+			dm.mode = READY
+		case enter:
+			name := strings.TrimSpace(dm.presetInput.Value())
+			if name == "" {
+				return nil, true
+			}
+			p := dm.currentPreset(name)
+			if i := slices.IndexFunc(dm.presets, func(q Preset) bool { return q.Name == name }); i >= 0 {
+				dm.presets[i] = p
+			} else {
+				dm.presets = append(dm.presets, p)
+			}
+			if err := dm.savePresets(dm.presets); err != nil {
+				dm.err = err
+			}
+			dm.mode = READY
+		default:
+			dm.presetInput, _ = dm.presetInput.Update(msg)
+		}
+		return nil, true
+	}
+
+	switch bk {
+	case escape, openPresets, quit:
+		dm.mode = READY
+	case "up", "k":
+		dm.presetIndex = max(0, dm.presetIndex-1)
+	case "down", "j":
+		dm.presetIndex = max(0, min(len(dm.presets)-1, dm.presetIndex+1))
+	case enter:
+		if dm.presetIndex < len(dm.presets) {
+			dm.applyPreset(dm.presets[dm.presetIndex])
+		}
+	case deletePreset:
+		if dm.presetIndex < len(dm.presets) {
+			dm.presets = slices.Delete(dm.presets, dm.presetIndex, dm.presetIndex+1)
+			dm.presetIndex = max(0, min(len(dm.presets)-1, dm.presetIndex))
+			if err := dm.savePresets(dm.presets); err != nil {
+				dm.err = err
+				dm.mode = READY
+			}
+		}
+	}
+	return nil, true
+}
+
+// presetSummary describes a preset in one line.
+func presetSummary(p Preset) string {
+	parts := []string{p.View}
+	if len(p.Langs) > 0 {
+		parts = append(parts, strings.Join(p.Langs, "+"))
+	} else if p.Lang != "" {
+		parts = append(parts, p.Lang)
+	}
+	if p.NameFilter != "" {
+		parts = append(parts, "/"+p.NameFilter)
+	}
+	if p.Query != "" {
+		parts = append(parts, ":"+p.Query)
+	}
+	if p.Sort != SortByNone {
+		dir := "↑"
+		if p.Desc {
+			dir = "↓"
+		}
+		parts = append(parts, string(p.Sort)+" "+dir)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// viewPresets renders the preset overlay.
+func (dm *DirModel) viewPresets() string {
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).Render("Presets")
+	hint := "Enter: apply, d: delete, Esc: close"
+	if dm.presetSaving {
+		title = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).Render("Save Preset")
+		hint = "Enter: save (replaces a preset of the same name), Esc: cancel"
+	}
+	lines := []string{title, lipgloss.NewStyle().Faint(true).Render(hint), ""}
+
+	if dm.presetSaving {
+		lines = append(lines, dm.presetInput.View(), "",
+			lipgloss.NewStyle().Faint(true).Render(presetSummary(dm.currentPreset(""))))
+	} else {
+		if len(dm.presets) == 0 {
+			lines = append(lines, treemapEmptyStyle.Render(" (no presets; press Ctrl+S to save the current view)"))
+		}
+		width := dm.width / 2
+		for i, p := range dm.presets {
+			cursor := "  "
+			name := p.Name
+			if i == dm.presetIndex {
+				cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("#3a86ff")).Render("→ ")
+				name = lipgloss.NewStyle().Bold(true).Render(name)
+			}
+			lines = append(lines, cursor+name+"  "+
+				lipgloss.NewStyle().Faint(true).Render(fmtName(presetSummary(p), width)))
+		}
+	}
+	lines = append(lines, "", lipgloss.NewStyle().Faint(true).Render(dm.presetsPath()))
+	return chartBoxStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zdyxry/tokui/filter"
+)
+
+// queryFilter returns the query filter of the directory view.
+func (dm *DirModel) queryFilter() (*filter.QueryFilter, bool) {
+	q, ok := dm.filters[filter.QueryFilterID].(*filter.QueryFilter)
+	return q, ok
+}
+
+// openQuery starts editing the query filter, keeping the current query.
+func (dm *DirModel) openQuery() {
+	q, ok := dm.queryFilter()
+	if !ok {
+		return
+	}
+	dm.mode = QUERY
+	q.Edit()
+	dm.updateTableData()
+}
+
+// handleQueryKeys handles key presses while the query is edited. The table
+// follows the query as it is typed; Enter keeps it applied and Esc clears it.
+func (dm *DirModel) handleQueryKeys(msg tea.KeyMsg, bk bindingKey) (tea.Cmd, bool) {
+	q, ok := dm.queryFilter()
+	if !ok {
+		dm.mode = READY
+		return nil, false
+	}
+	switch bk {
+	case cancel:
+		return nil, false
+	case escape:
+		q.Reset()
+		dm.mode = READY
+	case enter:
+		q.Commit()
+		dm.mode = READY
+	case "tab":
+		q.Complete()
+	default:
+		q.Update(msg)
+	}
+	dm.updateTableData(true)
+	return nil, true
+}
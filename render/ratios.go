@@ -0,0 +1,51 @@
+package render
+
+import (
+	"github.com/zdyxry/tokui/structure"
+)
+
+// commentRatio returns the number of comment lines per line of code.
+func commentRatio(stats structure.CodeStats) (float64, bool) {
+	if stats.Code == 0 {
+		return 0, false
+	}
+	return float64(stats.Comments) / float64(stats.Code), true
+}
+
+// blankRatio returns the share of blank lines among all lines.
+func blankRatio(stats structure.CodeStats) (float64, bool) {
+	if stats.Total() == 0 {
+		return 0, false
+	}
+	return float64(stats.Blanks) / float64(stats.Total()), true
+}
+
+// complexityDensity returns the complexity per 1000 lines of code.
+func complexityDensity(stats structure.CodeStats) (float64, bool) {
+	if stats.Code == 0 {
+		return 0, false
+	}
+	return float64(stats.Complexity) * 1000 / float64(stats.Code), true
+}
+
+// ratioValue returns the value of a ratio metric for stats.
+func ratioValue(stats structure.CodeStats, key SortKey) (float64, bool) {
+	switch key {
+	case SortByCommentRatio:
+		return commentRatio(stats)
+	case SortByBlankRatio:
+		return blankRatio(stats)
+	case SortByComplexityDensity:
+		return complexityDensity(stats)
+	default:
+		return 0, false
+	}
+}
+
+// ratioValueFunc returns a function reading the ratio metric key from the
+// filtered stats of an entry.
+func (dm *DirModel) ratioValueFunc(key SortKey) func(*structure.Entry) (float64, bool) {
+	return func(e *structure.Entry) (float64, bool) {
+		return ratioValue(dm.comparableStats(e), key)
+	}
+}
@@ -32,15 +32,23 @@ func NewViewModel(n *Navigation, dirModel *DirModel) *ViewModel {
 }
 
 func (vm *ViewModel) Init() tea.Cmd {
-	return nil
+	return vm.dirModel.Init()
 }
 
 func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := vm.update(msg)
+	// Navigation may have entered a directory whose authors are not loaded
+	// yet, and a churn or time metric may have been selected for the first
+	// time.
+	return model, tea.Batch(cmd, vm.dirModel.authorsCmd(), vm.dirModel.churnCmd(), vm.dirModel.timesCmd())
+}
+
+func (vm *ViewModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if vm.dirModel.mode == SELECT_LANG {
+		if vm.dirModel.inSelectMode() || vm.dirModel.mode == LANG_SUMMARY || vm.dirModel.mode == EXCLUDED || vm.dirModel.mode == PRESETS {
 			bk := parseBindingKey(msg)
 			if bk == cancel {
 				return vm, tea.Quit
@@ -51,7 +59,7 @@ func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch bk {
 		case quit:
-			if vm.dirModel.mode != PREVIEW && vm.dirModel.mode != INPUT && vm.dirModel.mode != SEARCH {
+			if vm.dirModel.mode != PREVIEW && vm.dirModel.mode != INPUT && vm.dirModel.mode != SEARCH && vm.dirModel.mode != QUERY {
 				return vm, tea.Quit
 			}
 		case cancel:
@@ -78,9 +86,13 @@ func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case SEARCH:
 				vm.dirModel.applySearchResult()
+			case QUERY:
+				// Handled by DirModel, which applies the query.
 			default:
 				if vm.dirModel.IsParentSelected() {
 					vm.levelUp()
+				} else if vm.dirModel.flatMode {
+					vm.dirModel.revealSelected()
 				} else if vm.dirModel.treeMode {
 					vm.toggleExpand()
 				} else if vm.dirModel.treemapMode {
@@ -91,7 +103,7 @@ func (vm *ViewModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case backspace:
 			// If DirModel is in input mode, don't handle top-level shortcuts
-			if vm.dirModel.mode == INPUT || vm.dirModel.mode == SEARCH {
+			if vm.dirModel.mode == INPUT || vm.dirModel.mode == SEARCH || vm.dirModel.mode == QUERY {
 				break
 			}
 			vm.levelUp()
@@ -128,11 +140,22 @@ func (vm *ViewModel) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		_, cmd = vm.dirModel.filePreview.Update(msg)
 		return vm, cmd
 
-	case vm.dirModel.mode == SELECT_LANG:
-		// Consume all mouse events while the language overlay is open.
-		cmd, _ = vm.dirModel.handleLangSelectMouse(msg)
+	case vm.dirModel.inSelectMode():
+		// Consume all mouse events while a select overlay is open.
+		cmd, _ = vm.dirModel.handleSelectMouse(msg)
+		return vm, cmd
+
+	case vm.dirModel.mode == LANG_SUMMARY:
+		cmd, _ = vm.dirModel.handleLangSummaryMouse(msg)
 		return vm, cmd
 
+	case vm.dirModel.mode == EXCLUDED || vm.dirModel.mode == PRESETS:
+		// Click outside the exclusions or preset overlay closes it.
+		if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress && !vm.dirModel.isInsideOverlay(msg.X, msg.Y) {
+			vm.dirModel.mode = READY
+		}
+		return vm, nil
+
 	case vm.dirModel.showCart:
 		// Click outside the chart closes it.
 		if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress && !vm.dirModel.isInsideChartBox(msg.X, msg.Y) {
@@ -140,6 +163,13 @@ func (vm *ViewModel) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 			return vm, nil
 		}
 		return vm, nil
+
+	case vm.dirModel.showSelection:
+		// Click outside the selection panel closes it.
+		if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress && !vm.dirModel.isInsideOverlay(msg.X, msg.Y) {
+			vm.dirModel.showSelection = false
+		}
+		return vm, nil
 	}
 
 	if vm.dirModel.treemapMode {
@@ -160,7 +190,9 @@ func (vm *ViewModel) handleMouseMsg(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	row, clickCount, handled := vm.dirModel.handleTableMouse(msg)
 	if handled {
 		if clickCount >= 2 && row >= 0 {
-			if vm.dirModel.treeMode {
+			if vm.dirModel.flatMode {
+				vm.dirModel.revealSelected()
+			} else if vm.dirModel.treeMode {
 				vm.toggleExpand()
 			} else {
 				vm.levelDown()
@@ -185,6 +217,10 @@ func (vm *ViewModel) levelDown() {
 		vm.levelUp()
 		return
 	}
+	if vm.dirModel.IsFoldSelected() {
+		vm.dirModel.expandFold()
+		return
+	}
 
 	selectedRow := vm.dirModel.dirsTable.SelectedRow()
 	entryName := selectedRow[2]
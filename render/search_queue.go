@@ -0,0 +1,152 @@
+package render
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zdyxry/tokui/search"
+)
+
+const (
+	// searchSyncItems is the largest index searched on every keystroke; larger
+	// ones are searched in the background once typing pauses.
+	searchSyncItems = 50000
+	// searchDebounce is the pause in typing after which a background search
+	// starts.
+	searchDebounce = 120 * time.Millisecond
+	// searchResultLimit caps the results kept for the search overlay.
+	searchResultLimit = 1000
+	// searchIndexStep is the number of entries indexed per update while the
+	// search index is built, so that the UI stays responsive on large trees.
+	searchIndexStep = 20000
+)
+
+// SearchIndexing continues building the search index after the UI has had a
+// chance to update.
+type SearchIndexing struct {
+	builder *search.IndexBuilder
+}
+
+// SearchDebounced is sent once typing in the search overlay has paused. Seq
+// identifies the query, so pauses of superseded queries are ignored.
+type SearchDebounced struct {
+	Seq int
+}
+
+// SearchDone carries the results of a background name search.
+type SearchDone struct {
+	Seq     int
+	Matches []search.Match
+}
+
+// buildSearchIndex starts building the search index of the tree, unless it is
+// being built already. Each step indexes searchIndexStep entries on the update
+// loop, where the tree is safe to read, and yields to the UI before the next.
+func (dm *DirModel) buildSearchIndex() tea.Cmd {
+	if dm.searchIndexer != nil {
+		return nil
+	}
+	dm.searchIndexer = search.NewIndexBuilder(dm.nav.tree.Root())
+	return dm.continueSearchIndex(SearchIndexing{builder: dm.searchIndexer})
+}
+
+// continueSearchIndex indexes the next entries. Once the index is complete,
+// the query typed meanwhile is run.
+func (dm *DirModel) continueSearchIndex(msg SearchIndexing) tea.Cmd {
+	if msg.builder != dm.searchIndexer {
+		return nil
+	}
+	if !msg.builder.Step(searchIndexStep) {
+		return func() tea.Msg { return msg }
+	}
+	dm.searchIndex = msg.builder.Index()
+	dm.searchIndexer = nil
+	if dm.mode != SEARCH {
+		return nil
+	}
+	return dm.queueSearchQuery()
+}
+
+// queueSearchQuery runs the query typed into the search overlay. Small indexes
+// are searched at once; large ones and file contents are searched in the
+// background after searchDebounce without further typing.
+func (dm *DirModel) queueSearchQuery() tea.Cmd {
+	dm.cancelSearch()
+	if !dm.searchContent && dm.searchIndex.Items() <= searchSyncItems {
+		return dm.updateSearchQuery()
+	}
+	if dm.searchContent {
+		dm.cancelContentSearch()
+	}
+	dm.searchPending = true
+	seq := dm.searchSeq
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+		return SearchDebounced{Seq: seq}
+	})
+}
+
+// runQueuedSearch starts the background search of a query once typing has
+// paused, unless the query has changed since.
+func (dm *DirModel) runQueuedSearch(msg SearchDebounced) tea.Cmd {
+	if msg.Seq != dm.searchSeq || dm.mode != SEARCH {
+		return nil
+	}
+	if dm.searchContent {
+		dm.searchPending = false
+		return dm.contentSearchCmd()
+	}
+	if dm.searchIndex == nil {
+		dm.searchPending = false
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dm.searchCancel = cancel
+	idx, query, limit, seq := dm.searchIndex, dm.searchInput.Value(), dm.searchFindLimit(), dm.searchSeq
+	return func() tea.Msg {
+		return SearchDone{Seq: seq, Matches: idx.FindTop(ctx, query, limit)}
+	}
+}
+
+// applySearchDone shows the results of the latest background search.
+func (dm *DirModel) applySearchDone(msg SearchDone) {
+	if msg.Seq != dm.searchSeq || dm.mode != SEARCH || dm.searchContent {
+		return
+	}
+	dm.searchCancel = nil
+	dm.searchPending = false
+	dm.setSearchMatches(msg.Matches)
+}
+
+// cancelSearch stops a waiting or running background search and makes its
+// results stale.
+func (dm *DirModel) cancelSearch() {
+	if dm.searchCancel != nil {
+		dm.searchCancel()
+		dm.searchCancel = nil
+	}
+	dm.searchSeq++
+	dm.searchPending = false
+}
+
+// searchFindLimit returns how many matches to ask the index for. Results
+// sorted by a metric need all matches to find the largest ones.
+func (dm *DirModel) searchFindLimit() int {
+	if dm.searchSort != SortByNone {
+		return 0
+	}
+	return searchResultLimit
+}
+
+// setSearchMatches shows matches in the search overlay, sorted by the search
+// sort and capped at searchResultLimit.
+func (dm *DirModel) setSearchMatches(matches []search.Match) {
+	dm.searchMatches = matches
+	dm.sortSearchMatches()
+	if len(dm.searchMatches) > searchResultLimit {
+		dm.searchMatches = dm.searchMatches[:searchResultLimit]
+	}
+	dm.searchCursor = 0
+	dm.searchOffset = 0
+}
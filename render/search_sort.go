@@ -0,0 +1,81 @@
+package render
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/provider"
+	"github.com/zdyxry/tokui/search"
+)
+
+// cycleSearchSort switches the order of global search results between the
+// fuzzy score, lines of code and complexity (when the provider reports it).
+// Content search results keep their file order. The query is run again like
+// a typed one, so large indexes are searched in the background.
+func (dm *DirModel) cycleSearchSort() tea.Cmd {
+	order := []SortKey{SortByNone, SortByCode}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		order = append(order, SortByComplexity)
+	}
+	dm.searchSort = order[(slices.Index(order, dm.searchSort)+1)%len(order)]
+	if dm.searchContent {
+		return nil
+	}
+	return dm.queueSearchQuery()
+}
+
+// sortSearchMatches orders the search results by the search sort metric,
+// largest first. Ties keep their fuzzy order. Metrics are computed once per
+// result, as there may be many.
+func (dm *DirModel) sortSearchMatches() {
+	if dm.searchSort == SortByNone {
+		return
+	}
+	type ranked struct {
+		match  search.Match
+		metric int64
+	}
+	rs := make([]ranked, len(dm.searchMatches))
+	for i, m := range dm.searchMatches {
+		rs[i] = ranked{m, dm.searchMetric(m)}
+	}
+	slices.SortStableFunc(rs, func(a, b ranked) int { return cmp.Compare(b.metric, a.metric) })
+	for i, r := range rs {
+		dm.searchMatches[i] = r.match
+	}
+}
+
+// searchMetric returns the value of a search result for the search sort.
+func (dm *DirModel) searchMetric(m search.Match) int64 {
+	stats := dm.comparableStats(m.Item.Entry)
+	if dm.searchSort == SortByComplexity {
+		return stats.Complexity
+	}
+	return stats.Code
+}
+
+// searchSortLabel describes the order of the search results.
+func (dm *DirModel) searchSortLabel() string {
+	if dm.searchSort == SortByNone {
+		return "by match"
+	}
+	return "by " + string(dm.searchSort)
+}
+
+// searchResultStats renders the stats shown at the end of a search result:
+// lines of code, complexity when available, and the file count of
+// directories.
+func (dm *DirModel) searchResultStats(m search.Match) string {
+	stats := dm.comparableStats(m.Item.Entry)
+	parts := []string{formatNumber(stats.Code) + " code"}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		parts = append(parts, "cx "+formatNumber(stats.Complexity))
+	}
+	if m.Item.Entry.IsDir {
+		parts = append(parts, formatNumber(stats.Files)+" files")
+	}
+	return lipgloss.NewStyle().Faint(true).Render(strings.Join(parts, " · "))
+}
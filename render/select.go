@@ -0,0 +1,227 @@
+package render
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var errNoOwners = errors.New("no CODEOWNERS file found for this project")
+
+// inSelectMode reports whether a multi-select overlay (languages or owners) is
+// open.
+func (dm *DirModel) inSelectMode() bool {
+	return dm.mode == SELECT_LANG || dm.mode == SELECT_OWNER
+}
+
+// selectKind returns the overlay kind of the open select overlay.
+func (dm *DirModel) selectKind() string {
+	if dm.mode == SELECT_OWNER {
+		return "ownerselect"
+	}
+	return "langselect"
+}
+
+// selectList returns the items and selection state backing the select overlay
+// of the given kind.
+func (dm *DirModel) selectList(kind string) ([]string, map[string]bool) {
+	if kind == "ownerselect" {
+		return dm.owners, dm.selectedOwners
+	}
+	return dm.languages, dm.selectedLangs
+}
+
+// viewSelectOverlay renders the open select overlay and records its bounds
+// for mouse hit-testing.
+func (dm *DirModel) viewSelectOverlay() string {
+	kind := dm.selectKind()
+	items, selected := dm.selectList(kind)
+
+	titleText := "Select Languages"
+	if kind == "ownerselect" {
+		titleText = "Select Owners"
+	}
+
+	var lines []string
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).Render(titleText)
+	desc := lipgloss.NewStyle().Faint(true).Render("Space: toggle, Enter: confirm, Esc: cancel")
+	lines = append(lines, title)
+	lines = append(lines, desc)
+	// Calculate visible window height (excluding title/desc, at least 2 lines)
+	maxList := dm.height - 6
+	if maxList < 2 {
+		maxList = 2
+	}
+	start := 0
+	end := len(items)
+	if len(items) > maxList {
+		// Ensure highlighted item is visible
+		if dm.selectIndex < maxList/2 {
+			start = 0
+		} else if dm.selectIndex > len(items)-maxList/2 {
+			start = len(items) - maxList
+		} else {
+			start = dm.selectIndex - maxList/2
+		}
+		end = start + maxList
+		if end > len(items) {
+			end = len(items)
+		}
+	}
+	if start > 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("..."))
+	}
+	for i := start; i < end; i++ {
+		item := items[i]
+		cursor := "  "
+		if i == dm.selectIndex {
+			cursor = lipgloss.NewStyle().Foreground(lipgloss.Color("#3a86ff")).Render("→ ")
+		}
+		var checked string
+		if selected[item] {
+			checked = lipgloss.NewStyle().Foreground(lipgloss.Color("#fb5607")).Render("[x]")
+		} else {
+			checked = lipgloss.NewStyle().Faint(true).Render("[ ]")
+		}
+		itemStr := item
+		if i == dm.selectIndex {
+			itemStr = lipgloss.NewStyle().Bold(true).Render(item)
+		}
+		lines = append(lines, cursor+checked+" "+itemStr)
+	}
+	if end < len(items) {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("..."))
+	}
+	box := chartBoxStyle.Render(lipgloss.JoinVertical(lipgloss.Top, lines...))
+	boxW := lipgloss.Width(box)
+	boxH := lipgloss.Height(box)
+	dm.overlayBounds = overlayBounds{
+		kind:      kind,
+		x:         dm.width/2 - boxW/2,
+		y:         dm.height/2 - boxH/2,
+		w:         boxW,
+		h:         boxH,
+		listStart: start,
+		listEnd:   end,
+	}
+	return box
+}
+
+// handleSelectKeys handles key presses while a select overlay is open.
+func (dm *DirModel) handleSelectKeys(bk bindingKey) (tea.Cmd, bool) {
+	items, selected := dm.selectList(dm.selectKind())
+
+	switch bk {
+	case cancel:
+		return nil, false
+	case escape, toggleLangSelect, toggleOwnerSelect, quit:
+		// Cancel: revert any unconfirmed selections made this session.
+		if dm.mode == SELECT_OWNER {
+			dm.selectedOwners = copyLangSelection(dm.selectOwnersSnapshot)
+			dm.selectOwnersSnapshot = nil
+		} else {
+			dm.selectedLangs = copyLangSelection(dm.selectLangsSnapshot)
+			dm.selectLangsSnapshot = nil
+		}
+		dm.closeSelect()
+		return nil, true
+	case "up", "k":
+		if dm.selectIndex > 0 {
+			dm.selectIndex--
+		}
+		return nil, true
+	case "down", "j":
+		if dm.selectIndex < len(items)-1 {
+			dm.selectIndex++
+		}
+		return nil, true
+	case " ":
+		if len(items) > 0 && dm.selectIndex < len(items) {
+			item := items[dm.selectIndex]
+			selected[item] = !selected[item]
+		}
+		return nil, true
+	case enter:
+		// Confirm: keep current selections and clear the snapshot.
+		dm.selectLangsSnapshot = nil
+		dm.selectOwnersSnapshot = nil
+		dm.closeSelect()
+		return nil, true
+	default:
+		return nil, true
+	}
+}
+
+// closeSelect leaves the select overlay and applies the current selection.
+// A changed owner selection recomputes the stats overlay in updateTableData.
+func (dm *DirModel) closeSelect() {
+	dm.mode = READY
+	dm.selectMode = false
+	dm.updateTableData()
+}
+
+func (dm *DirModel) isInsideSelectBox(x, y int) bool {
+	return (dm.overlayBounds.kind == "langselect" || dm.overlayBounds.kind == "ownerselect") &&
+		dm.isInsideOverlay(x, y)
+}
+
+func (dm *DirModel) selectIndexAtY(y int) int {
+	b := dm.overlayBounds
+	if b.kind != "langselect" && b.kind != "ownerselect" {
+		return -1
+	}
+	items, _ := dm.selectList(b.kind)
+	// Content starts one cell below the top border.
+	contentY := y - b.y - 1
+	if contentY < 2 {
+		return -1 // title or description line
+	}
+	listY := contentY - 2
+	if b.listStart > 0 {
+		if listY == 0 {
+			return -1 // "..." scroll indicator
+		}
+		listY--
+	}
+	idx := b.listStart + listY
+	if idx < b.listStart || idx >= b.listEnd || idx >= len(items) {
+		return -1
+	}
+	return idx
+}
+
+// handleSelectMouse handles mouse events for the language and owner selection
+// overlays.
+func (dm *DirModel) handleSelectMouse(msg tea.MouseMsg) (tea.Cmd, bool) {
+	items, selected := dm.selectList(dm.selectKind())
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if dm.selectIndex > 0 {
+			dm.selectIndex--
+		}
+		return nil, true
+	case tea.MouseButtonWheelDown:
+		if dm.selectIndex < len(items)-1 {
+			dm.selectIndex++
+		}
+		return nil, true
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return nil, false
+		}
+		if !dm.isInsideSelectBox(msg.X, msg.Y) {
+			dm.closeSelect()
+			return nil, true
+		}
+		idx := dm.selectIndexAtY(msg.Y)
+		if idx >= 0 && idx < len(items) {
+			dm.selectIndex = idx
+			item := items[idx]
+			selected[item] = !selected[item]
+		}
+		return nil, true
+	}
+	return nil, false
+}
@@ -0,0 +1,173 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zdyxry/tokui/provider"
+	"github.com/zdyxry/tokui/structure"
+)
+
+// selectionMark prefixes marked rows and search results.
+const selectionMark = "✓"
+
+// selectionMaxListed caps the number of marked entries listed in the
+// selection panel.
+const selectionMaxListed = 8
+
+// toggleMark marks or unmarks e. Marks survive navigation, so sets spanning
+// several directories can be built up.
+func (dm *DirModel) toggleMark(e *structure.Entry) {
+	if e == nil {
+		return
+	}
+	if dm.marked == nil {
+		dm.marked = make(map[*structure.Entry]bool)
+	}
+	if dm.marked[e] {
+		delete(dm.marked, e)
+	} else {
+		dm.marked[e] = true
+	}
+	if len(dm.marked) == 0 {
+		dm.showSelection = false
+	}
+	dm.updateTableData()
+}
+
+// clearMarks unmarks all entries.
+func (dm *DirModel) clearMarks() {
+	dm.marked = nil
+	dm.showSelection = false
+	dm.updateTableData()
+}
+
+// toggleSelectionPanel shows or hides the selection statistics panel.
+func (dm *DirModel) toggleSelectionPanel() {
+	dm.showSelection = !dm.showSelection && len(dm.marked) > 0
+	if dm.showSelection {
+		dm.showCart = false
+	}
+}
+
+// isMarked reports whether e is marked.
+func (dm *DirModel) isMarked(e *structure.Entry) bool {
+	return dm.marked[e]
+}
+
+// markedRoots returns the marked entries that are not inside another marked
+// directory, sorted by path. Summing them counts every line once.
+func (dm *DirModel) markedRoots() []*structure.Entry {
+	roots := make([]*structure.Entry, 0, len(dm.marked))
+	for e := range dm.marked {
+		covered := false
+		for a := range dm.marked {
+			if a != e && isDescendant(a, e) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			roots = append(roots, e)
+		}
+	}
+	slices.SortFunc(roots, func(a, b *structure.Entry) int { return cmp.Compare(a.Path, b.Path) })
+	return roots
+}
+
+// isDescendant reports whether e lies below the directory dir.
+func isDescendant(dir, e *structure.Entry) bool {
+	if !dir.IsDir {
+		return false
+	}
+	rel, err := filepath.Rel(dir.Path, e.Path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// selectionStats sums the stats of the marked entries under the active
+// filters, along with the per-language split of their total lines.
+func (dm *DirModel) selectionStats() (structure.CodeStats, map[string]structure.CodeStats) {
+	var total structure.CodeStats
+	byLang := make(map[string]structure.CodeStats)
+	for _, e := range dm.markedRoots() {
+		total.Add(dm.comparableStats(e))
+		s := dm.statsEntry(e)
+		for _, lang := range dm.filteredLangs(s) {
+			ls := byLang[lang]
+			ls.Add(s.GetScopedStats(lang, dm.scope))
+			byLang[lang] = ls
+		}
+	}
+	return total, byLang
+}
+
+// statusSelectionLabel summarizes the selection for the status bar.
+func (dm *DirModel) statusSelectionLabel() string {
+	stats, _ := dm.selectionStats()
+	return fmt.Sprintf("%d · %s lines", len(dm.marked), formatNumber(stats.Total()))
+}
+
+// viewSelection renders the selection panel: the marked entries, their
+// combined stats and the language split of their lines.
+func (dm *DirModel) viewSelection() string {
+	stats, byLang := dm.selectionStats()
+	roots := dm.markedRoots()
+
+	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#3a86ff")).
+		Render(fmt.Sprintf("Selection · %d items", len(dm.marked)))
+	if nested := len(dm.marked) - len(roots); nested > 0 {
+		title += lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf(" (%d inside other selected directories)", nested))
+	}
+
+	width := dm.width / 2
+	lines := []string{title, ""}
+	for i, e := range roots {
+		if i == selectionMaxListed {
+			lines = append(lines, lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("… and %d more", len(roots)-i)))
+			break
+		}
+		name := dm.nav.tree.Root().Path
+		if rel, err := filepath.Rel(name, e.Path); err == nil {
+			name = rel
+		}
+		if e.IsDir {
+			name += "/"
+		}
+		lines = append(lines, fmtName(EntryIcon(e)+" "+name, width))
+	}
+
+	labelStyle := lipgloss.NewStyle().Faint(true)
+	summary := []string{
+		labelStyle.Render("Files ") + formatNumber(stats.Files),
+		labelStyle.Render("Code ") + formatNumber(stats.Code),
+		labelStyle.Render("Comments ") + formatNumber(stats.Comments),
+		labelStyle.Render("Blanks ") + formatNumber(stats.Blanks),
+		labelStyle.Render("Total ") + formatNumber(stats.Total()),
+	}
+	if dm.providerInfo.Capabilities&provider.CapComplexity != 0 {
+		summary = append(summary, labelStyle.Render("Complexity ")+formatNumber(stats.Complexity))
+	}
+	lines = append(lines, "", strings.Join(summary, "  "), "")
+
+	sectors := make([]RawChartSector, 0, len(byLang))
+	for lang, s := range byLang {
+		if s.Total() > 0 {
+			sectors = append(sectors, RawChartSector{Label: lang, Value: float64(s.Total())})
+		}
+	}
+	if body := BarChart(width, float64(stats.Total()), sectors, "lines"); body != "" {
+		lines = append(lines, body)
+	} else {
+		lines = append(lines, treemapEmptyStyle.Render(" (no lines under the current filters)"))
+	}
+	lines = append(lines, "", helpDescStyle.Render("v: mark/unmark  U: clear  V/Esc: close"))
+
+	return chartBoxStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
@@ -0,0 +1,145 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/zdyxry/tokui/structure"
+	"github.com/zdyxry/tokui/vcs"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// now is the clock used for ages; tests replace it.
+var now = time.Now
+
+// TimesLoaded reports the creation and last change times of the tree's files.
+type TimesLoaded struct {
+	Times map[string]structure.Times // keyed by entry path
+	Err   error
+}
+
+// EnableFileTimes offers file ages and last change times as sort keys and heat
+// metrics. They are loaded the first time one of them is selected, from git
+// history when available and from file modification times otherwise.
+func (dm *DirModel) EnableFileTimes() {
+	dm.timesEnabled = true
+}
+
+// timesAvailable reports whether the time metrics can be selected.
+func (dm *DirModel) timesAvailable() bool {
+	return dm.timesEnabled || dm.hasTimes
+}
+
+// timesWanted reports whether the table is sorted by, or the treemap colored
+// by, a time metric.
+func (dm *DirModel) timesWanted() bool {
+	isTime := func(k SortKey) bool { return k == SortByLastChanged || k == SortByAge }
+	return isTime(dm.sortState.Key) || dm.treemapColorMode == treemapColorHeat && isTime(dm.treemapHeatKey)
+}
+
+// timesCmd returns a command collecting the times of every file in the tree
+// once a time metric is first selected.
+func (dm *DirModel) timesCmd() tea.Cmd {
+	root := dm.nav.tree.Root()
+	if !dm.timesEnabled || dm.timesLoading || dm.hasTimes || root == nil || !dm.timesWanted() {
+		return nil
+	}
+	dm.timesLoading = true
+
+	// Collect paths here so the tree is only read from the update loop.
+	type file struct{ path, rel string }
+	var files []file
+	var walk func(e *structure.Entry)
+	walk = func(e *structure.Entry) {
+		for _, child := range e.Child {
+			if child.IsDir {
+				walk(child)
+				continue
+			}
+			rel, err := filepath.Rel(root.Path, child.Path)
+			if err != nil {
+				rel = child.Path
+			}
+			files = append(files, file{path: child.Path, rel: filepath.ToSlash(rel)})
+		}
+	}
+	walk(root)
+
+	dir := root.Path
+	return func() tea.Msg {
+		history, err := vcs.Times(dir)
+		if errors.Is(err, vcs.ErrNotRepository) || errors.Is(err, exec.ErrNotFound) {
+			err = nil
+		}
+		times := make(map[string]structure.Times, len(files))
+		for _, f := range files {
+			if h, ok := history[f.rel]; ok {
+				times[f.path] = structure.Times{Created: h.Created, LastChanged: h.LastChanged}
+				continue
+			}
+			// Untracked files and projects outside git fall back to mtime.
+			if info, statErr := os.Stat(f.path); statErr == nil {
+				times[f.path] = structure.Times{Created: info.ModTime(), LastChanged: info.ModTime()}
+			}
+		}
+		return TimesLoaded{Times: times, Err: err}
+	}
+}
+
+// applyTimes merges loaded times into the tree and adds the time columns.
+func (dm *DirModel) applyTimes(msg TimesLoaded) {
+	dm.timesLoading = false
+	if msg.Err != nil {
+		dm.err = fmt.Errorf("git history unavailable, using file modification times: %w", msg.Err)
+	}
+	dm.nav.tree.ApplyTimes(msg.Times)
+	if !dm.hasTimes {
+		dm.hasTimes = true
+		dm.columns = append(dm.columns,
+			Column{Title: "Last changed", SortKey: SortByLastChanged},
+			Column{Title: "Age", SortKey: SortByAge},
+		)
+	}
+	dm.applyStatsOverlay()
+	dm.updateTableData()
+}
+
+// sinceChange returns how long ago an entry last changed.
+func (dm *DirModel) sinceChange(e *structure.Entry) (time.Duration, bool) {
+	t := dm.statsEntry(e).Times.LastChanged
+	if t.IsZero() {
+		return 0, false
+	}
+	return now().Sub(t), true
+}
+
+// age returns how long ago an entry was created.
+func (dm *DirModel) age(e *structure.Entry) (time.Duration, bool) {
+	t := dm.statsEntry(e).Times.Created
+	if t.IsZero() {
+		return 0, false
+	}
+	return now().Sub(t), true
+}
+
+// durationHeat adapts a duration metric for a heat scale, measured in days.
+func durationHeat(metric func(*structure.Entry) (time.Duration, bool)) func(*structure.Entry) (float64, bool) {
+	return func(e *structure.Entry) (float64, bool) {
+		d, ok := metric(e)
+		return max(0, d.Hours()/24), ok
+	}
+}
+
+// timeKey converts a time into a sortable number, mapping the zero time below
+// all real ones.
+func timeKey(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
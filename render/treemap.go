@@ -69,6 +69,25 @@ var treemapColors = []lipgloss.Color{
 	"#16A085", // green sea
 }
 
+// treemapColorMode selects how treemap tiles are colored.
+type treemapColorMode int
+
+const (
+	treemapColorDir  treemapColorMode = iota // one hue per top-level tile
+	treemapColorLang                         // GitHub Linguist language colors
+	treemapColorHeat                         // sequential scale of a metric
+)
+
+// treemapColoring carries the coloring mode and, in heat mode, the function
+// that places an entry on the [0, 1] color scale along with the palette used
+// to render it. A negative heat value means the metric is not available for
+// the entry.
+type treemapColoring struct {
+	mode    treemapColorMode
+	heat    func(*structure.Entry) float64
+	palette []lipgloss.Color
+}
+
 // treemapSelectedBorder is used to outline the currently selected tile.
 var treemapSelectedBorder = lipgloss.Color("#ebbd34")
 
@@ -106,13 +125,19 @@ func entryPrimaryLang(e *structure.Entry) string {
 // In language-color mode, directories are shaded slightly darker than files so
 // that the container hierarchy remains visible even when all children share the
 // same language.
-func treemapColorFor(entry *structure.Entry, colorIdx int, colorByLang bool) lipgloss.Color {
-	if colorByLang {
+func treemapColorFor(entry *structure.Entry, colorIdx int, coloring treemapColoring) lipgloss.Color {
+	switch coloring.mode {
+	case treemapColorLang:
 		c := langColor(entryPrimaryLang(entry))
 		if entry != nil && entry.IsDir {
 			c = adjustColor(c, -0.12)
 		}
 		return c
+	case treemapColorHeat:
+		if entry == nil || coloring.heat == nil {
+			return heatUnknownColor
+		}
+		return heatColor(coloring.palette, coloring.heat(entry))
 	}
 	return treemapColors[colorIdx%len(treemapColors)]
 }
@@ -137,7 +162,7 @@ type treemapCell struct {
 //
 // It returns the rendered string and the list of layout blocks, which the
 // caller can use for keyboard/mouse selection.
-func Treemap(width, height int, children []*structure.Entry, getSize func(*structure.Entry) int64, selectedIdx int, colorByLang bool) (string, []treemapBlock) {
+func Treemap(width, height int, children []*structure.Entry, getSize func(*structure.Entry) int64, selectedIdx int, coloring treemapColoring) (string, []treemapBlock) {
 	if width <= 0 || height <= 0 {
 		return "", nil
 	}
@@ -190,7 +215,7 @@ func Treemap(width, height int, children []*structure.Entry, getSize func(*struc
 		}
 
 		label := buildLabel(it.entry, it.size)
-		color := treemapColorFor(it.entry, i, colorByLang)
+		color := treemapColorFor(it.entry, i, coloring)
 		topBlocks = append(topBlocks, treemapBlock{
 			entry:  it.entry,
 			rect:   r,
@@ -205,7 +230,7 @@ func Treemap(width, height int, children []*structure.Entry, getSize func(*struc
 	allBlocks := make([]treemapBlock, 0, len(topBlocks)*2)
 	for i := range topBlocks {
 		allBlocks = append(allBlocks, topBlocks[i])
-		buildNested(&allBlocks, len(allBlocks)-1, getSize, 1, colorByLang)
+		buildNested(&allBlocks, len(allBlocks)-1, getSize, 1, coloring)
 	}
 
 	// Draw the grid. Parents are drawn before children so child borders and
@@ -223,7 +248,7 @@ func Treemap(width, height int, children []*structure.Entry, getSize func(*struc
 		isDir := b.entry != nil && b.entry.IsDir
 		fillRect(grid, b.rect, b.color)
 		drawBorder(grid, b.rect, selected)
-		placeLabel(grid, b.rect, b.label, selected, isDir, colorByLang)
+		placeLabel(grid, b.rect, b.label, selected, isDir, coloring.mode)
 	}
 
 	// Convert grid to a styled string.
@@ -249,7 +274,7 @@ func Treemap(width, height int, children []*structure.Entry, getSize func(*struc
 
 // buildNested lays out children inside a directory block when there is enough
 // space, then recurses up to treemapMaxNestedDepth.
-func buildNested(allBlocks *[]treemapBlock, parentIdx int, getSize func(*structure.Entry) int64, level int, colorByLang bool) {
+func buildNested(allBlocks *[]treemapBlock, parentIdx int, getSize func(*structure.Entry) int64, level int, coloring treemapColoring) {
 	if level > treemapMaxNestedDepth {
 		return
 	}
@@ -304,8 +329,8 @@ func buildNested(allBlocks *[]treemapBlock, parentIdx int, getSize func(*structu
 
 		label := buildLabel(it.entry, it.size)
 		var color lipgloss.Color
-		if colorByLang {
-			color = treemapColorFor(it.entry, i, true)
+		if coloring.mode != treemapColorDir {
+			color = treemapColorFor(it.entry, i, coloring)
 		} else {
 			// Children inherit the parent's hue family so nested tiles feel cohesive.
 			// Each deeper level darkens slightly, and siblings alternate a tiny bit
@@ -327,7 +352,7 @@ func buildNested(allBlocks *[]treemapBlock, parentIdx int, getSize func(*structu
 	// grows during deeper recursion, so we must freeze the loop bound here.
 	endIdx := len(*allBlocks)
 	for i := startIdx; i < endIdx; i++ {
-		buildNested(allBlocks, i, getSize, level+1, colorByLang)
+		buildNested(allBlocks, i, getSize, level+1, coloring)
 	}
 }
 
@@ -539,7 +564,7 @@ func borderRune(x, left, right int, leftCorner, rightCorner, mid rune) rune {
 	return mid
 }
 
-func placeLabel(grid [][]treemapCell, r treemapRect, label string, selected, isDir, colorByLang bool) {
+func placeLabel(grid [][]treemapCell, r treemapRect, label string, selected, isDir bool, mode treemapColorMode) {
 	innerW := r.w - 2
 	innerH := r.h - 2
 	if innerW <= 0 || innerH <= 0 {
@@ -566,13 +591,13 @@ func placeLabel(grid [][]treemapCell, r treemapRect, label string, selected, isD
 	fg := lipgloss.Color("#262626")
 	if selected {
 		fg = lipgloss.Color("#FFFFFF")
-	} else if colorByLang {
+	} else if mode == treemapColorLang {
 		// Language colors vary in brightness; a light label gives more reliable
 		// contrast across the whole palette than the default dark gray.
 		fg = lipgloss.Color("#FFFFFF")
 	}
 
-	bold := selected || (colorByLang && isDir)
+	bold := selected || (mode != treemapColorDir && isDir)
 	for i, ch := range runes {
 		pos := x + i
 		if pos >= len(grid[y]) {
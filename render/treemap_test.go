@@ -16,7 +16,7 @@ func TestTreemapBasic(t *testing.T) {
 	}
 
 	getSize := func(e *structure.Entry) int64 { return e.TotalStats.Total() }
-	view, blocks := Treemap(40, 20, children, getSize, 0, false)
+	view, blocks := Treemap(40, 20, children, getSize, 0, treemapColoring{})
 
 	if view == "" {
 		t.Fatal("Treemap returned empty view")
@@ -37,7 +37,7 @@ func TestTreemapBasic(t *testing.T) {
 }
 
 func TestTreemapEmpty(t *testing.T) {
-	view, blocks := Treemap(40, 20, nil, func(e *structure.Entry) int64 { return 0 }, 0, false)
+	view, blocks := Treemap(40, 20, nil, func(e *structure.Entry) int64 { return 0 }, 0, treemapColoring{})
 	if view == "" {
 		t.Fatal("expected non-empty empty-state view")
 	}
@@ -85,7 +85,7 @@ func TestTreemapNestedBlocks(t *testing.T) {
 	children := []*structure.Entry{bigDir, smallDir}
 
 	getSize := func(e *structure.Entry) int64 { return e.TotalStats.Total() }
-	view, blocks := Treemap(60, 30, children, getSize, 0, false)
+	view, blocks := Treemap(60, 30, children, getSize, 0, treemapColoring{})
 
 	if view == "" {
 		t.Fatal("Treemap returned empty view")
@@ -163,7 +163,7 @@ func TestTreemapTopIdxContiguous(t *testing.T) {
 	children := []*structure.Entry{bigDir, smallDir}
 
 	getSize := func(e *structure.Entry) int64 { return e.TotalStats.Total() }
-	_, blocks := Treemap(60, 30, children, getSize, 0, false)
+	_, blocks := Treemap(60, 30, children, getSize, 0, treemapColoring{})
 
 	// topIdx values for top-level blocks must be contiguous and match their
 	// position among top-level blocks. This invariant lets keyboard navigation
@@ -204,7 +204,7 @@ func TestTreemapNestedPrealloc(t *testing.T) {
 
 	getSize := func(e *structure.Entry) int64 { return e.TotalStats.Total() }
 	// Use a large canvas so nesting is allowed.
-	view, blocks := Treemap(40, 20, []*structure.Entry{parent}, getSize, 0, false)
+	view, blocks := Treemap(40, 20, []*structure.Entry{parent}, getSize, 0, treemapColoring{})
 	if view == "" {
 		t.Fatal("Treemap returned empty view")
 	}
@@ -306,7 +306,7 @@ func TestTreemapLanguageColorMode(t *testing.T) {
 		return total
 	}
 
-	_, blocks := Treemap(60, 30, children, getSize, 0, true)
+	_, blocks := Treemap(60, 30, children, getSize, 0, treemapColoring{mode: treemapColorLang})
 
 	var goFileColor, goDirColor, jsColor lipgloss.Color
 	foundGoFile, foundGoDir, foundJS := false, false, false
@@ -392,14 +392,14 @@ func TestTreemapColorFor(t *testing.T) {
 	goFile := &structure.Entry{Path: "a.go", IsDir: false, StatsByLang: map[string]structure.CodeStats{"Go": {Code: 100}}}
 	goDir := &structure.Entry{Path: "pkg", IsDir: true, StatsByLang: map[string]structure.CodeStats{"Go": {Code: 100}}}
 
-	c1 := treemapColorFor(goFile, 0, false)
-	c2 := treemapColorFor(goFile, 1, false)
+	c1 := treemapColorFor(goFile, 0, treemapColoring{})
+	c2 := treemapColorFor(goFile, 1, treemapColoring{})
 	if c1 == c2 {
 		t.Fatal("expected different palette colors for different indices")
 	}
 
-	langColor1 := treemapColorFor(goFile, 0, true)
-	langColor2 := treemapColorFor(goDir, 0, true)
+	langColor1 := treemapColorFor(goFile, 0, treemapColoring{mode: treemapColorLang})
+	langColor2 := treemapColorFor(goDir, 0, treemapColoring{mode: treemapColorLang})
 	if langColor1 == langColor2 {
 		t.Fatal("expected directory tile to be shaded differently from file tile in language mode")
 	}
@@ -471,7 +471,7 @@ func TestTreemapLegendWidthMatchesTotal(t *testing.T) {
 		canvasW -= treemapLegendTotalWidth
 	}
 
-	view, blocks := Treemap(canvasW, 20, children, getSize, 0, true)
+	view, blocks := Treemap(canvasW, 20, children, getSize, 0, treemapColoring{mode: treemapColorLang})
 	if showLegend {
 		legend := buildTreemapLegend(blocks, 20, getSize)
 		combined := lipgloss.JoinHorizontal(lipgloss.Top, view, legend)
@@ -486,3 +486,52 @@ func TestTreemapLegendWidthMatchesTotal(t *testing.T) {
 		}
 	}
 }
+
+func TestTreemapColorForHeat(t *testing.T) {
+	file := &structure.Entry{Path: "a.go"}
+	coloring := func(v float64) treemapColoring {
+		return treemapColoring{mode: treemapColorHeat, heat: func(*structure.Entry) float64 { return v }, palette: sequentialPalette}
+	}
+	cold := treemapColorFor(file, 0, coloring(0))
+	hot := treemapColorFor(file, 0, coloring(1))
+	unknown := treemapColorFor(file, 0, coloring(-1))
+
+	if cold != sequentialPalette[0] {
+		t.Errorf("expected coolest palette color, got %v", cold)
+	}
+	if hot != sequentialPalette[len(sequentialPalette)-1] {
+		t.Errorf("expected hottest palette color, got %v", hot)
+	}
+	if unknown != heatUnknownColor {
+		t.Errorf("expected unknown color for missing metric, got %v", unknown)
+	}
+	if c := treemapColorFor(nil, 0, treemapColoring{mode: treemapColorHeat}); c != heatUnknownColor {
+		t.Errorf("expected unknown color for the synthetic other tile, got %v", c)
+	}
+}
+
+func TestHeatColorInterpolates(t *testing.T) {
+	mid := heatColor(divergingPalette, 0.125)
+	if mid == divergingPalette[0] || mid == divergingPalette[1] {
+		t.Fatalf("expected a blended color between the first two stops, got %v", mid)
+	}
+}
+
+func TestBuildHeatLegend(t *testing.T) {
+	scale := heatScale{palette: sequentialPalette, hot: "12d", cold: "0h"}
+	legend := buildHeatLegend("staleness", scale, 20)
+	if got := lipgloss.Width(legend); got != treemapLegendTotalWidth {
+		t.Errorf("unexpected legend width %d", got)
+	}
+	if got := lipgloss.Height(legend); got != 20 {
+		t.Errorf("expected legend height 20, got %d", got)
+	}
+	for _, want := range []string{"staleness", "12d", "0h", "n/a"} {
+		if !strings.Contains(legend, want) {
+			t.Errorf("expected legend to contain %q", want)
+		}
+	}
+	if buildHeatLegend("x", scale, 2) != "" {
+		t.Error("expected no legend when there is no room")
+	}
+}
@@ -0,0 +1,176 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// grepMaxFileSize skips files too large to preview.
+const grepMaxFileSize = 10 * 1024 * 1024
+
+// LineMatch is a line of a file that contains the query.
+type LineMatch struct {
+	Item   Item
+	Line   int    // 1-based line number
+	Text   string // the matching line
+	Start  int    // byte offset of the match in Text, or -1 if unknown
+	End    int    // byte offset just past the match
+	Before []string
+	After  []string
+}
+
+// FilesUnder returns the indexed files below the directory at the given path
+// relative to the root, where "." is the root itself.
+func (idx *Index) FilesUnder(dir string) []Item {
+	if idx == nil {
+		return nil
+	}
+	prefix := ""
+	if dir != "." && dir != "" {
+		prefix = strings.TrimSuffix(dir, "/") + "/"
+	}
+	var files []Item
+	for _, item := range idx.items {
+		if item.Entry != nil && !item.Entry.IsDir && strings.HasPrefix(item.Path, prefix) {
+			files = append(files, item)
+		}
+	}
+	return files
+}
+
+// Grep searches the contents of files for query, reading them in parallel.
+// The search is case-insensitive unless the query contains an upper-case
+// letter. Binary files, detected by a NUL byte like the file preview does,
+// and files over 10 MB are skipped. Each match carries up to contextLines
+// lines before and after it. Results follow the order of files and stop after
+// limit matches; a cancelled context ends the search early.
+func Grep(ctx context.Context, files []Item, query string, contextLines, limit int) []LineMatch {
+	if query == "" || len(files) == 0 || limit <= 0 {
+		return nil
+	}
+	foldCase := !strings.ContainsFunc(query, unicode.IsUpper)
+	if foldCase {
+		query = strings.ToLower(query)
+	}
+
+	results := make([][]LineMatch, len(files))
+	var found atomic.Int64
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				remaining := int64(limit) - found.Load()
+				if ctx.Err() != nil || remaining <= 0 {
+					continue
+				}
+				results[i] = grepFile(files[i], query, foldCase, contextLines, int(remaining))
+				found.Add(int64(len(results[i])))
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	var matches []LineMatch
+	for _, r := range results {
+		matches = append(matches, r...)
+		if len(matches) >= limit {
+			return matches[:limit]
+		}
+	}
+	return matches
+}
+
+// grepFile returns up to limit matches of query in one file. The file is read
+// line by line, keeping only the lines needed for context, and reading stops
+// once the last match has its context.
+func grepFile(item Item, query string, foldCase bool, contextLines, limit int) []LineMatch {
+	if info, err := os.Stat(item.Entry.Path); err != nil || info.Size() > grepMaxFileSize {
+		return nil
+	}
+	f, err := os.Open(item.Entry.Path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), grepMaxFileSize+1)
+	var (
+		matches []LineMatch
+		before  = make([]string, 0, contextLines) // ring of the previous lines
+		next    int                               // oldest slot of before once full
+		waiting []int                             // matches still collecting After
+	)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.IndexByte(line, 0) >= 0 {
+			return nil
+		}
+		for _, m := range waiting {
+			matches[m].After = append(matches[m].After, line)
+		}
+		waiting = slices.DeleteFunc(waiting, func(m int) bool { return len(matches[m].After) == contextLines })
+
+		if len(matches) < limit {
+			if m, ok := matchLine(line, query, foldCase); ok {
+				m.Item, m.Line = item, n
+				m.Before = append(slices.Clone(before[next:]), before[:next]...)
+				matches = append(matches, m)
+				if contextLines > 0 {
+					waiting = append(waiting, len(matches)-1)
+				}
+			}
+		}
+		if contextLines > 0 {
+			if len(before) < contextLines {
+				before = append(before, line)
+			} else {
+				before[next] = line
+				next = (next + 1) % contextLines
+			}
+		}
+		if len(matches) == limit && len(waiting) == 0 {
+			break
+		}
+	}
+	if scanner.Err() != nil {
+		return nil
+	}
+	return matches
+}
+
+// matchLine returns the match of query in line, if any, with its offsets.
+func matchLine(line, query string, foldCase bool) (LineMatch, bool) {
+	haystack := line
+	if foldCase {
+		haystack = strings.ToLower(line)
+	}
+	start := strings.Index(haystack, query)
+	if start < 0 {
+		return LineMatch{}, false
+	}
+	end := start + len(query)
+	if len(haystack) != len(line) {
+		// Lower-casing changed the byte length, so the offsets do not apply
+		// to the original line.
+		start, end = -1, -1
+	}
+	return LineMatch{Text: line, Start: start, End: end}, true
+}
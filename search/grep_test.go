@@ -0,0 +1,154 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+func buildGrepTree(t *testing.T) *Index {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":       "package main\n\nfunc main() {\n\t// serve forever\n\tServe()\n}\n",
+		"srv/server.go": "package srv\r\n\r\n// Serve starts the server.\r\nfunc Serve() {}\r\n",
+		"srv/blob.bin":  "Serve\x00\x01",
+	}
+	root := structure.NewDirEntry(dir)
+	srv := structure.NewDirEntry(filepath.Join(dir, "srv"))
+	root.AddChild(srv)
+	if err := os.Mkdir(srv.Path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		parent := root
+		if filepath.Dir(name) == "srv" {
+			parent = srv
+		}
+		parent.AddChild(structure.NewFileEntry(path, map[string]structure.CodeStats{"Go": {Code: 1}}))
+	}
+	return BuildIndex(root)
+}
+
+func TestFilesUnder(t *testing.T) {
+	idx := buildGrepTree(t)
+
+	if got := len(idx.FilesUnder(".")); got != 3 {
+		t.Errorf("expected 3 files under the root, got %d", got)
+	}
+	files := idx.FilesUnder("srv")
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files under srv, got %d", len(files))
+	}
+	for _, f := range files {
+		if filepath.Dir(f.Path) != "srv" {
+			t.Errorf("unexpected file %q under srv", f.Path)
+		}
+	}
+}
+
+func TestGrep_SmartCase(t *testing.T) {
+	idx := buildGrepTree(t)
+	files := idx.FilesUnder(".")
+
+	matches := Grep(context.Background(), files, "serve", 1, 100)
+	if len(matches) != 4 {
+		t.Fatalf("expected 4 case-insensitive matches, got %d", len(matches))
+	}
+	matches = Grep(context.Background(), files, "Serve", 1, 100)
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 case-sensitive matches, got %d", len(matches))
+	}
+	for _, m := range matches {
+		if m.Item.Path == "srv/blob.bin" {
+			t.Error("expected binary file to be skipped")
+		}
+	}
+}
+
+func TestGrep_LineAndContext(t *testing.T) {
+	idx := buildGrepTree(t)
+
+	matches := Grep(context.Background(), idx.FilesUnder("srv"), "func Serve", 1, 100)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	m := matches[0]
+	if m.Line != 4 || m.Text != "func Serve() {}" {
+		t.Errorf("unexpected match %d: %q", m.Line, m.Text)
+	}
+	if m.Text[m.Start:m.End] != "func Serve" {
+		t.Errorf("unexpected match offsets %d..%d", m.Start, m.End)
+	}
+	if len(m.Before) != 1 || m.Before[0] != "// Serve starts the server." {
+		t.Errorf("unexpected context before: %q", m.Before)
+	}
+	if len(m.After) != 0 {
+		t.Errorf("expected no context after the last line, got %q", m.After)
+	}
+}
+
+func TestGrep_FileLimitAndRingContext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	var content []byte
+	for i := range 10000 {
+		content = fmt.Appendf(content, "line %d error\n", i)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := structure.NewDirEntry(dir)
+	root.AddChild(structure.NewFileEntry(path, map[string]structure.CodeStats{"Text": {Code: 10000}}))
+	files := BuildIndex(root).FilesUnder(".")
+
+	matches := grepFile(files[0], "er", true, 2, 3)
+	if len(matches) != 3 {
+		t.Fatalf("expected the file scan to stop at 3 matches, got %d", len(matches))
+	}
+	m := matches[2]
+	if m.Line != 3 {
+		t.Errorf("expected third match on line 3, got %d", m.Line)
+	}
+	if want := []string{"line 0 error", "line 1 error"}; !slices.Equal(m.Before, want) {
+		t.Errorf("context before = %q, want %q", m.Before, want)
+	}
+	if want := []string{"line 3 error", "line 4 error"}; !slices.Equal(m.After, want) {
+		t.Errorf("context after = %q, want %q", m.After, want)
+	}
+	// Later matches see the ring after it has wrapped.
+	matches = grepFile(files[0], "line 7 ", true, 3, 1)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	if want := []string{"line 4 error", "line 5 error", "line 6 error"}; !slices.Equal(matches[0].Before, want) {
+		t.Errorf("context before = %q, want %q", matches[0].Before, want)
+	}
+	if got := len(Grep(context.Background(), files, "er", 0, 5)); got != 5 {
+		t.Errorf("expected 5 matches, got %d", got)
+	}
+}
+
+func TestGrep_LimitAndCancel(t *testing.T) {
+	idx := buildGrepTree(t)
+	files := idx.FilesUnder(".")
+
+	if got := len(Grep(context.Background(), files, "e", 0, 2)); got != 2 {
+		t.Errorf("expected results truncated to 2, got %d", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := Grep(ctx, files, "serve", 0, 100); got != nil {
+		t.Errorf("expected no results after cancel, got %d", len(got))
+	}
+}
@@ -0,0 +1,94 @@
+package search
+
+import (
+	"slices"
+	"strings"
+)
+
+// Kind restricts a query to files or directories.
+type Kind int
+
+const (
+	KindAny Kind = iota
+	KindDir
+	KindFile
+)
+
+// Query is a parsed search query: the text matched against paths plus the
+// filters given as prefixed terms.
+type Query struct {
+	Text  string   // fuzzy-matched text, without the prefixed terms
+	Kind  Kind     // "d:" directories only, "f:" files only
+	Langs []string // "lang:Go" or "lang:Go,Rust"; entries containing any of them
+	In    string   // "in:subdir"; the subtree relative to the root
+}
+
+// ParseQuery splits the prefixed terms off a query. The prefixes are d: and
+// f: (optionally followed by text, as in "f:main"), lang: and in:. Other terms
+// are kept as the query text.
+func ParseQuery(s string) Query {
+	var q Query
+	var text []string
+	for _, term := range strings.Fields(s) {
+		prefix, value, ok := strings.Cut(term, ":")
+		switch {
+		case ok && prefix == "d":
+			q.Kind = KindDir
+		case ok && prefix == "f":
+			q.Kind = KindFile
+		case ok && prefix == "lang":
+			for _, lang := range strings.Split(value, ",") {
+				if lang != "" {
+					q.Langs = append(q.Langs, lang)
+				}
+			}
+			continue
+		case ok && prefix == "in":
+			q.In = strings.Trim(strings.TrimPrefix(value, "./"), "/")
+			if q.In == "." {
+				q.In = ""
+			}
+			continue
+		default:
+			text = append(text, term)
+			continue
+		}
+		if value != "" {
+			text = append(text, value)
+		}
+	}
+	q.Text = strings.Join(text, " ")
+	return q
+}
+
+// HasFilters reports whether the query restricts the results beyond its text.
+func (q Query) HasFilters() bool {
+	return q.Kind != KindAny || len(q.Langs) > 0 || q.In != ""
+}
+
+// Matches reports whether item passes the filters of the query. It only reads
+// the item, not the tree, so it is safe to use off the update loop.
+func (q Query) Matches(item Item) bool {
+	if item.Entry == nil {
+		return false
+	}
+	switch q.Kind {
+	case KindDir:
+		if !item.Entry.IsDir {
+			return false
+		}
+	case KindFile:
+		if item.Entry.IsDir {
+			return false
+		}
+	}
+	if q.In != "" && item.Path != q.In && !strings.HasPrefix(item.Path, q.In+"/") {
+		return false
+	}
+	if len(q.Langs) > 0 && !slices.ContainsFunc(q.Langs, func(lang string) bool {
+		return slices.ContainsFunc(item.Langs, func(l string) bool { return strings.EqualFold(l, lang) })
+	}) {
+		return false
+	}
+	return true
+}
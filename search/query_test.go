@@ -0,0 +1,86 @@
+package search
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zdyxry/tokui/structure"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Query
+	}{
+		{"handler", Query{Text: "handler"}},
+		{"d: api", Query{Text: "api", Kind: KindDir}},
+		{"f:main", Query{Text: "main", Kind: KindFile}},
+		{"lang:Go,Rust lang:Python x", Query{Text: "x", Langs: []string{"Go", "Rust", "Python"}}},
+		{"in:./services/ readme", Query{Text: "readme", In: "services"}},
+		{"in:. foo bar", Query{Text: "foo bar"}},
+		{"http://host", Query{Text: "http://host"}},
+	}
+	for _, tt := range tests {
+		got := ParseQuery(tt.query)
+		if got.Text != tt.want.Text || got.Kind != tt.want.Kind || got.In != tt.want.In || !slices.Equal(got.Langs, tt.want.Langs) {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func buildMixedTree() *structure.Entry {
+	root := buildTestTree()
+	scripts := structure.NewDirEntry(filepath.Join("/project", "scripts"))
+	root.AddChild(scripts)
+	scripts.AddChild(structure.NewFileEntry(filepath.Join("/project", "scripts", "app.py"), map[string]structure.CodeStats{
+		"Python": {Code: 8},
+	}))
+	root.AggregateStats()
+	return root
+}
+
+func matchPaths(matches []Match) []string {
+	paths := make([]string, 0, len(matches))
+	for _, m := range matches {
+		paths = append(paths, m.Item.Path)
+	}
+	return paths
+}
+
+func TestFind_Filters(t *testing.T) {
+	idx := BuildIndex(buildMixedTree())
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"f:app", []string{"cmd/app.go", "scripts/app.py"}},
+		{"d:", []string{".", "cmd", "render", "scripts"}},
+		{"app lang:python", []string{"scripts/app.py"}},
+		{"lang:Python", []string{".", "scripts", "scripts/app.py"}},
+		{"in:cmd f:", []string{"cmd/app.go", "cmd/error.go"}},
+		{"in:cm go", nil},
+	}
+	for _, tt := range tests {
+		got := matchPaths(idx.Find(tt.query))
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Find(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFind_LangUsesIndexedLanguages(t *testing.T) {
+	root := buildMixedTree()
+	idx := BuildIndex(root)
+
+	// Aggregation replaces the stats maps of directories; the filter must not
+	// depend on them.
+	root.StatsByLang = nil
+	got := matchPaths(idx.Find("lang:python"))
+	slices.Sort(got)
+	if want := []string{".", "scripts", "scripts/app.py"}; !slices.Equal(got, want) {
+		t.Errorf("Find(lang:python) = %v, want %v", got, want)
+	}
+}
@@ -2,8 +2,14 @@
 package search
 
 import (
+	"cmp"
+	"context"
+	"math"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/sahilm/fuzzy"
 	"github.com/zdyxry/tokui/structure"
@@ -13,6 +19,10 @@ import (
 type Item struct {
 	Entry *structure.Entry
 	Path  string // path relative to the tree root, using '/' separators
+	// Langs lists the languages of the entry when it was indexed, so that
+	// queries never read the tree's stats maps, which the update loop
+	// rebuilds while stats load.
+	Langs []string
 }
 
 // Match represents a single fuzzy match result.
@@ -24,9 +34,13 @@ type Match struct {
 
 // Index holds a flat list of all searchable entries in a project tree.
 type Index struct {
+	root  *structure.Entry
 	items []Item
 }
 
+// findChunkSize is the number of items matched per unit of parallel work.
+const findChunkSize = 4096
+
 // itemSource adapts []Item to fuzzy.Source.
 type itemSource struct {
 	items []Item
@@ -43,74 +57,161 @@ func (s itemSource) Len() int {
 	return len(s.items)
 }
 
-// BuildIndex recursively walks the entry tree and creates a searchable index.
-// The returned paths are relative to root.Path and always use '/' separators.
+// BuildIndex walks the entry tree and creates a searchable index. The
+// returned paths are relative to root.Path and always use '/' separators.
+// They are joined from the entry names on the way down, so building costs a
+// single pass over the tree.
 func BuildIndex(root *structure.Entry) *Index {
-	if root == nil {
-		return &Index{}
-	}
+	b := NewIndexBuilder(root)
+	b.Step(math.MaxInt)
+	return b.Index()
+}
 
-	idx := &Index{}
-	idx.items = make([]Item, 0, 1024)
+// IndexBuilder builds an Index in steps, so that indexing a large tree can be
+// interleaved with other work instead of blocking it for the whole walk.
+type IndexBuilder struct {
+	idx   *Index
+	stack []Item // entries still to be indexed, the next one last
+}
 
-	rootPath := filepath.Clean(filepath.FromSlash(root.Path))
+// NewIndexBuilder starts building the index of root. Nothing is indexed until
+// Step is called.
+func NewIndexBuilder(root *structure.Entry) *IndexBuilder {
+	b := &IndexBuilder{idx: &Index{root: root, items: make([]Item, 0, 1024)}}
+	if root != nil {
+		b.stack = append(b.stack, Item{Entry: root, Path: "."})
+	}
+	return b
+}
 
-	var walk func(entry *structure.Entry)
-	walk = func(entry *structure.Entry) {
-		if entry == nil {
-			return
+// Step indexes up to n more entries, in the order BuildIndex would, and
+// reports whether the index is complete.
+func (b *IndexBuilder) Step(n int) bool {
+	for ; n > 0 && len(b.stack) > 0; n-- {
+		item := b.stack[len(b.stack)-1]
+		b.stack = b.stack[:len(b.stack)-1]
+		item.Langs = item.Entry.Languages()
+		b.idx.items = append(b.idx.items, item)
+		if !item.Entry.IsDir {
+			continue
 		}
-
-		relPath := "."
-		if entry != root {
-			entryPath := filepath.Clean(filepath.FromSlash(entry.Path))
-			r, err := filepath.Rel(rootPath, entryPath)
-			if err != nil {
-				r = entryPath
+		// Children are pushed in reverse so they are indexed in order.
+		for i := len(item.Entry.Child) - 1; i >= 0; i-- {
+			child := item.Entry.Child[i]
+			if child == nil {
+				continue
 			}
-			relPath = filepath.ToSlash(filepath.Clean(r))
-		}
-
-		idx.items = append(idx.items, Item{
-			Entry: entry,
-			Path:  relPath,
-		})
-
-		if entry.IsDir {
-			for _, child := range entry.Child {
-				if child == nil {
-					continue
-				}
-				walk(child)
+			childPath := filepath.Base(filepath.FromSlash(child.Path))
+			if item.Path != "." {
+				childPath = item.Path + "/" + childPath
 			}
+			b.stack = append(b.stack, Item{Entry: child, Path: childPath})
 		}
 	}
+	return len(b.stack) == 0
+}
+
+// Indexed returns the number of entries indexed so far.
+func (b *IndexBuilder) Indexed() int {
+	return len(b.idx.items)
+}
 
-	walk(root)
-	return idx
+// Index returns the index being built. It is complete once Step has reported
+// so.
+func (b *IndexBuilder) Index() *Index {
+	return b.idx
+}
+
+// Root returns the entry the index was built from.
+func (idx *Index) Root() *structure.Entry {
+	if idx == nil {
+		return nil
+	}
+	return idx.root
 }
 
-// Find performs a fuzzy search against the indexed paths.
-// Results are sorted from best to worst match.
+// Find performs a fuzzy search against the indexed paths, restricted by the
+// prefixed terms of the query (see ParseQuery). Results are sorted from best
+// to worst match. A query with filters but no text lists every entry passing
+// them, ordered by path.
 func (idx *Index) Find(query string) []Match {
+	return idx.FindTop(context.Background(), query, 0)
+}
+
+// FindTop is Find for large indexes: the items are matched in parallel chunks,
+// only the best limit matches are kept (all of them when limit <= 0), and a
+// cancelled context stops the search early and returns nil.
+// It only reads the index, never the tree, so it may run off the update loop
+// while the tree is re-aggregated.
+func (idx *Index) FindTop(ctx context.Context, query string, limit int) []Match {
 	if idx == nil || len(idx.items) == 0 {
 		return nil
 	}
-	query = strings.TrimSpace(query)
-	if query == "" {
+	q := ParseQuery(query)
+	if q.Text == "" && !q.HasFilters() {
 		return nil
 	}
 
-	src := itemSource{items: idx.items}
-	results := fuzzy.FindFrom(query, src)
+	items := idx.items
+	if q.HasFilters() {
+		items = make([]Item, 0, len(idx.items))
+		for _, item := range idx.items {
+			if q.Matches(item) {
+				items = append(items, item)
+			}
+		}
+	}
+	if q.Text == "" {
+		matches := make([]Match, 0, len(items))
+		for _, item := range items {
+			matches = append(matches, Match{Item: item})
+		}
+		slices.SortFunc(matches, func(a, b Match) int { return strings.Compare(a.Item.Path, b.Item.Path) })
+		return truncateMatches(matches, limit)
+	}
+
+	// Scores do not depend on the other items, so chunks can be matched
+	// independently and merged.
+	chunks := make([][]Match, (len(items)+findChunkSize-1)/findChunkSize)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.NumCPU(), len(chunks)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range next {
+				if ctx.Err() != nil {
+					continue
+				}
+				chunk := items[c*findChunkSize : min(len(items), (c+1)*findChunkSize)]
+				for _, r := range fuzzy.FindFromNoSort(q.Text, itemSource{items: chunk}) {
+					chunks[c] = append(chunks[c], Match{
+						Item:           chunk[r.Index],
+						Score:          r.Score,
+						MatchedIndexes: r.MatchedIndexes,
+					})
+				}
+			}
+		}()
+	}
+	for c := range chunks {
+		next <- c
+	}
+	close(next)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	matches := slices.Concat(chunks...)
+	slices.SortStableFunc(matches, func(a, b Match) int { return cmp.Compare(b.Score, a.Score) })
+	return truncateMatches(matches, limit)
+}
 
-	matches := make([]Match, 0, len(results))
-	for _, r := range results {
-		matches = append(matches, Match{
-			Item:           idx.items[r.Index],
-			Score:          r.Score,
-			MatchedIndexes: r.MatchedIndexes,
-		})
+// truncateMatches keeps the first limit matches, or all when limit <= 0.
+func truncateMatches(matches []Match, limit int) []Match {
+	if limit > 0 && len(matches) > limit {
+		return matches[:limit]
 	}
 	return matches
 }
@@ -1,9 +1,13 @@
 package search
 
 import (
+	"context"
+	"fmt"
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/sahilm/fuzzy"
 	"github.com/zdyxry/tokui/structure"
 )
 
@@ -49,6 +53,29 @@ func TestBuildIndex(t *testing.T) {
 	}
 }
 
+func TestIndexBuilder_Steps(t *testing.T) {
+	root := buildWideTree(100)
+	want := BuildIndex(root)
+
+	b := NewIndexBuilder(root)
+	steps := 0
+	for !b.Step(7) {
+		steps++
+		if b.Indexed() != 7*steps {
+			t.Fatalf("step %d: indexed %d entries, want %d", steps, b.Indexed(), 7*steps)
+		}
+	}
+	if steps == 0 {
+		t.Fatal("expected the build to take several steps")
+	}
+	if !reflect.DeepEqual(b.Index().items, want.items) {
+		t.Error("stepwise index differs from BuildIndex")
+	}
+	if b.Index().Root() != root {
+		t.Error("expected the index root to be the tree root")
+	}
+}
+
 func TestFind_Exact(t *testing.T) {
 	root := buildTestTree()
 	idx := BuildIndex(root)
@@ -200,3 +227,94 @@ func TestFind_FuzzyMultipleResults(t *testing.T) {
 		}
 	}
 }
+
+func buildWideTree(n int) *structure.Entry {
+	root := structure.NewDirEntry("/project")
+	for d := range 10 {
+		dir := structure.NewDirEntry(filepath.Join("/project", fmt.Sprintf("pkg%d", d)))
+		root.AddChild(dir)
+		for f := range n / 10 {
+			dir.AddChild(structure.NewFileEntry(filepath.Join(dir.Path, fmt.Sprintf("file_%d.go", f)), map[string]structure.CodeStats{
+				"Go": {Code: 1},
+			}))
+		}
+	}
+	return root
+}
+
+func TestFindTop_MatchesFuzzyAcrossChunks(t *testing.T) {
+	idx := BuildIndex(buildWideTree(3 * findChunkSize))
+	const query = "pkg3/file_12"
+
+	// The reference scores every item in one pass, without chunks.
+	want := make(map[string]int)
+	for _, r := range fuzzy.FindFrom(query, itemSource{items: idx.items}) {
+		want[idx.items[r.Index].Path] = r.Score
+	}
+	all := idx.FindTop(context.Background(), query, 0)
+	if len(all) != len(want) {
+		t.Fatalf("expected %d matches, got %d", len(want), len(all))
+	}
+	for i, m := range all {
+		if score, ok := want[m.Item.Path]; !ok || score != m.Score {
+			t.Errorf("match %q: score %d, want %d (found %v)", m.Item.Path, m.Score, score, ok)
+		}
+		if i > 0 && m.Score > all[i-1].Score {
+			t.Errorf("match %d scores higher than the one before it", i)
+		}
+	}
+
+	top := idx.FindTop(context.Background(), query, 5)
+	if len(all) <= 5 || len(top) != 5 {
+		t.Fatalf("expected more than 5 matches capped to 5, got %d and %d", len(all), len(top))
+	}
+	if top[0].Item.Path != "pkg3/file_12.go" {
+		t.Errorf("expected best match pkg3/file_12.go, got %q", top[0].Item.Path)
+	}
+	for i := range top {
+		if top[i].Score != all[i].Score {
+			t.Errorf("match %d: score %d, want %d", i, top[i].Score, all[i].Score)
+		}
+	}
+}
+
+func TestFindTop_ConcurrentWithAggregation(t *testing.T) {
+	root := buildWideTree(2 * findChunkSize)
+	idx := BuildIndex(root)
+
+	// Loading churn, times or blame data re-aggregates the tree on the update
+	// loop while a background search may be running; run with -race.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			root.AggregateStats()
+		}
+	}()
+	for range 20 {
+		if got := idx.FindTop(context.Background(), "lang:Go file_1", 100); len(got) == 0 {
+			t.Fatal("expected matches")
+		}
+	}
+	<-done
+}
+
+func TestFindTop_Cancelled(t *testing.T) {
+	idx := BuildIndex(buildWideTree(1000))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := idx.FindTop(ctx, "file", 10); got != nil {
+		t.Errorf("expected nil after cancel, got %d matches", len(got))
+	}
+}
+
+func TestBuildIndex_Root(t *testing.T) {
+	root := buildTestTree()
+	if got := BuildIndex(root).Root(); got != root {
+		t.Errorf("expected index root %v, got %v", root, got)
+	}
+	var idx *Index
+	if idx.Root() != nil {
+		t.Error("expected nil root for nil index")
+	}
+}
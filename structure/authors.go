@@ -0,0 +1,85 @@
+package structure
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// AuthorLines is the number of surviving lines attributed to one author.
+type AuthorLines struct {
+	Author string
+	Lines  int64
+}
+
+// Authors returns the entry's authors ordered by the number of lines they
+// wrote, largest first. It is empty until blame data has been applied.
+func (e *Entry) Authors() []AuthorLines {
+	authors := make([]AuthorLines, 0, len(e.LinesByAuthor))
+	for author, lines := range e.LinesByAuthor {
+		authors = append(authors, AuthorLines{Author: author, Lines: lines})
+	}
+	slices.SortFunc(authors, func(a, b AuthorLines) int {
+		if c := cmp.Compare(b.Lines, a.Lines); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Author, b.Author)
+	})
+	return authors
+}
+
+// ApplyAuthors sets the per-author line counts of the files below dir from
+// byPath, keyed by entry path, and re-aggregates dir's subtree and every
+// directory between it and root. Files missing from byPath keep their
+// previous data.
+func ApplyAuthors(root, dir *Entry, byPath map[string]map[string]int64) {
+	if root == nil || dir == nil {
+		return
+	}
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		if !e.IsDir {
+			if lines, ok := byPath[e.Path]; ok {
+				e.LinesByAuthor = lines
+			}
+			return
+		}
+		for _, child := range e.Child {
+			walk(child)
+		}
+	}
+	walk(dir)
+	dir.AggregateStats()
+
+	ancestors := ancestorsOf(root, dir)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		ancestors[i].aggregateChildren()
+	}
+}
+
+// ancestorsOf returns the directories from root down to dir's parent, or nil
+// when dir is not below root.
+func ancestorsOf(root, dir *Entry) []*Entry {
+	rel, err := filepath.Rel(root.Path, dir.Path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	ancestors := make([]*Entry, 0, len(parts))
+	e := root
+	for _, name := range parts[:len(parts)-1] {
+		ancestors = append(ancestors, e)
+		if e = e.GetChild(name); e == nil {
+			return nil
+		}
+	}
+	return append(ancestors, e)
+}
+
+// addAuthorLines merges the per-author line counts in src into dst.
+func addAuthorLines(dst, src map[string]int64) {
+	for author, lines := range src {
+		dst[author] += lines
+	}
+}
@@ -0,0 +1,44 @@
+package structure
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyAuthors(t *testing.T) {
+	root := NewDirEntry("root")
+	pkg := NewDirEntry("root/pkg")
+	pkg.AddChild(NewFileEntry("root/pkg/a.go", map[string]CodeStats{"Go": {Code: 10}}))
+	pkg.AddChild(NewFileEntry("root/pkg/b.go", map[string]CodeStats{"Go": {Code: 5}}))
+	root.AddChild(pkg)
+	root.AddChild(NewFileEntry("root/untracked.go", map[string]CodeStats{"Go": {Code: 1}}))
+	root.AggregateStats()
+
+	sub := NewDirEntry("root/pkg/sub")
+	sub.AddChild(NewFileEntry("root/pkg/sub/c.go", map[string]CodeStats{"Go": {Code: 3}}))
+	pkg.AddChild(sub)
+	root.AggregateStats()
+
+	ApplyAuthors(root, sub, map[string]map[string]int64{
+		"root/pkg/sub/c.go": {"bob": 3},
+	})
+	if got := root.LinesByAuthor["bob"]; got != 3 {
+		t.Errorf("root bob lines after blaming sub = %d, want 3", got)
+	}
+
+	ApplyAuthors(root, pkg, map[string]map[string]int64{
+		"root/pkg/a.go": {"alice": 8, "bob": 2},
+		"root/pkg/b.go": {"bob": 5},
+	})
+
+	want := []AuthorLines{{Author: "bob", Lines: 10}, {Author: "alice", Lines: 8}}
+	if got := root.Authors(); !reflect.DeepEqual(got, want) {
+		t.Errorf("root authors = %v, want %v", got, want)
+	}
+	if got := pkg.LinesByAuthor["bob"]; got != 10 {
+		t.Errorf("pkg bob lines = %d, want 10", got)
+	}
+	if got := root.GetChild("untracked.go").LinesByAuthor; got != nil {
+		t.Errorf("untracked file authors = %v, want nil", got)
+	}
+}
@@ -0,0 +1,62 @@
+package structure
+
+import "path/filepath"
+
+// Churn summarizes the version history of an entry over the analysis window.
+// Directory values are the sums of their files.
+type Churn struct {
+	Commits int64 // commits touching the file; for directories, file changes
+	Added   int64
+	Removed int64
+
+	// Hotspot scores weight change frequency by size: commits multiplied by
+	// lines of code, or by cyclomatic complexity. They are computed per file
+	// and summed for directories.
+	CodeHotspot       int64
+	ComplexityHotspot int64
+}
+
+// Lines returns the number of lines added and removed.
+func (c Churn) Lines() int64 {
+	return c.Added + c.Removed
+}
+
+// Add accumulates another Churn into c.
+func (c *Churn) Add(other Churn) {
+	c.Commits += other.Commits
+	c.Added += other.Added
+	c.Removed += other.Removed
+	c.CodeHotspot += other.CodeHotspot
+	c.ComplexityHotspot += other.ComplexityHotspot
+}
+
+// ApplyChurn sets the churn of every file in the tree from byRelPath, keyed by
+// slash-separated paths relative to the tree root, computes the hotspot
+// scores and re-aggregates directory statistics. Files missing from
+// byRelPath did not change in the window.
+func (t *Tree) ApplyChurn(byRelPath map[string]Churn) {
+	if t.root == nil {
+		return
+	}
+	rootPath := t.root.Path
+
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		for _, child := range e.Child {
+			if child.IsDir {
+				walk(child)
+				continue
+			}
+			rel, err := filepath.Rel(rootPath, child.Path)
+			if err != nil {
+				rel = child.Path
+			}
+			c := byRelPath[filepath.ToSlash(rel)]
+			c.CodeHotspot = c.Commits * child.TotalStats.Code
+			c.ComplexityHotspot = c.Commits * child.TotalStats.Complexity
+			child.Churn = c
+		}
+	}
+	walk(t.root)
+	t.root.AggregateStats()
+}
@@ -0,0 +1,30 @@
+package structure
+
+import "testing"
+
+func TestTreeApplyChurn(t *testing.T) {
+	root := NewDirEntry("root")
+	pkg := NewDirEntry("root/pkg")
+	pkg.AddChild(NewFileEntry("root/pkg/a.go", map[string]CodeStats{"Go": {Code: 10, Complexity: 4}}))
+	pkg.AddChild(NewFileEntry("root/pkg/b.go", map[string]CodeStats{"Go": {Code: 5, Complexity: 1}}))
+	root.AddChild(pkg)
+	root.AggregateStats()
+
+	tree := NewTree(root)
+	tree.ApplyChurn(map[string]Churn{
+		"pkg/a.go": {Commits: 3, Added: 20, Removed: 5},
+		"pkg/b.go": {Commits: 1, Added: 5},
+	})
+
+	a := pkg.GetChild("a.go")
+	if a.Churn.CodeHotspot != 30 || a.Churn.ComplexityHotspot != 12 {
+		t.Errorf("a.go hotspots = %d/%d, want 30/12", a.Churn.CodeHotspot, a.Churn.ComplexityHotspot)
+	}
+	want := Churn{Commits: 4, Added: 25, Removed: 5, CodeHotspot: 35, ComplexityHotspot: 13}
+	if root.Churn != want {
+		t.Errorf("root churn = %+v, want %+v", root.Churn, want)
+	}
+	if got := root.Churn.Lines(); got != 30 {
+		t.Errorf("root churn lines = %d, want 30", got)
+	}
+}
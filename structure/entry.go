@@ -16,22 +16,58 @@ type CodeStats struct {
 	Blanks        int64
 	Complexity    int64
 	MaxComplexity int64
+	// Files counts the files the stats were collected from. A file counts
+	// once in its total and once for every language it contains.
+	Files int64
 }
 
 func (cs CodeStats) Total() int64 {
 	return cs.Code + cs.Comments + cs.Blanks
 }
 
+// Sub returns the stats remaining after removing other. MaxComplexity cannot
+// be subtracted and is cleared.
+func (cs CodeStats) Sub(other CodeStats) CodeStats {
+	cs.Code -= other.Code
+	cs.Comments -= other.Comments
+	cs.Blanks -= other.Blanks
+	cs.Complexity -= other.Complexity
+	cs.Files -= other.Files
+	cs.MaxComplexity = 0
+	return cs
+}
+
 func (cs *CodeStats) Add(other CodeStats) {
 	cs.Code += other.Code
 	cs.Comments += other.Comments
 	cs.Blanks += other.Blanks
 	cs.Complexity += other.Complexity
+	cs.Files += other.Files
 	if other.MaxComplexity > cs.MaxComplexity {
 		cs.MaxComplexity = other.MaxComplexity
 	}
 }
 
+// Scope selects which part of the code base stats are reported for.
+type Scope int
+
+const (
+	ScopeAll Scope = iota
+	ScopeProduction
+	ScopeTest
+)
+
+func (s Scope) String() string {
+	switch s {
+	case ScopeProduction:
+		return "prod"
+	case ScopeTest:
+		return "test"
+	default:
+		return "all"
+	}
+}
+
 type Entry struct {
 	Path        string
 	Child       []*Entry
@@ -40,6 +76,40 @@ type Entry struct {
 	StatsByLang map[string]CodeStats
 	TotalStats  CodeStats
 	Expanded    bool
+
+	// IsTest marks a file as test code. TestStats and TestStatsByLang hold the
+	// test portion of the entry's stats; for directories they aggregate all
+	// test files below it.
+	IsTest          bool
+	TestStats       CodeStats
+	TestStatsByLang map[string]CodeStats
+	// prodMaxComplexity and prodMaxComplexityByLang hold the highest
+	// complexity of a production file below a directory, which cannot be
+	// derived from the total and test stats.
+	prodMaxComplexity       int64
+	prodMaxComplexityByLang map[string]int64
+
+	// Owners lists the code owners of a file. StatsByOwner attributes the
+	// entry's stats to each owner; co-owned files count fully for every owner.
+	Owners       []string
+	StatsByOwner map[string]CodeStats
+
+	// LinesByAuthor counts the surviving lines per git blame author. It is nil
+	// until blame data has been loaded for the entry.
+	LinesByAuthor map[string]int64
+
+	// Churn holds change frequency and hotspot scores from version history.
+	Churn Churn
+	// Times holds creation and last change times from version history or the
+	// file system.
+	Times Times
+
+	// childByName indexes Child by name for GetChild. It is built on the
+	// first lookup and kept up to date by AddChild; indexedChildren counts the
+	// children it covers, so children appended to Child directly make it
+	// rebuild on the next lookup.
+	childByName     map[string]*Entry
+	indexedChildren int
 }
 
 func NewDirEntry(path string) *Entry {
@@ -51,19 +121,43 @@ func NewDirEntry(path string) *Entry {
 	}
 }
 
+// NewFileEntry creates a file entry with the given per-language stats. The
+// map is copied, as every language counts the file once.
 func NewFileEntry(path string, stats map[string]CodeStats) *Entry {
 	e := &Entry{
 		Path:        path,
-		StatsByLang: stats,
+		StatsByLang: make(map[string]CodeStats, len(stats)),
 		IsDir:       false,
 	}
 	// Calculate file totals
-	for _, s := range stats {
+	for lang, s := range stats {
+		s.Files = 1
+		e.StatsByLang[lang] = s
 		e.TotalStats.Add(s)
 	}
+	e.TotalStats.Files = 1
 	return e
 }
 
+// SetTest marks a file entry as test or production code and updates its test
+// stats accordingly.
+func (e *Entry) SetTest(isTest bool) {
+	if e.IsDir {
+		return
+	}
+	e.IsTest = isTest
+	e.TestStats = CodeStats{}
+	e.TestStatsByLang = nil
+	if !isTest {
+		return
+	}
+	e.TestStats = e.TotalStats
+	e.TestStatsByLang = make(map[string]CodeStats, len(e.StatsByLang))
+	for lang, stats := range e.StatsByLang {
+		e.TestStatsByLang[lang] = stats
+	}
+}
+
 func (e *Entry) Name() string {
 	return filepath.Base(e.Path)
 }
@@ -73,16 +167,39 @@ func (e *Entry) Ext() string {
 	return strings.ToLower(strings.TrimPrefix(filepath.Ext(e.Name()), "."))
 }
 
+// GetChild returns the child with the given name, or nil. If several children
+// share a name, the first one added is returned.
 func (e *Entry) GetChild(name string) *Entry {
 	e.mx.RLock()
-	defer e.mx.RUnlock()
+	if len(e.Child) == 0 {
+		e.mx.RUnlock()
+		return nil
+	}
+	if e.childIndexed() {
+		child := e.childByName[name]
+		e.mx.RUnlock()
+		return child
+	}
+	e.mx.RUnlock()
 
-	for _, child := range e.Child {
-		if child.Name() == name {
-			return child
+	e.mx.Lock()
+	defer e.mx.Unlock()
+	if !e.childIndexed() {
+		e.childByName = make(map[string]*Entry, len(e.Child))
+		for _, child := range e.Child {
+			if _, ok := e.childByName[child.Name()]; !ok {
+				e.childByName[child.Name()] = child
+			}
 		}
+		e.indexedChildren = len(e.Child)
 	}
-	return nil
+	return e.childByName[name]
+}
+
+// childIndexed reports whether childByName covers every child. The caller
+// must hold e.mx.
+func (e *Entry) childIndexed() bool {
+	return e.childByName != nil && e.indexedChildren == len(e.Child)
 }
 
 func (e *Entry) AddChild(child *Entry) {
@@ -92,7 +209,14 @@ func (e *Entry) AddChild(child *Entry) {
 	if e.Child == nil {
 		e.Child = make([]*Entry, 0, 10)
 	}
+	indexed := e.childIndexed()
 	e.Child = append(e.Child, child)
+	if indexed {
+		if _, ok := e.childByName[child.Name()]; !ok {
+			e.childByName[child.Name()] = child
+		}
+		e.indexedChildren++
+	}
 }
 
 func (e *Entry) HasChild() bool {
@@ -119,6 +243,54 @@ func (e *Entry) GetStats(langFilter string) CodeStats {
 	return e.StatsByLang[langFilter]
 }
 
+// GetScopedStats returns the stats for the given language filter restricted to
+// test code, production code or both.
+func (e *Entry) GetScopedStats(langFilter string, scope Scope) CodeStats {
+	all := e.GetStats(langFilter)
+	if scope == ScopeAll {
+		return all
+	}
+	test := e.TestStats
+	if langFilter != "" && langFilter != "All" {
+		test = e.TestStatsByLang[langFilter]
+	}
+	if scope == ScopeTest {
+		return test
+	}
+	prod := all.Sub(test)
+	prod.MaxComplexity = e.productionMaxComplexity(langFilter)
+	return prod
+}
+
+// productionMaxComplexity returns the highest complexity of a production file
+// in the entry, for the given language filter.
+func (e *Entry) productionMaxComplexity(langFilter string) int64 {
+	if !e.IsDir {
+		if e.IsTest {
+			return 0
+		}
+		return e.GetStats(langFilter).MaxComplexity
+	}
+	if langFilter == "" || langFilter == "All" {
+		return e.prodMaxComplexity
+	}
+	return e.prodMaxComplexityByLang[langFilter]
+}
+
+// addProdMaxComplexity raises the production maximum complexity of the
+// directory e to that of child.
+func (e *Entry) addProdMaxComplexity(child *Entry) {
+	e.prodMaxComplexity = max(e.prodMaxComplexity, child.productionMaxComplexity(""))
+	for lang := range child.StatsByLang {
+		if m := child.productionMaxComplexity(lang); m > e.prodMaxComplexityByLang[lang] {
+			if e.prodMaxComplexityByLang == nil {
+				e.prodMaxComplexityByLang = make(map[string]int64)
+			}
+			e.prodMaxComplexityByLang[lang] = m
+		}
+	}
+}
+
 func (e *Entry) Languages() []string {
 	if e.StatsByLang == nil {
 		return nil
@@ -137,19 +309,55 @@ func (e *Entry) AggregateStats() {
 		return
 	}
 
-	e.TotalStats = CodeStats{}
-	e.StatsByLang = make(map[string]CodeStats)
-
 	for _, child := range e.Child {
 		if child.IsDir {
 			child.AggregateStats()
 		}
+	}
+	e.aggregateChildren()
+}
+
+// aggregateChildren recomputes a directory's statistics from its immediate
+// children without descending into them.
+func (e *Entry) aggregateChildren() {
+	e.TotalStats = CodeStats{}
+	e.StatsByLang = make(map[string]CodeStats)
+	e.TestStats = CodeStats{}
+	e.TestStatsByLang = make(map[string]CodeStats)
+	e.prodMaxComplexity = 0
+	e.prodMaxComplexityByLang = nil
+	e.StatsByOwner = nil
+	e.LinesByAuthor = nil
+	e.Churn = Churn{}
 
+	for _, child := range e.Child {
 		e.TotalStats.Add(child.TotalStats)
-		for lang, stats := range child.StatsByLang {
-			currentLangStats := e.StatsByLang[lang]
-			currentLangStats.Add(stats)
-			e.StatsByLang[lang] = currentLangStats
+		e.Churn.Add(child.Churn)
+		addLangStats(e.StatsByLang, child.StatsByLang)
+		e.TestStats.Add(child.TestStats)
+		addLangStats(e.TestStatsByLang, child.TestStatsByLang)
+		e.addProdMaxComplexity(child)
+		if len(child.StatsByOwner) > 0 {
+			if e.StatsByOwner == nil {
+				e.StatsByOwner = make(map[string]CodeStats)
+			}
+			addLangStats(e.StatsByOwner, child.StatsByOwner)
+		}
+		if child.LinesByAuthor != nil {
+			if e.LinesByAuthor == nil {
+				e.LinesByAuthor = make(map[string]int64)
+			}
+			addAuthorLines(e.LinesByAuthor, child.LinesByAuthor)
 		}
 	}
+	e.Times = aggregateTimes(e.Child)
+}
+
+// addLangStats merges keyed stats (per language or per owner) in src into dst.
+func addLangStats(dst, src map[string]CodeStats) {
+	for lang, stats := range src {
+		current := dst[lang]
+		current.Add(stats)
+		dst[lang] = current
+	}
 }
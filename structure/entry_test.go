@@ -2,6 +2,7 @@ package structure
 
 import (
 	"cmp"
+	"fmt"
 	"testing"
 )
 
@@ -92,10 +93,16 @@ func TestNewFileEntry(t *testing.T) {
 	if e.IsDir {
 		t.Error("expected IsDir false")
 	}
-	want := CodeStats{Code: 10, Comments: 2, Blanks: 1}
+	want := CodeStats{Code: 10, Comments: 2, Blanks: 1, Files: 1}
 	if e.TotalStats != want {
 		t.Errorf("expected total stats %+v, got %+v", want, e.TotalStats)
 	}
+	if e.StatsByLang["Go"] != want {
+		t.Errorf("expected Go stats %+v, got %+v", want, e.StatsByLang["Go"])
+	}
+	if stats["Go"].Files != 0 {
+		t.Error("expected the caller's stats map to be left unchanged")
+	}
 }
 
 func TestEntryName(t *testing.T) {
@@ -206,7 +213,7 @@ func TestEntryGetStats(t *testing.T) {
 	if got := e.GetStats("All"); got != e.TotalStats {
 		t.Errorf("All filter should return total stats, got %+v", got)
 	}
-	wantGo := CodeStats{Code: 10, Comments: 2, Blanks: 1}
+	wantGo := CodeStats{Code: 10, Comments: 2, Blanks: 1, Files: 1}
 	if got := e.GetStats("Go"); got != wantGo {
 		t.Errorf("Go filter mismatch, got %+v, want %+v", got, wantGo)
 	}
@@ -247,21 +254,138 @@ func TestEntryAggregateStats(t *testing.T) {
 
 	root.AggregateStats()
 
-	wantTotal := CodeStats{Code: 35, Comments: 4, Blanks: 4}
+	wantTotal := CodeStats{Code: 35, Comments: 4, Blanks: 4, Files: 3}
 	if root.TotalStats != wantTotal {
 		t.Errorf("root total stats mismatch, got %+v, want %+v", root.TotalStats, wantTotal)
 	}
-	wantGo := CodeStats{Code: 30, Comments: 3, Blanks: 3}
+	wantGo := CodeStats{Code: 30, Comments: 3, Blanks: 3, Files: 2}
 	if root.StatsByLang["Go"] != wantGo {
 		t.Errorf("root Go stats mismatch, got %+v, want %+v", root.StatsByLang["Go"], wantGo)
 	}
-	wantPython := CodeStats{Code: 5, Comments: 1, Blanks: 1}
+	wantPython := CodeStats{Code: 5, Comments: 1, Blanks: 1, Files: 1}
 	if root.StatsByLang["Python"] != wantPython {
 		t.Errorf("root Python stats mismatch, got %+v, want %+v", root.StatsByLang["Python"], wantPython)
 	}
 
-	wantSubTotal := CodeStats{Code: 15, Comments: 2, Blanks: 2}
+	wantSubTotal := CodeStats{Code: 15, Comments: 2, Blanks: 2, Files: 2}
 	if sub.TotalStats != wantSubTotal {
 		t.Errorf("sub total stats mismatch, got %+v, want %+v", sub.TotalStats, wantSubTotal)
 	}
 }
+
+func TestEntryScopedStats(t *testing.T) {
+	root := NewDirEntry("root")
+	prod := NewFileEntry("root/a.go", map[string]CodeStats{"Go": {Code: 30, Comments: 10}})
+	test := NewFileEntry("root/a_test.go", map[string]CodeStats{"Go": {Code: 20, Blanks: 5}})
+	test.SetTest(true)
+	script := NewFileEntry("root/run.py", map[string]CodeStats{"Python": {Code: 15}})
+	root.AddChild(prod)
+	root.AddChild(test)
+	root.AddChild(script)
+	root.AggregateStats()
+
+	if got := root.TestStats; got != (CodeStats{Code: 20, Blanks: 5, Files: 1}) {
+		t.Errorf("TestStats = %+v", got)
+	}
+	if got := root.GetScopedStats("", ScopeAll).Code; got != 65 {
+		t.Errorf("all code = %d, want 65", got)
+	}
+	if got := root.GetScopedStats("", ScopeProduction).Code; got != 45 {
+		t.Errorf("production code = %d, want 45", got)
+	}
+	if got := root.GetScopedStats("Go", ScopeTest).Total(); got != 25 {
+		t.Errorf("Go test total = %d, want 25", got)
+	}
+	if got := root.GetScopedStats("Python", ScopeTest).Total(); got != 0 {
+		t.Errorf("Python test total = %d, want 0", got)
+	}
+
+	test.SetTest(false)
+	root.AggregateStats()
+	if got := root.TestStats.Total(); got != 0 {
+		t.Errorf("expected no test stats after reclassifying, got %d", got)
+	}
+}
+
+func TestEntryScopedMaxComplexity(t *testing.T) {
+	root := NewDirEntry("root")
+	sub := NewDirEntry("root/pkg")
+	sub.AddChild(NewFileEntry("root/pkg/a.go", map[string]CodeStats{"Go": {Code: 30, Complexity: 4, MaxComplexity: 4}}))
+	test := NewFileEntry("root/pkg/a_test.go", map[string]CodeStats{"Go": {Code: 20, Complexity: 9, MaxComplexity: 9}})
+	test.SetTest(true)
+	sub.AddChild(test)
+	root.AddChild(sub)
+	root.AddChild(NewFileEntry("root/run.py", map[string]CodeStats{"Python": {Code: 15, Complexity: 6, MaxComplexity: 6}}))
+	root.AggregateStats()
+
+	tests := []struct {
+		lang  string
+		scope Scope
+		want  int64
+	}{
+		{"", ScopeAll, 9},
+		{"", ScopeTest, 9},
+		{"", ScopeProduction, 6},
+		{"Go", ScopeProduction, 4},
+		{"Python", ScopeProduction, 6},
+		{"Python", ScopeTest, 0},
+	}
+	for _, tt := range tests {
+		if got := root.GetScopedStats(tt.lang, tt.scope).MaxComplexity; got != tt.want {
+			t.Errorf("%s %q max complexity = %d, want %d", tt.scope, tt.lang, got, tt.want)
+		}
+	}
+	if got := test.GetScopedStats("", ScopeProduction).MaxComplexity; got != 0 {
+		t.Errorf("test file production max complexity = %d, want 0", got)
+	}
+}
+
+func TestGetChild(t *testing.T) {
+	root := NewDirEntry("root")
+	if root.GetChild("a.go") != nil {
+		t.Fatal("expected no child in an empty directory")
+	}
+
+	a := &Entry{Path: "root/a.go"}
+	root.AddChild(a)
+	root.AddChild(&Entry{Path: "root/b.go"})
+	if got := root.GetChild("a.go"); got != a {
+		t.Fatalf("expected a.go, got %v", got)
+	}
+
+	// Children added after the index was built are found.
+	c := &Entry{Path: "root/c.go"}
+	root.AddChild(c)
+	if got := root.GetChild("c.go"); got != c {
+		t.Errorf("expected c.go after AddChild, got %v", got)
+	}
+
+	// So are children appended to Child directly.
+	d := &Entry{Path: "root/d.go"}
+	root.Child = append(root.Child, d)
+	if got := root.GetChild("d.go"); got != d {
+		t.Errorf("expected d.go after appending to Child, got %v", got)
+	}
+
+	// Duplicate names resolve to the first child added.
+	root.AddChild(&Entry{Path: "root/a.go"})
+	if got := root.GetChild("a.go"); got != a {
+		t.Errorf("expected the first a.go, got %v", got)
+	}
+	if root.GetChild("missing.go") != nil {
+		t.Error("expected nil for a missing child")
+	}
+}
+
+func BenchmarkGetChild(b *testing.B) {
+	root := NewDirEntry("root")
+	for i := range 10000 {
+		root.AddChild(&Entry{Path: fmt.Sprintf("root/file_%d.go", i)})
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		if root.GetChild(fmt.Sprintf("file_%d.go", i%10000)) == nil {
+			b.Fatal("child not found")
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package structure
+
+// AggregateFiltered recomputes the statistics of root's subtree counting only
+// the files for which keep returns true. Entries in skip are left out along
+// with everything below them, without being walked. The result maps every original entry
+// that retains at least one kept file to a detached, stats-only copy holding
+// the recomputed totals; entries missing from the map have nothing left after
+// filtering. The original tree is not modified.
+func AggregateFiltered(root *Entry, skip map[*Entry]bool, keep func(*Entry) bool) map[*Entry]*Entry {
+	result := make(map[*Entry]*Entry)
+	if root == nil {
+		return result
+	}
+	aggregateFiltered(root, skip, keep, result)
+	return result
+}
+
+func aggregateFiltered(e *Entry, skip map[*Entry]bool, keep func(*Entry) bool, result map[*Entry]*Entry) *Entry {
+	if skip[e] {
+		return nil
+	}
+	if !e.IsDir {
+		if !keep(e) {
+			return nil
+		}
+		shadow := &Entry{
+			Path:            e.Path,
+			StatsByLang:     e.StatsByLang,
+			TotalStats:      e.TotalStats,
+			IsTest:          e.IsTest,
+			TestStats:       e.TestStats,
+			TestStatsByLang: e.TestStatsByLang,
+			Owners:          e.Owners,
+			StatsByOwner:    e.StatsByOwner,
+			LinesByAuthor:   e.LinesByAuthor,
+			Churn:           e.Churn,
+			Times:           e.Times,
+		}
+		result[e] = shadow
+		return shadow
+	}
+
+	shadow := &Entry{Path: e.Path, IsDir: true}
+	for _, child := range e.Child {
+		if c := aggregateFiltered(child, skip, keep, result); c != nil {
+			shadow.Child = append(shadow.Child, c)
+		}
+	}
+	if len(shadow.Child) == 0 {
+		return nil
+	}
+	shadow.aggregateChildren()
+	// The copy is detached: its children are shadows, not tree entries.
+	shadow.Child = nil
+	result[e] = shadow
+	return shadow
+}
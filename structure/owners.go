@@ -0,0 +1,54 @@
+package structure
+
+import "path/filepath"
+
+// Unowned is the owner name attributed to files no ownership rule matches.
+const Unowned = "(unowned)"
+
+// OwnerResolver resolves the owners of a slash-separated path relative to the
+// analysis root.
+type OwnerResolver interface {
+	Owners(relPath string) []string
+}
+
+// SetOwners records the owners of a file entry and attributes its stats to
+// each of them. Files without owners are attributed to Unowned.
+func (e *Entry) SetOwners(owners []string) {
+	if e.IsDir {
+		return
+	}
+	if len(owners) == 0 {
+		owners = []string{Unowned}
+	}
+	e.Owners = owners
+	e.StatsByOwner = make(map[string]CodeStats, len(owners))
+	for _, o := range owners {
+		e.StatsByOwner[o] = e.TotalStats
+	}
+}
+
+// ApplyOwners attributes every file in the tree to its owners as reported by
+// r and re-aggregates directory statistics.
+func (t *Tree) ApplyOwners(r OwnerResolver) {
+	if t.root == nil || r == nil {
+		return
+	}
+	rootPath := t.root.Path
+
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		for _, child := range e.Child {
+			if child.IsDir {
+				walk(child)
+				continue
+			}
+			rel, err := filepath.Rel(rootPath, child.Path)
+			if err != nil {
+				rel = child.Path
+			}
+			child.SetOwners(r.Owners(filepath.ToSlash(rel)))
+		}
+	}
+	walk(t.root)
+	t.root.AggregateStats()
+}
@@ -0,0 +1,90 @@
+package structure
+
+import "testing"
+
+type mapResolver map[string][]string
+
+func (m mapResolver) Owners(relPath string) []string {
+	return m[relPath]
+}
+
+func newOwnedTree() *Tree {
+	root := NewDirEntry("root")
+	api := NewDirEntry("root/api")
+	api.AddChild(NewFileEntry("root/api/a.go", map[string]CodeStats{"Go": {Code: 10}}))
+	api.AddChild(NewFileEntry("root/api/b.go", map[string]CodeStats{"Go": {Code: 20}}))
+	root.AddChild(api)
+	root.AddChild(NewFileEntry("root/main.py", map[string]CodeStats{"Python": {Code: 5}}))
+	root.AggregateStats()
+
+	tree := NewTree(root)
+	tree.ApplyOwners(mapResolver{
+		"api/a.go": {"@api"},
+		"api/b.go": {"@api", "@core"},
+	})
+	return tree
+}
+
+func TestTreeApplyOwners(t *testing.T) {
+	root := newOwnedTree().Root()
+
+	want := map[string]int64{"@api": 30, "@core": 20, Unowned: 5}
+	if len(root.StatsByOwner) != len(want) {
+		t.Fatalf("owners = %v, want %v", root.StatsByOwner, want)
+	}
+	for owner, code := range want {
+		if got := root.StatsByOwner[owner].Code; got != code {
+			t.Errorf("root owner %s code = %d, want %d", owner, got, code)
+		}
+	}
+
+	main := root.GetChild("main.py")
+	if len(main.Owners) != 1 || main.Owners[0] != Unowned {
+		t.Errorf("main.py owners = %v, want [%s]", main.Owners, Unowned)
+	}
+	// Totals are unaffected by co-ownership.
+	if root.TotalStats.Code != 35 {
+		t.Errorf("root code = %d, want 35", root.TotalStats.Code)
+	}
+}
+
+func TestAggregateFiltered(t *testing.T) {
+	root := newOwnedTree().Root()
+	api := root.GetChild("api")
+
+	result := AggregateFiltered(root, nil, func(e *Entry) bool {
+		_, ok := e.StatsByOwner["@core"]
+		return ok
+	})
+
+	if got := result[root].TotalStats.Code; got != 20 {
+		t.Errorf("root filtered code = %d, want 20", got)
+	}
+	if got := result[api].StatsByLang["Go"].Code; got != 20 {
+		t.Errorf("api filtered Go code = %d, want 20", got)
+	}
+	if _, ok := result[root.GetChild("main.py")]; ok {
+		t.Error("expected main.py to be filtered out")
+	}
+	if _, ok := result[api.GetChild("a.go")]; ok {
+		t.Error("expected a.go to be filtered out")
+	}
+	// The original tree is untouched.
+	if root.TotalStats.Code != 35 {
+		t.Errorf("original root code = %d, want 35", root.TotalStats.Code)
+	}
+
+	// Skipped subtrees are left out without consulting keep.
+	result = AggregateFiltered(root, map[*Entry]bool{api: true}, func(e *Entry) bool {
+		if e.Path == api.GetChild("a.go").Path {
+			t.Errorf("keep called for %s below a skipped directory", e.Path)
+		}
+		return true
+	})
+	if _, ok := result[api]; ok {
+		t.Error("expected api to be skipped")
+	}
+	if got, want := result[root].TotalStats.Code, root.TotalStats.Code-api.TotalStats.Code; got != want {
+		t.Errorf("root code without api = %d, want %d", got, want)
+	}
+}
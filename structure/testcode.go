@@ -0,0 +1,131 @@
+package structure
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultTestPatterns lists the built-in conventions used to recognize test
+// code. Patterns ending in "/" match a directory sequence anywhere in the
+// path; all other patterns are globs matched against the file name.
+var DefaultTestPatterns = []string{
+	// Go
+	"*_test.go",
+	// Python
+	"test_*.py",
+	"*_test.py",
+	// JavaScript / TypeScript
+	"*.spec.ts",
+	"*.test.ts",
+	"*.spec.tsx",
+	"*.test.tsx",
+	"*.spec.js",
+	"*.test.js",
+	"*.spec.jsx",
+	"*.test.jsx",
+	// Ruby
+	"*_spec.rb",
+	"*_test.rb",
+	// Rust / C / C++
+	"*_test.rs",
+	"*_test.c",
+	"*_test.cc",
+	"*_test.cpp",
+	// Java / Kotlin / C#
+	"*Test.java",
+	"*Tests.java",
+	"*Test.kt",
+	"*Tests.cs",
+	// Directory conventions
+	"src/test/",
+	"__tests__/",
+	"tests/",
+	"testdata/",
+}
+
+// TestMatcher classifies file paths as test or production code.
+type TestMatcher struct {
+	namePatterns []namePattern
+	dirPatterns  []string
+}
+
+// namePattern is a glob matched against file names, or against the path if
+// it contains a "/".
+type namePattern struct {
+	glob string
+	// Globs with a single "*" and no other metacharacters, like all the
+	// defaults, are matched by prefix and suffix, which is much faster than
+	// path.Match when classifying large trees.
+	simple         bool
+	prefix, suffix string
+}
+
+func newNamePattern(glob string) namePattern {
+	p := namePattern{glob: glob}
+	if strings.Count(glob, "*") == 1 && !strings.ContainsAny(glob, "?[\\/") {
+		p.simple = true
+		p.prefix, p.suffix, _ = strings.Cut(glob, "*")
+	}
+	return p
+}
+
+func (p namePattern) match(relPath, name string) bool {
+	if p.simple {
+		return len(name) >= len(p.prefix)+len(p.suffix) &&
+			strings.HasPrefix(name, p.prefix) && strings.HasSuffix(name, p.suffix)
+	}
+	target := name
+	if strings.Contains(p.glob, "/") {
+		target = relPath
+	}
+	ok, _ := path.Match(p.glob, target)
+	return ok
+}
+
+// NewTestMatcher creates a matcher from the given patterns. See
+// DefaultTestPatterns for the pattern syntax.
+func NewTestMatcher(patterns []string) *TestMatcher {
+	m := &TestMatcher{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		p = strings.TrimPrefix(strings.ReplaceAll(p, "\\", "/"), "./")
+		if strings.HasSuffix(p, "/") {
+			m.dirPatterns = append(m.dirPatterns, "/"+strings.Trim(p, "/")+"/")
+			continue
+		}
+		m.namePatterns = append(m.namePatterns, newNamePattern(p))
+	}
+	return m
+}
+
+// DefaultTestMatcher returns a matcher using DefaultTestPatterns.
+func DefaultTestMatcher() *TestMatcher {
+	return NewTestMatcher(DefaultTestPatterns)
+}
+
+// Match reports whether the slash-separated path relative to the analysis
+// root refers to test code.
+func (m *TestMatcher) Match(relPath string) bool {
+	if m == nil {
+		return false
+	}
+	relPath = strings.TrimPrefix(strings.ReplaceAll(relPath, "\\", "/"), "./")
+
+	name := path.Base(relPath)
+	for _, p := range m.namePatterns {
+		if p.match(relPath, name) {
+			return true
+		}
+	}
+
+	dir := "/" + path.Dir(relPath) + "/"
+	for _, p := range m.dirPatterns {
+		if strings.Contains(dir, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package structure
+
+import "testing"
+
+func TestTestMatcher_DefaultPatterns(t *testing.T) {
+	m := DefaultTestMatcher()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"pkg/foo_test.go", true},
+		{"pkg/foo.go", false},
+		{"app/test_views.py", true},
+		{"app/views.py", false},
+		{"web/src/button.spec.ts", true},
+		{"web/src/button.ts", false},
+		{"service/src/test/java/com/acme/Foo.java", true},
+		{"service/src/main/java/com/acme/Foo.java", false},
+		{"ui/__tests__/App.jsx", true},
+		{"contest/main.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTestMatcher_CustomPatternsReplaceDefaults(t *testing.T) {
+	m := NewTestMatcher([]string{"*_check.go", "qa/", " "})
+
+	if m.Match("pkg/foo_test.go") {
+		t.Error("expected built-in pattern to be replaced")
+	}
+	if !m.Match("pkg/foo_check.go") {
+		t.Error("expected custom name pattern to match")
+	}
+	if !m.Match("qa/smoke/run.sh") {
+		t.Error("expected custom directory pattern to match")
+	}
+}
+
+func TestTestMatcher_Nil(t *testing.T) {
+	var m *TestMatcher
+	if m.Match("foo_test.go") {
+		t.Error("expected nil matcher to match nothing")
+	}
+}
+
+func TestTestMatcher_SimpleAndGlobPatterns(t *testing.T) {
+	m := NewTestMatcher([]string{"t*t.sh", "check?.go", "e2e/*.go"})
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"bin/test.sh", true},
+		{"bin/t.sh", false}, // prefix and suffix must not overlap
+		{"pkg/check1.go", true},
+		{"pkg/check12.go", false},
+		{"e2e/login.go", true},
+		{"app/e2e/login.go", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package structure
+
+import (
+	"slices"
+	"time"
+)
+
+// Times records when an entry was created and last changed. For directories
+// LastChanged is the newest change of any child and Created is the
+// size-weighted median of the children's creation times.
+type Times struct {
+	Created     time.Time
+	LastChanged time.Time
+}
+
+// ApplyTimes sets the times of every file in the tree from byPath, keyed by
+// entry path, and re-aggregates directory statistics.
+func (t *Tree) ApplyTimes(byPath map[string]Times) {
+	if t.root == nil {
+		return
+	}
+	var walk func(e *Entry)
+	walk = func(e *Entry) {
+		for _, child := range e.Child {
+			if child.IsDir {
+				walk(child)
+				continue
+			}
+			child.Times = byPath[child.Path]
+		}
+	}
+	walk(t.root)
+	t.root.AggregateStats()
+}
+
+// aggregateTimes computes a directory's times from its immediate children.
+func aggregateTimes(children []*Entry) Times {
+	var times Times
+	type weighted struct {
+		created time.Time
+		weight  int64
+	}
+	created := make([]weighted, 0, len(children))
+	var totalWeight int64
+	for _, child := range children {
+		if child.Times.LastChanged.After(times.LastChanged) {
+			times.LastChanged = child.Times.LastChanged
+		}
+		if child.Times.Created.IsZero() {
+			continue
+		}
+		// Every child counts at least once so empty files still have a say
+		// when nothing else does.
+		w := max(1, child.TotalStats.Total())
+		created = append(created, weighted{created: child.Times.Created, weight: w})
+		totalWeight += w
+	}
+	if len(created) == 0 {
+		return times
+	}
+
+	slices.SortFunc(created, func(a, b weighted) int { return a.created.Compare(b.created) })
+	var acc int64
+	for _, c := range created {
+		acc += c.weight
+		if acc*2 >= totalWeight {
+			times.Created = c.created
+			break
+		}
+	}
+	return times
+}
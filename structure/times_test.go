@@ -0,0 +1,36 @@
+package structure
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTreeApplyTimes(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2024, 1, n, 0, 0, 0, 0, time.UTC) }
+
+	root := NewDirEntry("root")
+	pkg := NewDirEntry("root/pkg")
+	pkg.AddChild(NewFileEntry("root/pkg/big.go", map[string]CodeStats{"Go": {Code: 100}}))
+	pkg.AddChild(NewFileEntry("root/pkg/small.go", map[string]CodeStats{"Go": {Code: 10}}))
+	pkg.AddChild(NewFileEntry("root/pkg/tiny.go", map[string]CodeStats{"Go": {Code: 10}}))
+	root.AddChild(pkg)
+	root.AddChild(NewFileEntry("root/unknown.go", map[string]CodeStats{"Go": {Code: 1}}))
+	root.AggregateStats()
+
+	tree := NewTree(root)
+	tree.ApplyTimes(map[string]Times{
+		"root/pkg/big.go":   {Created: day(1), LastChanged: day(2)},
+		"root/pkg/small.go": {Created: day(5), LastChanged: day(9)},
+		"root/pkg/tiny.go":  {Created: day(6), LastChanged: day(6)},
+	})
+
+	// The big file outweighs the two newer small ones.
+	want := Times{Created: day(1), LastChanged: day(9)}
+	if pkg.Times != want {
+		t.Errorf("pkg times = %+v, want %+v", pkg.Times, want)
+	}
+	// Files without times do not pull the directory towards the zero time.
+	if root.Times != want {
+		t.Errorf("root times = %+v, want %+v", root.Times, want)
+	}
+}
@@ -3,7 +3,9 @@
 package structure
 
 import (
+	"maps"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/zdyxry/tokui/provider"
@@ -11,7 +13,8 @@ import (
 
 // Tree represents the code statistics file tree.
 type Tree struct {
-	root *Entry
+	root        *Entry
+	testMatcher *TestMatcher
 }
 
 // NewTree creates a new Tree with the given root entry.
@@ -29,6 +32,12 @@ func (t *Tree) SetRoot(root *Entry) {
 	t.root = root
 }
 
+// SetTestMatcher sets the matcher used to classify files as test code while
+// building the tree. When unset, DefaultTestMatcher is used.
+func (t *Tree) SetTestMatcher(m *TestMatcher) {
+	t.testMatcher = m
+}
+
 // BuildFromProvider analyzes the given path using the supplied Provider and
 // builds the file tree from the returned per-file statistics.
 func (t *Tree) BuildFromProvider(p provider.Provider, path string) error {
@@ -90,42 +99,74 @@ func (t *Tree) buildFromResult(result provider.Result, absPath string) error {
 		}
 	}
 
-	for filePath, stats := range fileStats {
-		t.addFileToTree(t.root, filePath, stats)
+	matcher := t.testMatcher
+	if matcher == nil {
+		matcher = DefaultTestMatcher()
+	}
+	// Adding the files in path order keeps the tree deterministic, and the
+	// builder resolves each directory only once.
+	b := newTreeBuilder(t.root)
+	for _, filePath := range slices.Sorted(maps.Keys(fileStats)) {
+		if file := b.addFile(filePath, fileStats[filePath]); file != nil {
+			file.SetTest(matcher.Match(filePath))
+		}
 	}
 	return nil
 }
 
-func (t *Tree) addFileToTree(root *Entry, relativePath string, stats map[string]CodeStats) {
-	parts := strings.Split(relativePath, "/")
-	currentNode := root
+// addFileToTree inserts a file below root, creating intermediate directories
+// as needed, and returns the new file entry.
+func (t *Tree) addFileToTree(root *Entry, relativePath string, stats map[string]CodeStats) *Entry {
+	return newTreeBuilder(root).addFile(relativePath, stats)
+}
 
-	// Iterate through the directory parts of the path (excluding the final filename)
-	for i := 0; i < len(parts)-1; i++ {
-		part := parts[i]
-		if part == "" { // Ignore empty strings produced by splitting "//" etc.
-			continue
-		}
+// treeBuilder adds files below a root, remembering the directory entry of
+// every relative directory path it has resolved.
+type treeBuilder struct {
+	dirs map[string]*Entry
+}
 
-		childNode := currentNode.GetChild(part)
-		if childNode == nil {
-			// If the directory doesn't exist, create it. Use filepath.Join to ensure path separators match the current system.
-			childPath := filepath.Join(currentNode.Path, part)
-			childNode = NewDirEntry(childPath)
-			currentNode.AddChild(childNode)
-		}
-		currentNode = childNode
+func newTreeBuilder(root *Entry) *treeBuilder {
+	return &treeBuilder{dirs: map[string]*Entry{"": root}}
+}
+
+// addFile inserts a file at a slash-separated path relative to the root and
+// returns the new file entry, or nil if the path names no file.
+func (b *treeBuilder) addFile(relativePath string, stats map[string]CodeStats) *Entry {
+	dir, fileName := "", relativePath
+	if i := strings.LastIndexByte(relativePath, '/'); i >= 0 {
+		dir, fileName = relativePath[:i], relativePath[i+1:]
+	}
+	parent := b.dir(dir)
+	if fileName == "" {
+		return nil
 	}
+	// Use filepath.Join to ensure path separators match the current system.
+	fileEntry := NewFileEntry(filepath.Join(parent.Path, fileName), stats)
+	parent.AddChild(fileEntry)
+	return fileEntry
+}
 
-	// Add the file node
-	if len(parts) > 0 {
-		fileName := parts[len(parts)-1]
-		if fileName != "" {
-			filePath := filepath.Join(currentNode.Path, fileName)
-			fileEntry := NewFileEntry(filePath, stats)
-			currentNode.AddChild(fileEntry)
+// dir returns the directory entry at a slash-separated path relative to the
+// root, creating it and its parents as needed. Empty path segments, as in
+// "a//b", are ignored.
+func (b *treeBuilder) dir(relativePath string) *Entry {
+	if e, ok := b.dirs[relativePath]; ok {
+		return e
+	}
+	parent, name := b.dirs[""], relativePath
+	if i := strings.LastIndexByte(relativePath, '/'); i >= 0 {
+		parent, name = b.dir(relativePath[:i]), relativePath[i+1:]
+	}
+	e := parent
+	if name != "" {
+		if e = parent.GetChild(name); e == nil {
+			e = NewDirEntry(filepath.Join(parent.Path, name))
+			parent.AddChild(e)
 		}
 	}
+	b.dirs[relativePath] = e
+	return e
 }
 
 // normalizePath converts a raw file path (absolute or relative) to a path
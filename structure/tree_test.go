@@ -1,6 +1,7 @@
 package structure
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -111,24 +112,24 @@ func TestAggregateStats_NestedTree(t *testing.T) {
 
 	root.AggregateStats()
 
-	wantTotal := CodeStats{Code: 35, Comments: 4, Blanks: 4}
+	wantTotal := CodeStats{Code: 35, Comments: 4, Blanks: 4, Files: 3}
 	if root.TotalStats != wantTotal {
 		t.Errorf("root total stats mismatch: got %+v, want %+v", root.TotalStats, wantTotal)
 	}
 
-	wantGo := CodeStats{Code: 30, Comments: 3, Blanks: 3}
+	wantGo := CodeStats{Code: 30, Comments: 3, Blanks: 3, Files: 2}
 	if root.StatsByLang["Go"] != wantGo {
 		t.Errorf("root Go stats mismatch: got %+v, want %+v", root.StatsByLang["Go"], wantGo)
 	}
 
-	wantPython := CodeStats{Code: 5, Comments: 1, Blanks: 1}
+	wantPython := CodeStats{Code: 5, Comments: 1, Blanks: 1, Files: 1}
 	if root.StatsByLang["Python"] != wantPython {
 		t.Errorf("root Python stats mismatch: got %+v, want %+v", root.StatsByLang["Python"], wantPython)
 	}
 
 	src := root.GetChild("src")
 	require.NotNil(t, src, "expected src dir")
-	wantSrcTotal := CodeStats{Code: 25, Comments: 3, Blanks: 3}
+	wantSrcTotal := CodeStats{Code: 25, Comments: 3, Blanks: 3, Files: 2}
 	if src.TotalStats != wantSrcTotal {
 		t.Errorf("src total stats mismatch: got %+v, want %+v", src.TotalStats, wantSrcTotal)
 	}
@@ -304,3 +305,78 @@ func TestNormalizePath_RelativeUnderRoot(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildFromProviderResult_ClassifiesTests(t *testing.T) {
+	result := provider.Result{Files: []provider.FileStats{
+		{Path: "pkg/foo.go", Language: "Go", Code: 40},
+		{Path: "pkg/foo_test.go", Language: "Go", Code: 10},
+	}}
+
+	tr := NewTree(nil)
+	require.NoError(t, tr.BuildFromProviderResult(result, "."))
+
+	pkg := tr.Root().GetChild("pkg")
+	require.NotNil(t, pkg)
+	require.True(t, pkg.GetChild("foo_test.go").IsTest)
+	require.False(t, pkg.GetChild("foo.go").IsTest)
+	require.Equal(t, int64(10), tr.Root().TestStats.Code)
+
+	tr = NewTree(nil)
+	tr.SetTestMatcher(NewTestMatcher([]string{"foo.go"}))
+	require.NoError(t, tr.BuildFromProviderResult(result, "."))
+	require.Equal(t, int64(40), tr.Root().TestStats.Code)
+}
+
+func TestBuildFromResult_SharedDirectories(t *testing.T) {
+	tr := NewTree(NewDirEntry("root"))
+	result := provider.Result{Files: []provider.FileStats{
+		{Path: "/root/src/b.go", Language: "Go", Code: 2},
+		{Path: "/root/src//a.go", Language: "Go", Code: 1},
+		{Path: "/root/src/pkg/c.go", Language: "Go", Code: 3},
+		{Path: "/root/main.go", Language: "Go", Code: 4},
+	}}
+	require.NoError(t, tr.buildFromResult(result, "/root"))
+
+	root := tr.Root()
+	require.Len(t, root.Child, 2)
+	src := root.GetChild("src")
+	require.NotNil(t, src)
+	require.True(t, src.IsDir)
+	require.Len(t, src.Child, 3, "src is created once and holds a.go, b.go and pkg")
+	require.Equal(t, []string{"a.go", "b.go", "pkg"}, []string{src.Child[0].Name(), src.Child[1].Name(), src.Child[2].Name()})
+	require.Equal(t, filepath.Join("root", "src", "pkg", "c.go"), src.GetChild("pkg").GetChild("c.go").Path)
+}
+
+// benchmarkResult returns a result of n files spread over dirs directories.
+func benchmarkResult(n, dirs int) provider.Result {
+	result := provider.Result{Files: make([]provider.FileStats, n)}
+	for i := range n {
+		result.Files[i] = provider.FileStats{
+			Path:     fmt.Sprintf("/root/dir_%d/file_%d.go", i%dirs, i),
+			Language: "Go",
+			Code:     10,
+		}
+	}
+	return result
+}
+
+func BenchmarkBuildFromResult(b *testing.B) {
+	for _, bc := range []struct {
+		name        string
+		files, dirs int
+	}{
+		{"100k files in one directory", 100000, 1},
+		{"100k files in 1k directories", 100000, 1000},
+		{"100k directories of one file", 100000, 100000},
+	} {
+		result := benchmarkResult(bc.files, bc.dirs)
+		b.Run(bc.name, func(b *testing.B) {
+			for range b.N {
+				tr := NewTree(nil)
+				if err := tr.BuildFromProviderResult(result, "/root"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
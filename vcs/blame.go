@@ -0,0 +1,133 @@
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotCommitted is the author git blame reports for uncommitted lines.
+const NotCommitted = "Not Committed Yet"
+
+// ParseLinePorcelain counts the lines attributed to each author in the output
+// of "git blame --line-porcelain".
+func ParseLinePorcelain(r io.Reader) (map[string]int64, error) {
+	lines := make(map[string]int64)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Content lines start with a tab and may themselves begin with
+		// "author "; every other line is a header.
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "author "); ok {
+			lines[name]++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// blameKey identifies a version of a file so cached results are discarded
+// when the file changes on disk.
+type blameKey struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Blamer runs git blame on files and caches the per-author line counts.
+// It is safe for concurrent use.
+type Blamer struct {
+	mu      sync.Mutex
+	cache   map[blameKey]map[string]int64
+	workers int
+}
+
+// NewBlamer creates a Blamer that blames up to one file per CPU concurrently.
+func NewBlamer() *Blamer {
+	return &Blamer{
+		cache:   make(map[blameKey]map[string]int64),
+		workers: runtime.NumCPU(),
+	}
+}
+
+// Blame returns the number of surviving lines per author for the file at
+// path.
+func (b *Blamer) Blame(path string) (map[string]int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key := blameKey{path: path, size: info.Size(), modTime: info.ModTime()}
+
+	b.mu.Lock()
+	cached, ok := b.cache[key]
+	b.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	out, err := runGit(filepath.Dir(path), "blame", "--line-porcelain", "--", filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	lines, err := ParseLinePorcelain(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	b.cache[key] = lines
+	b.mu.Unlock()
+	return lines, nil
+}
+
+// BlameFiles blames the given files in parallel and returns the per-author
+// line counts keyed by path. Files git cannot blame, such as untracked ones,
+// are left out of the result. It stops early when ctx is canceled.
+func (b *Blamer) BlameFiles(ctx context.Context, paths []string) map[string]map[string]int64 {
+	result := make(map[string]map[string]int64, len(paths))
+	var mu sync.Mutex
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for range max(1, b.workers) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				lines, err := b.Blame(path)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				result[path] = lines
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, path := range paths {
+		select {
+		case jobs <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return result
+}
@@ -0,0 +1,106 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const porcelainSample = `4b825dc642cb6eb9a060e54bf8d69288fbee4904 1 1 2
+author Alice
+author-mail <alice@example.com>
+author-time 1700000000
+author-tz +0000
+committer Alice
+committer-mail <alice@example.com>
+committer-time 1700000000
+committer-tz +0000
+summary init
+filename main.go
+	package main
+4b825dc642cb6eb9a060e54bf8d69288fbee4904 2 2
+author Alice
+author-mail <alice@example.com>
+summary init
+filename main.go
+	author Mallory in a string literal
+0000000000000000000000000000000000000000 3 3 1
+author Not Committed Yet
+author-mail <not.committed.yet>
+filename main.go
+	func main() {}
+`
+
+func TestParseLinePorcelain(t *testing.T) {
+	got, err := ParseLinePorcelain(strings.NewReader(porcelainSample))
+	if err != nil {
+		t.Fatalf("ParseLinePorcelain: %v", err)
+	}
+	want := map[string]int64{"Alice": 2, NotCommitted: 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// initRepo creates a git repository with one committed file and one untracked
+// file.
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nvar x = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "a.go")
+	git("commit", "-q", "-m", "init")
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestBlamer_BlameFiles(t *testing.T) {
+	dir := initRepo(t)
+	tracked := filepath.Join(dir, "a.go")
+	untracked := filepath.Join(dir, "new.go")
+
+	b := NewBlamer()
+	got := b.BlameFiles(context.Background(), []string{tracked, untracked})
+
+	if want := map[string]int64{"Alice": 3}; !reflect.DeepEqual(got[tracked], want) {
+		t.Errorf("a.go authors = %v, want %v", got[tracked], want)
+	}
+	if _, ok := got[untracked]; ok {
+		t.Error("expected untracked file to be skipped")
+	}
+	if len(b.cache) != 1 {
+		t.Errorf("cache size = %d, want 1", len(b.cache))
+	}
+}
+
+func TestRepoRoot_NotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	_, err := RepoRoot(t.TempDir())
+	if !errors.Is(err, ErrNotRepository) {
+		t.Errorf("err = %v, want ErrNotRepository", err)
+	}
+}
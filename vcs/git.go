@@ -0,0 +1,55 @@
+// Package vcs extracts history information such as blame authorship from git
+// repositories by shelling out to the git binary.
+package vcs
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNotRepository is returned when a path is not inside a git work tree.
+var ErrNotRepository = errors.New("not a git repository")
+
+// RepoRoot returns the top-level directory of the git work tree containing
+// dir.
+func RepoRoot(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s: %w", dir, ErrNotRepository)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runGit runs git with the given arguments in dir and returns its standard
+// output.
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// Name the subcommand rather than leading -c options.
+			sub := args[0]
+			for i := 0; i+2 < len(args) && args[i] == "-c"; i += 2 {
+				sub = args[i+2]
+			}
+			return nil, fmt.Errorf("git %s failed (exit code %d): %s: %w",
+				sub, exitErr.ExitCode(), strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return nil, fmt.Errorf("failed to execute git (please ensure git is installed and in PATH): %w", err)
+	}
+	return out, nil
+}
+
+// runGitLog runs "git log -z" in dir with the given arguments, printing paths
+// verbatim rather than C-quoted.
+func runGitLog(dir string, args ...string) ([]byte, error) {
+	return runGit(dir, append([]string{"-c", "core.quotePath=false", "log", "-z"}, args...)...)
+}
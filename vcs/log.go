@@ -0,0 +1,103 @@
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FileChurn summarizes how often a file changed and by how much.
+type FileChurn struct {
+	Commits int64
+	Added   int64
+	Removed int64
+}
+
+// commitHeader starts the format of every commit header in "git log -z"
+// output. The NUL makes the header an empty record followed by the header
+// itself, which no file record can be mistaken for.
+const commitHeader = "%x00"
+
+// scanLog splits the output of "git log -z" with a commitHeader format into
+// commit headers and the per-file records that follow them.
+func scanLog(r io.Reader, header, file func(string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	inHeader, first := false, false
+	for scanner.Scan() {
+		record := scanner.Text()
+		switch {
+		case record == "":
+			inHeader = true
+		case inHeader:
+			header(record)
+			inHeader, first = false, true
+		default:
+			// git separates the header from the first file with a newline.
+			if first {
+				record = strings.TrimPrefix(record, "\n")
+				first = false
+			}
+			file(record)
+		}
+	}
+	return scanner.Err()
+}
+
+// ParseNumstat aggregates the output of
+// "git log -z --numstat --format=%x00%H" into per-file churn keyed by path.
+// Binary files count as changed without lines.
+func ParseNumstat(r io.Reader) (map[string]FileChurn, error) {
+	churn := make(map[string]FileChurn)
+	err := scanLog(r, func(string) {}, func(record string) {
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			return
+		}
+		c := churn[fields[2]]
+		c.Commits++
+		// Binary files report "-" instead of line counts.
+		if n, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			c.Added += n
+		}
+		if n, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			c.Removed += n
+		}
+		churn[fields[2]] = c
+	})
+	if err != nil {
+		return nil, err
+	}
+	return churn, nil
+}
+
+// Churn returns the per-file churn of commits since the given date, keyed by
+// slash-separated paths relative to dir. since accepts anything git
+// understands, such as "6.months" or "2024-01-01"; an empty value means the
+// whole history. Renames are not followed.
+func Churn(dir, since string) (map[string]FileChurn, error) {
+	if _, err := RepoRoot(dir); err != nil {
+		return nil, err
+	}
+	args := []string{"--numstat", "--no-renames", "--relative", "--format=" + commitHeader + "%H"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	args = append(args, "--", ".")
+	out, err := runGitLog(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNumstat(bytes.NewReader(out))
+}
@@ -0,0 +1,116 @@
+package vcs
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNumstat(t *testing.T) {
+	out := "\x001111111111111111111111111111111111111111\x00" +
+		"\n3\t1\tpkg/a.go\x0010\t0\tREADME.md\x00" +
+		"\x002222222222222222222222222222222222222222\x00" +
+		"\n2\t2\tpkg/a.go\x00-\t-\tassets/logo.png\x001\t0\tcommit notes.txt\x00"
+	got, err := ParseNumstat(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ParseNumstat: %v", err)
+	}
+	want := map[string]FileChurn{
+		"pkg/a.go":         {Commits: 2, Added: 5, Removed: 3},
+		"README.md":        {Commits: 1, Added: 10},
+		"assets/logo.png":  {Commits: 1},
+		"commit notes.txt": {Commits: 1, Added: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChurn(t *testing.T) {
+	dir := initRepo(t)
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "b.go"), []byte("package sub\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// git C-quotes non-ASCII paths unless told otherwise.
+	if err := os.WriteFile(filepath.Join(dir, "naïve.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "-q", "-m", "edit"}} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Bob", "-c", "user.email=bob@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	got, err := Churn(dir, "")
+	if err != nil {
+		t.Fatalf("Churn: %v", err)
+	}
+	if want := (FileChurn{Commits: 2, Added: 3, Removed: 2}); got["a.go"] != want {
+		t.Errorf("a.go churn = %+v, want %+v", got["a.go"], want)
+	}
+	if want := (FileChurn{Commits: 1, Added: 1}); got["naïve.go"] != want {
+		t.Errorf("naïve.go churn = %+v, want %+v", got["naïve.go"], want)
+	}
+
+	// Paths are relative to the directory being analyzed.
+	got, err = Churn(sub, "")
+	if err != nil {
+		t.Fatalf("Churn(sub): %v", err)
+	}
+	want := map[string]FileChurn{"b.go": {Commits: 1, Added: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sub churn = %v, want %v", got, want)
+	}
+}
+
+func TestParseNameLog(t *testing.T) {
+	out := "\x002222222222222222222222222222222222222222 200\x00\npkg/a.go\x00README.md\x00" +
+		"\x001111111111111111111111111111111111111111 100\x00\npkg/a.go\x00commit 3333 300\x00"
+	got, err := ParseNameLog(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("ParseNameLog: %v", err)
+	}
+	want := map[string]FileTimes{
+		"pkg/a.go":  {Created: time.Unix(100, 0), LastChanged: time.Unix(200, 0)},
+		"README.md": {Created: time.Unix(200, 0), LastChanged: time.Unix(200, 0)},
+		// A file named like a header is still a file.
+		"commit 3333 300": {Created: time.Unix(100, 0), LastChanged: time.Unix(100, 0)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTimes(t *testing.T) {
+	dir := initRepo(t)
+
+	got, err := Times(dir)
+	if err != nil {
+		t.Fatalf("Times: %v", err)
+	}
+	a, ok := got["a.go"]
+	if !ok || a.Created.IsZero() || !a.Created.Equal(a.LastChanged) {
+		t.Errorf("a.go times = %+v, want a single commit", a)
+	}
+	if _, ok := got["new.go"]; ok {
+		t.Error("untracked file has history")
+	}
+
+	if _, err := Times(t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Times outside a repository: err = %v, want ErrNotRepository", err)
+	}
+}
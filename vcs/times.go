@@ -0,0 +1,58 @@
+package vcs
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileTimes records when a file was first added and last changed.
+type FileTimes struct {
+	Created     time.Time
+	LastChanged time.Time
+}
+
+// ParseNameLog reads the output of
+// "git log -z --name-only --format=%x00%H %ct" and returns the commit times
+// of the oldest and newest commit touching each file.
+func ParseNameLog(r io.Reader) (map[string]FileTimes, error) {
+	times := make(map[string]FileTimes)
+	var current time.Time
+	err := scanLog(r, func(header string) {
+		fields := strings.Fields(header)
+		if len(fields) == 2 {
+			if sec, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				current = time.Unix(sec, 0)
+			}
+		}
+	}, func(path string) {
+		ft, seen := times[path]
+		if !seen || current.After(ft.LastChanged) {
+			ft.LastChanged = current
+		}
+		if !seen || current.Before(ft.Created) {
+			ft.Created = current
+		}
+		times[path] = ft
+	})
+	if err != nil {
+		return nil, err
+	}
+	return times, nil
+}
+
+// Times returns the creation and last change times of every file in the
+// history of dir, keyed by slash-separated paths relative to dir. Renames are
+// not followed, so a moved file is as old as its move.
+func Times(dir string) (map[string]FileTimes, error) {
+	if _, err := RepoRoot(dir); err != nil {
+		return nil, err
+	}
+	out, err := runGitLog(dir, "--name-only", "--no-renames", "--relative", "--format="+commitHeader+"%H %ct", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	return ParseNameLog(bytes.NewReader(out))
+}